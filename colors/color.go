@@ -46,6 +46,47 @@ func (c Color) RGBA() (r, g, b, a uint32) {
 		maxUInt32
 }
 
+// AlphaColor is a Color with an opacity A, ranging from 0 (fully transparent) to 1 (opaque)
+type AlphaColor struct {
+	Color
+	A float64
+}
+
+// Transparent is the fully transparent color
+var Transparent = AlphaColor{}
+
+// WithAlpha returns the color with opacity a
+func (c Color) WithAlpha(a float64) AlphaColor {
+	return AlphaColor{Color: c, A: a}
+}
+
+// RGBA returns the alpha-premultiplied color, as required by color.Color
+func (c AlphaColor) RGBA() (r, g, b, a uint32) {
+	return uint32(maxUInt32 * gamma(c.R) * c.A),
+		uint32(maxUInt32 * gamma(c.G) * c.A),
+		uint32(maxUInt32 * gamma(c.B) * c.A),
+		uint32(maxUInt32 * c.A)
+}
+
+// IsTransparent returns true if nothing behind the color is obscured by it
+func (c AlphaColor) IsTransparent() bool {
+	return c.A <= 0
+}
+
+// IsOpaque returns true if nothing behind the color shows through it
+func (c AlphaColor) IsOpaque() bool {
+	return c.A >= 1
+}
+
+// Over composites the color on top of the background color d
+func (c AlphaColor) Over(d Color) Color {
+	return Color{
+		c.R*c.A + d.R*(1-c.A),
+		c.G*c.A + d.G*(1-c.A),
+		c.B*c.A + d.B*(1-c.A),
+	}
+}
+
 func (c Color) String() string {
 	return fmt.Sprintf("#%02x%02x%02x", floatToUInt32(c.R), floatToUInt32(c.G), floatToUInt32(c.B))
 }
@@ -86,6 +127,35 @@ func (c Color) Add(d Color) Color {
 	}
 }
 
+// AddUnclamped adds the color components of the two colors without clamping, for summing light,
+// which can be brighter than white
+func (c Color) AddUnclamped(d Color) Color {
+	return Color{
+		c.R + d.R,
+		c.G + d.G,
+		c.B + d.B,
+	}
+}
+
+// Multiply the color components of the two colors, used to filter a color through another one,
+// such as light reflecting off a colored surface
+func (c Color) Multiply(d Color) Color {
+	return Color{
+		c.R * d.R,
+		c.G * d.G,
+		c.B * d.B,
+	}
+}
+
+// Scale multiplies each color component by r, without clamping
+func (c Color) Scale(r float64) Color {
+	return Color{
+		c.R * r,
+		c.G * r,
+		c.B * r,
+	}
+}
+
 func GrayscaleColor(v float64) Color {
 	return Color{
 		R: v,
@@ -116,6 +186,24 @@ func Hex(s string) Color {
 	return c
 }
 
+// FromColor converts a color, such as the pixel of an image, into an AlphaColor, reversing the gamma
+// correction of Color.RGBA
+func FromColor(c color.Color) AlphaColor {
+	r, g, b, a := c.RGBA()
+	if a == 0 {
+		return Transparent
+	}
+	// the color's channels are premultiplied by its alpha
+	return AlphaColor{
+		Color: Color{
+			R: inverseGamma(float64(r) / float64(a)),
+			G: inverseGamma(float64(g) / float64(a)),
+			B: inverseGamma(float64(b) / float64(a)),
+		},
+		A: float64(a) / maxUInt32,
+	}
+}
+
 // h,s,v each range from 0 to 1
 func HSL(h, s, l float64) Color {
 	r, g, b, err := colorconv.HSLToRGB(h*360, s, l)
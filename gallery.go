@@ -165,7 +165,13 @@ var (
 	SpinningTriangle = scenes.SingleSpinningTriangle(scenes.BackgroundFromTexture(textures.StaticTexture(textures.Uniform(colors.Blue))))
 	SpinningHolyCube = scenes.SpinningIndividualMulticubeWithHoles(scenes.BackgroundFromTexture(textures.StaticTexture(textures.Uniform(colors.Blue))))
 	// TODO: fix
-	HeightMap = scenes.HeightMap(blackBackground)
+	HeightMap    = scenes.HeightMap(blackBackground)
+	LitHeightMap = scenes.HeightMap(blackBackground).WithLights(
+		scenes.StaticLight(scenes.AmbientLight{Color: colors.White, Intensity: 0.1}),
+		scenes.StaticLight(scenes.DirectionalLight{Direction: geometry.V3(0.6, -1, -0.4), Color: colors.White, Intensity: 1.2}),
+	)
+
+	CheckerboardHeightMap = scenes.CheckerboardHeightMap(scenes.BackgroundFromTexture(textures.StaticTexture(textures.Uniform(colors.Blue))))
 
 	SpinningTriangleWithHole = scenes.CheckerboardSquareWithRoundHole(
 		scenes.BackgroundFromTexture(
@@ -182,23 +188,98 @@ var (
 		),
 	)
 
-	Noise                      = scenes.NoiseTest()
 	SquaresAlongPath           = scenes.SquaresAlongPath(blackBackground)
 	SquaresAlongPathWithCamera = scenes.CameraThroughSquaresAlongPath(blackBackground)
 	ThreeSpheres               = scenes.ThreeSpheres(blackBackground)
+	LitThreeSpheres            = scenes.ThreeSpheres(blackBackground).WithLights(scenes.DefaultLights()...)
+	LitSpinningCube            = scenes.DummySpinningCube(blackBackground).WithLights(scenes.DefaultLights()...)
 	NineSpheres                = scenes.NineSpheres(blackBackground)
-	OneBigSphere               = scenes.OneBigSphere(blackBackground)
-	CameraWithAxisTriangles    = scenes.CameraWithAxisTriangles(blackBackground)
-	// Perlin = scenes.NewPerlinNoise(color.Grayscale)
-	PerlinColors                  = scenes.PerlinColors()
-	ColorRotation                 = scenes.ColorRotation()
-	HeightMapCross                = scenes.HeightMapCross(blackBackground)
-	ShuffledColorRotation         = scenes.ShuffledColorRotation()
-	FourColorSquares              = scenes.FourColorSquares()
-	VerticalLineConcentricCircles = scenes.VerticalLineConcentricCircles()
-	VerticalWiggler               = scenes.VerticalWiggler()
-	ShuffledConcentricCircles     = scenes.ShuffledConcentricCircles()
-	ConcentricCircles             = scenes.ConcentricCircles()
-	IntegratedSpinners            = scenes.IntegratedSpinners()
-	IntegratedCrossColors         = scenes.IntegratedCrossColors()
+	LitNineSpheres             = scenes.NineSpheres(blackBackground).WithLights(
+		scenes.StaticLight(scenes.AmbientLight{Color: colors.White, Intensity: 0.1}),
+		scenes.StaticLight(scenes.AreaLight{
+			Corner:    geometry.Pt(-6, 6, 2),
+			Edge1:     geometry.V3(2, 0, 0),
+			Edge2:     geometry.V3(0, 0, 2),
+			Samples:   4,
+			Color:     colors.White,
+			Intensity: 120,
+		}),
+	)
+	OneBigSphere            = scenes.OneBigSphere(blackBackground)
+	SemiTransparentCube     = scenes.SemiTransparentCube(blackBackground)
+	FocusPull               = scenes.FocusPull(blackBackground)
+	CameraWithAxisTriangles = scenes.CameraWithAxisTriangles(blackBackground)
+	MirrorAndGlass          = scenes.MirrorAndGlass(
+		scenes.BackgroundFromTexture(textures.StaticTexture(textures.VerticalGradient{
+			Gradient: colors.SimpleGradient{Start: colors.Hex("#203040"), End: colors.Hex("#A0C0E0")},
+		})),
+	)
+	HeightMapCross = scenes.HeightMapCross(blackBackground)
+	CSGShapes      = scenes.CSGShapes(
+		scenes.BackgroundFromTexture(textures.StaticTexture(textures.VerticalGradient{
+			Gradient: colors.SimpleGradient{Start: colors.Hex("#203040"), End: colors.Hex("#A0C0E0")},
+		})),
+	)
+	Primitives = scenes.Primitives(
+		scenes.BackgroundFromTexture(textures.StaticTexture(textures.VerticalGradient{
+			Gradient: colors.SimpleGradient{Start: colors.Hex("#203040"), End: colors.Hex("#A0C0E0")},
+		})),
+	)
+	SDFShapes = scenes.SDFShapes(
+		scenes.BackgroundFromTexture(textures.StaticTexture(textures.VerticalGradient{
+			Gradient: colors.SimpleGradient{Start: colors.Hex("#203040"), End: colors.Hex("#A0C0E0")},
+		})),
+	)
 )
+
+// galleryEntry registers a scene of the gallery, which is constructed along with the gallery
+func galleryEntry(name, description string, scene scenes.DynamicScene) scenes.Entry {
+	return scenes.Entry{
+		Name:        name,
+		Description: description,
+		Scene:       func() scenes.DynamicScene { return scene },
+	}
+}
+
+// withPresets sets the presets that the scene is rendered with, unless others are requested
+func withPresets(entry scenes.Entry, image, video string) scenes.Entry {
+	entry.ImagePreset = image
+	entry.VideoPreset = video
+	return entry
+}
+
+func init() {
+	scenes.Register(
+		galleryEntry("EinsteinOnTheBeach", "A fuzzy vertical gradient in the colors of Einstein on the Beach", EinsteinOnTheBeach),
+		galleryEntry("SwivelLines", "A square of wiggling lines, swiveling in front of more of them", SwivelLines),
+		galleryEntry("CharMap", "A background of characters, picked by Perlin noise", CharMap),
+		galleryEntry("MinecraftCube", "A spinning cube of characters, picked by Perlin noise", MinecraftCube),
+		galleryEntry("RoundedSquare", "A white rounded square on black", RoundedSquare),
+		galleryEntry("MulticubeContracting", "Cubes with red, green and blue gradients dancing in and out", MulticubeContracting),
+		galleryEntry("SpinningMulticube", "A spinning cube made of smaller cubes", SpinningMulticube),
+		galleryEntry("Checkerboard", "A spinning checkerboard square", Checkckerboard),
+		galleryEntry("SpinningTriangle", "A single spinning triangle", SpinningTriangle),
+		galleryEntry("SpinningHolyCube", "A spinning cube of smaller cubes with holes in their faces", SpinningHolyCube),
+		galleryEntry("HeightMap", "A height map of Perlin noise", HeightMap),
+		galleryEntry("LitHeightMap", "A height map of Perlin noise, lit by ambient and directional light", LitHeightMap),
+		galleryEntry("CheckerboardHeightMap", "A spinning height map of Perlin noise, covered by one checkerboard", CheckerboardHeightMap),
+		galleryEntry("SpinningTriangleWithHole", "A spinning checkerboard square with a round hole", SpinningTriangleWithHole),
+		galleryEntry("SquaresAlongPath", "Squares placed along a path", SquaresAlongPath),
+		galleryEntry("SquaresAlongPathWithCamera", "The camera flying along a path of squares", SquaresAlongPathWithCamera),
+		galleryEntry("ThreeSpheres", "Three spheres", ThreeSpheres),
+		galleryEntry("LitThreeSpheres", "Three spheres, lit by the default lights", LitThreeSpheres),
+		galleryEntry("LitSpinningCube", "A spinning cube, lit by the default lights", LitSpinningCube),
+		galleryEntry("NineSpheres", "A grid of nine spheres", NineSpheres),
+		galleryEntry("LitNineSpheres", "A grid of nine spheres, with soft shadows from an area light", LitNineSpheres),
+		galleryEntry("OneBigSphere", "A single large sphere", OneBigSphere),
+		galleryEntry("SemiTransparentCube", "A semi-transparent cube in front of other objects", SemiTransparentCube),
+		// depth of field needs several samples per pixel
+		withPresets(galleryEntry("FocusPull", "Depth of field with the focus pulled between near and far spheres", FocusPull), "hidef", "intermediate"),
+		galleryEntry("CameraWithAxisTriangles", "The camera moving around triangles along each axis", CameraWithAxisTriangles),
+		galleryEntry("MirrorAndGlass", "A mirror sphere and a spinning glass cube over a checkerboard floor", MirrorAndGlass),
+		galleryEntry("HeightMapCross", "A height map of a rotating cross", HeightMapCross),
+		galleryEntry("CSGShapes", "A sphere bitten by a cube, a lens and a hemisphere, combined by CSG, above an endless floor", CSGShapes),
+		galleryEntry("Primitives", "A torus, a cone and a disc spinning on a ground plane, between rows of pillars", Primitives),
+		galleryEntry("SDFShapes", "Shapes of signed distance functions, blended together and sphere traced, spinning above a ground plane", SDFShapes),
+	)
+}
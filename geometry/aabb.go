@@ -0,0 +1,143 @@
+package geometry
+
+import (
+	"fmt"
+	"math"
+)
+
+var (
+	// EmptyAABB contains no points, the union of it with any other box is that other box
+	EmptyAABB = AABB{
+		Min: Point{math.Inf(1), math.Inf(1), math.Inf(1)},
+		Max: Point{math.Inf(-1), math.Inf(-1), math.Inf(-1)},
+	}
+	// InfiniteAABB contains all points, it bounds objects that extend infinitely, like planes
+	InfiniteAABB = AABB{
+		Min: Point{math.Inf(-1), math.Inf(-1), math.Inf(-1)},
+		Max: Point{math.Inf(1), math.Inf(1), math.Inf(1)},
+	}
+)
+
+// AABB is an axis-aligned bounding box in 3D space
+type AABB struct {
+	Min Point
+	Max Point
+}
+
+// AABBFromPoints returns the smallest box containing all the points
+func AABBFromPoints(points ...Point) AABB {
+	box := EmptyAABB
+	for _, p := range points {
+		box = box.AddPoint(p)
+	}
+	return box
+}
+
+func (b AABB) String() string {
+	return fmt.Sprintf("AABB(%s %s)", b.Min, b.Max)
+}
+
+func (b AABB) IsEmpty() bool {
+	return b.Min.X > b.Max.X || b.Min.Y > b.Max.Y || b.Min.Z > b.Max.Z
+}
+
+func (b AABB) AddPoint(p Point) AABB {
+	return AABB{
+		Min: Point{min(b.Min.X, p.X), min(b.Min.Y, p.Y), min(b.Min.Z, p.Z)},
+		Max: Point{max(b.Max.X, p.X), max(b.Max.Y, p.Y), max(b.Max.Z, p.Z)},
+	}
+}
+
+func (b AABB) Union(c AABB) AABB {
+	return AABB{
+		Min: Point{min(b.Min.X, c.Min.X), min(b.Min.Y, c.Min.Y), min(b.Min.Z, c.Min.Z)},
+		Max: Point{max(b.Max.X, c.Max.X), max(b.Max.Y, c.Max.Y), max(b.Max.Z, c.Max.Z)},
+	}
+}
+
+func (b AABB) Centroid() Point {
+	return Point{(b.Min.X + b.Max.X) / 2, (b.Min.Y + b.Max.Y) / 2, (b.Min.Z + b.Max.Z) / 2}
+}
+
+// LongestAxis returns the axis along which the box is the widest, 0 for X, 1 for Y, 2 for Z
+func (b AABB) LongestAxis() int {
+	d := b.Max.Subtract(b.Min)
+	if d.X >= d.Y && d.X >= d.Z {
+		return 0
+	}
+	if d.Y >= d.Z {
+		return 1
+	}
+	return 2
+}
+
+func (b AABB) SurfaceArea() float64 {
+	if b.IsEmpty() {
+		return 0
+	}
+	d := b.Max.Subtract(b.Min)
+	return 2 * (d.X*d.Y + d.Y*d.Z + d.Z*d.X)
+}
+
+// IntersectRay returns the range of ray parameters (tMin, tMax) for which the ray is inside the box,
+// clipped to (0, maxT). The bool is false if the ray misses the box within that range.
+// invD is the component-wise inverse of the ray direction, so that it can be reused across boxes.
+func (b AABB) IntersectRay(r Ray, invD Vector3D, maxT float64) (float64, float64, bool) {
+	tMin, tMax := 0.0, maxT
+	for axis := range 3 {
+		inv := invD.Axis(axis)
+		origin := r.P.Axis(axis)
+		t0 := (b.Min.Axis(axis) - origin) * inv
+		t1 := (b.Max.Axis(axis) - origin) * inv
+		if inv < 0 {
+			t0, t1 = t1, t0
+		}
+		// NaN comparisons are false, which keeps the existing bound for rays parallel to a face
+		if t0 > tMin {
+			tMin = t0
+		}
+		if t1 < tMax {
+			tMax = t1
+		}
+		if tMin > tMax {
+			return 0, 0, false
+		}
+	}
+	return tMin, tMax, true
+}
+
+// Intersect returns the box of the points inside both boxes, which is empty if they don't overlap
+func (b AABB) Intersect(c AABB) AABB {
+	return AABB{
+		Min: Point{max(b.Min.X, c.Min.X), max(b.Min.Y, c.Min.Y), max(b.Min.Z, c.Min.Z)},
+		Max: Point{min(b.Max.X, c.Max.X), min(b.Max.Y, c.Max.Y), min(b.Max.Z, c.Max.Z)},
+	}
+}
+
+// Corners returns the corners of the box, the i-th corner is at the maximum along X if bit 0 of i is set,
+// along Y for bit 1 and along Z for bit 2
+func (b AABB) Corners() [8]Point {
+	var corners [8]Point
+	for i := range corners {
+		corners[i] = b.Min
+		if i&1 != 0 {
+			corners[i].X = b.Max.X
+		}
+		if i&2 != 0 {
+			corners[i].Y = b.Max.Y
+		}
+		if i&4 != 0 {
+			corners[i].Z = b.Max.Z
+		}
+	}
+	return corners
+}
+
+// Expand returns the box grown by d on every side
+func (b AABB) Expand(d float64) AABB {
+	if b.IsEmpty() {
+		return b
+	}
+	v := Vector3D{d, d, d}
+	return AABB{Min: Point(b.Min.Vector().AddVector(v.ScalarMultiply(-1))), Max: Point(b.Max.Vector().AddVector(v))}
+}
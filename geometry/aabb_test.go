@@ -0,0 +1,68 @@
+package geometry
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAABBIntersectRay(t *testing.T) {
+	box := AABB{Min: Point{-1, -1, -3}, Max: Point{1, 1, -2}}
+	tests := []struct {
+		name     string
+		ray      Ray
+		maxT     float64
+		wantOK   bool
+		wantTMin float64
+		wantTMax float64
+	}{
+		{"straight on", Ray{P: OriginPoint, D: Vector3D{0, 0, -1}}, math.Inf(1), true, 2, 3},
+		{"diagonal", Ray{P: OriginPoint, D: Vector3D{0.4, 0.4, -1}}, math.Inf(1), true, 2, 2.5},
+		{"parallel to face", Ray{P: Point{0.5, 0, 0}, D: Vector3D{0, 0, -1}}, math.Inf(1), true, 2, 3},
+		{"miss to the side", Ray{P: OriginPoint, D: Vector3D{1, 0, -1}}, math.Inf(1), false, 0, 0},
+		{"pointing away", Ray{P: OriginPoint, D: Vector3D{0, 0, 1}}, math.Inf(1), false, 0, 0},
+		{"starts inside", Ray{P: Point{0, 0, -2.5}, D: Vector3D{0, 0, -1}}, math.Inf(1), true, 0, 0.5},
+		{"stops short", Ray{P: OriginPoint, D: Vector3D{0, 0, -1}}, 1.5, false, 0, 0},
+		{"stops inside", Ray{P: OriginPoint, D: Vector3D{0, 0, -1}}, 2.5, true, 2, 2.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tMin, tMax, ok := box.IntersectRay(tt.ray, tt.ray.InverseDirection(), tt.maxT)
+			if ok != tt.wantOK {
+				t.Fatalf("wanted intersection %v, got %v", tt.wantOK, ok)
+			}
+			if !ok {
+				return
+			}
+			if diff := cmp.Diff([]float64{tt.wantTMin, tt.wantTMax}, []float64{tMin, tMax}, approxFloatOpt); diff != "" {
+				t.Errorf("failure, diff: %s", diff)
+			}
+		})
+	}
+}
+
+func TestAABBIntersect(t *testing.T) {
+	box := AABB{Min: Point{-1, -1, -1}, Max: Point{1, 1, 1}}
+	tests := []struct {
+		name  string
+		other AABB
+		want  AABB
+	}{
+		{"overlapping", AABB{Min: Point{0, -2, 0.5}, Max: Point{2, 2, 2}}, AABB{Min: Point{0, -1, 0.5}, Max: Point{1, 1, 1}}},
+		{"inside", AABB{Min: Point{-0.5, -0.5, -0.5}, Max: Point{0.5, 0.5, 0.5}}, AABB{Min: Point{-0.5, -0.5, -0.5}, Max: Point{0.5, 0.5, 0.5}}},
+		{"infinite", InfiniteAABB, box},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, box.Intersect(tt.other)); diff != "" {
+				t.Errorf("Intersect() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+	if !box.Intersect(AABB{Min: Point{2, 2, 2}, Max: Point{3, 3, 3}}).IsEmpty() {
+		t.Errorf("wanted disjoint boxes to have an empty intersection")
+	}
+}
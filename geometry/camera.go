@@ -0,0 +1,182 @@
+package geometry
+
+import (
+	"fmt"
+	"math"
+)
+
+var (
+	// DefaultCamera is a perspective camera with a 90° field of view, which maps the screen corners
+	// (±1, ±1) to rays along (±1, ±1, -1)
+	DefaultCamera = Camera{
+		FOV:           math.Pi / 2,
+		AspectRatio:   1,
+		Near:          0.01,
+		Height:        2,
+		FocusDistance: 1,
+	}
+)
+
+// Camera describes how camera space, where the camera is at the origin looking down the negative z-axis
+// with y pointing up, is projected onto the screen. Screen coordinates range from -1 to 1 in both
+// x and y, regardless of the aspect ratio.
+type Camera struct {
+	FOV          float64 // vertical field of view, in radians, only used for perspective projection
+	AspectRatio  float64 // width/height of the screen, 0 means it is taken from the image being rendered
+	Near         float64 // distance to the near plane, anything closer to the camera is not seen
+	Orthographic bool    // if true, all rays are parallel to the z-axis, otherwise they start at the origin
+	Height       float64 // height of the visible area, only used for orthographic projection
+
+	// thin-lens depth of field, rays through a pixel start across a lens disc of radius Aperture,
+	// and converge at FocusDistance. An Aperture of 0 is a pinhole camera, with everything in focus.
+	Aperture      float64
+	FocusDistance float64 // z-depth that is in perfect focus
+}
+
+// WithDefaults returns a copy of the camera, with any unset values replaced by those of DefaultCamera
+func (c Camera) WithDefaults() Camera {
+	if c.FOV == 0 {
+		c.FOV = DefaultCamera.FOV
+	}
+	if c.AspectRatio == 0 {
+		c.AspectRatio = DefaultCamera.AspectRatio
+	}
+	if c.Near == 0 {
+		c.Near = DefaultCamera.Near
+	}
+	if c.Height == 0 {
+		c.Height = DefaultCamera.Height
+	}
+	if c.FocusDistance == 0 {
+		c.FocusDistance = DefaultCamera.FocusDistance
+	}
+	return c
+}
+
+// WithAspectRatio returns a copy of the camera with the aspect ratio, if it doesn't have one already
+func (c Camera) WithAspectRatio(ratio float64) Camera {
+	if c.AspectRatio == 0 {
+		c.AspectRatio = ratio
+	}
+	return c
+}
+
+func (c Camera) String() string {
+	if c.Orthographic {
+		return fmt.Sprintf("Camera(orthographic, height %.3f, aspect %.3f, near %.3f)", c.Height, c.AspectRatio, c.Near)
+	}
+	return fmt.Sprintf("Camera(fov %.3f, aspect %.3f, near %.3f)", c.FOV, c.AspectRatio, c.Near)
+}
+
+// halfExtent returns the size of half the screen at unit depth for perspective cameras,
+// or half of the visible area for orthographic ones
+func (c Camera) halfExtent() (float64, float64) {
+	var y float64
+	if c.Orthographic {
+		y = c.Height / 2
+	} else {
+		y = math.Tan(c.FOV / 2)
+	}
+	return y * c.AspectRatio, y
+}
+
+// IsInFront returns true if the point is beyond the near plane
+func (c Camera) IsInFront(p Point) bool {
+	return p.IsInFrontOfCamera(-c.Near)
+}
+
+// ToPixel returns the screen coordinates of the point, as seen through the center of the lens,
+// as well as its z-depth (not actual depth), or nil if the point is behind the camera
+func (c Camera) ToPixel(p Point) (*Pixel, float64) {
+	return c.LensToPixel(p, Vector2D{})
+}
+
+// LensToPixel returns the screen coordinates of the point, as seen through the point lens on the
+// unit disc of the lens, as well as its z-depth (not actual depth), or nil if the point is behind the camera.
+// It is the inverse of LensRay.
+func (c Camera) LensToPixel(p Point, lens Vector2D) (*Pixel, float64) {
+	if p.Z > 0 {
+		return nil, 0
+	}
+	hx, hy := c.halfExtent()
+	lx, ly := c.lensOffset(lens)
+	// slope of rays from the lens point towards the pixel, relative to rays from the center of the lens
+	var sx, sy float64
+	if lx != 0 || ly != 0 {
+		sx, sy = lx/c.FocusDistance, ly/c.FocusDistance
+	}
+	depth := -p.Z
+	if c.Orthographic {
+		return &Pixel{
+			(p.X - lx + depth*sx) / hx,
+			(p.Y - ly + depth*sy) / hy,
+		}, depth
+	}
+	return &Pixel{
+		((p.X-lx)/depth + sx) / hx,
+		((p.Y-ly)/depth + sy) / hy,
+	}, depth
+}
+
+// Ray returns the ray through the screen coordinates (x,y). Its direction has a z-component of -1,
+// so that the ray parameter of a point on it is the point's z-depth.
+func (c Camera) Ray(x, y float64) Ray {
+	hx, hy := c.halfExtent()
+	if c.Orthographic {
+		return Ray{P: Pt(x*hx, y*hy, 0), D: V3(0, 0, -1)}
+	}
+	return Ray{P: OriginPoint, D: V3(x*hx, y*hy, -1)}
+}
+
+// LensRay returns the ray through the screen coordinates (x,y), starting at the point lens on the unit disc
+// of the lens. All rays through (x,y) meet at the focus distance. As with Ray, the direction has a z-component of -1.
+func (c Camera) LensRay(x, y float64, lens Vector2D) Ray {
+	r := c.Ray(x, y)
+	lx, ly := c.lensOffset(lens)
+	if lx == 0 && ly == 0 {
+		return r
+	}
+	focus := r.PointAt(c.FocusDistance)
+	origin := Pt(r.P.X+lx, r.P.Y+ly, 0)
+	return Ray{P: origin, D: focus.Subtract(origin).ScalarMultiply(1 / c.FocusDistance)}
+}
+
+// PixelRay returns LensRay(x, y, lens), along with the rays through the neighboring pixels,
+// which are dx and dy away from it along the x and y axes
+func (c Camera) PixelRay(x, y, dx, dy float64, lens Vector2D) Ray {
+	r := c.LensRay(x, y, lens)
+	r.Differential = &RayDifferential{
+		X: c.LensRay(x+dx, y, lens),
+		Y: c.LensRay(x, y+dy, lens),
+	}
+	return r
+}
+
+// lensOffset returns the offset from the center of the lens, of the point lens on the unit disc
+func (c Camera) lensOffset(lens Vector2D) (float64, float64) {
+	return lens.X * c.Aperture, lens.Y * c.Aperture
+}
+
+// RayToScreen returns the screen coordinates that the ray r would be seen at, clamped to the screen.
+// For perspective cameras this is where its direction would be seen from the origin, mirroring directions
+// pointing behind the camera to the front. For orthographic cameras, whose rays start on the screen,
+// it is where the line of the ray crosses the screen. This is used to look up the background of secondary rays.
+func (c Camera) RayToScreen(r Ray) Pixel {
+	hx, hy := c.halfExtent()
+	if c.Orthographic {
+		dz := r.D.Z
+		if math.Abs(dz) < 1e-9 {
+			dz = math.Copysign(1e-9, dz)
+		}
+		t := r.P.Z / dz
+		return Pixel{
+			max(-1, min(1, (r.P.X-t*r.D.X)/hx)),
+			max(-1, min(1, (r.P.Y-t*r.D.Y)/hy)),
+		}
+	}
+	z := max(math.Abs(r.D.Z), 1e-9)
+	return Pixel{
+		max(-1, min(1, r.D.X/(z*hx))),
+		max(-1, min(1, r.D.Y/(z*hy))),
+	}
+}
@@ -0,0 +1,100 @@
+package geometry
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCameraToPixel(t *testing.T) {
+	tests := []struct {
+		name      string
+		camera    Camera
+		point     Point
+		wantPixel Pixel
+		wantDepth float64
+	}{
+		{"default center", DefaultCamera, Point{0, 0, -2}, Pixel{0, 0}, 2},
+		{"default corner", DefaultCamera, Point{2, -2, -2}, Pixel{1, -1}, 2},
+		{"narrow fov", Camera{FOV: 2 * math.Atan(0.5), AspectRatio: 1}, Point{1, 1, -2}, Pixel{1, 1}, 2},
+		{"widescreen", Camera{FOV: math.Pi / 2, AspectRatio: 2}, Point{4, 2, -2}, Pixel{1, 1}, 2},
+		{"orthographic", Camera{Orthographic: true, Height: 4, AspectRatio: 0.5}, Point{1, 2, -5}, Pixel{1, 1}, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pixel, depth := tt.camera.ToPixel(tt.point)
+			if pixel == nil {
+				t.Fatalf("point %s is unexpectedly behind the camera", tt.point)
+			}
+			if diff := cmp.Diff([]float64{tt.wantPixel.X, tt.wantPixel.Y, tt.wantDepth}, []float64{pixel.X, pixel.Y, depth}, approxFloatOpt); diff != "" {
+				t.Errorf("failure, diff: %s", diff)
+			}
+			// the ray through the pixel should pass through the point, at a ray parameter equal to its depth
+			ray := tt.camera.Ray(pixel.X, pixel.Y)
+			got := ray.PointAt(depth)
+			if diff := cmp.Diff([]float64{tt.point.X, tt.point.Y, tt.point.Z}, []float64{got.X, got.Y, got.Z}, approxFloatOpt); diff != "" {
+				t.Errorf("ray doesn't pass through point, diff: %s", diff)
+			}
+		})
+	}
+}
+
+func TestCameraLensRay(t *testing.T) {
+	cameras := []struct {
+		name   string
+		camera Camera
+	}{
+		{"perspective", Camera{FOV: 1, AspectRatio: 1.5, Aperture: 0.2, FocusDistance: 4}.WithDefaults()},
+		{"orthographic", Camera{Orthographic: true, Height: 3, AspectRatio: 0.5, Aperture: 0.3, FocusDistance: 2}.WithDefaults()},
+	}
+	lenses := []Vector2D{{0, 0}, {1, 0}, {-0.3, 0.6}}
+
+	for _, tt := range cameras {
+		t.Run(tt.name, func(t *testing.T) {
+			focus := tt.camera.Ray(0.3, -0.5).PointAt(tt.camera.FocusDistance)
+			for _, lens := range lenses {
+				ray := tt.camera.LensRay(0.3, -0.5, lens)
+				// rays through the same pixel converge at the focus distance
+				got := ray.PointAt(tt.camera.FocusDistance)
+				if diff := cmp.Diff([]float64{focus.X, focus.Y, focus.Z}, []float64{got.X, got.Y, got.Z}, approxFloatOpt); diff != "" {
+					t.Errorf("lens %s doesn't focus, diff: %s", lens, diff)
+				}
+				// points along the ray project back onto the same pixel through the same point on the lens
+				pixel, depth := tt.camera.LensToPixel(ray.PointAt(7), lens)
+				if diff := cmp.Diff([]float64{0.3, -0.5, 7}, []float64{pixel.X, pixel.Y, depth}, approxFloatOpt); diff != "" {
+					t.Errorf("lens %s doesn't project back, diff: %s", lens, diff)
+				}
+			}
+		})
+	}
+}
+
+func TestCameraRayToScreen(t *testing.T) {
+	perspective := Camera{FOV: 1, AspectRatio: 1.5}.WithDefaults()
+	orthographic := Camera{Orthographic: true, Height: 4, AspectRatio: 0.5}.WithDefaults()
+	tests := []struct {
+		name   string
+		camera Camera
+		ray    Ray
+		want   Pixel
+	}{
+		{"perspective camera ray", perspective, perspective.Ray(0.3, -0.5), Pixel{0.3, -0.5}},
+		{"perspective offset ray", perspective, Ray{P: Pt(5, 5, -3), D: perspective.Ray(0.3, -0.5).D}, Pixel{0.3, -0.5}},
+		{"perspective behind camera", perspective, Ray{P: OriginPoint, D: perspective.Ray(0.3, -0.5).D.ScalarMultiply(-1)}, Pixel{-0.3, 0.5}},
+		{"perspective off screen", perspective, Ray{P: OriginPoint, D: V3(1, 0, -0.01)}, Pixel{1, 0}},
+		{"orthographic camera ray", orthographic, orthographic.Ray(0.3, -0.5), Pixel{0.3, -0.5}},
+		{"orthographic reflected ray", orthographic, Ray{P: Pt(0.1, 0.2, -2), D: V3(-0.5, 1, 2)}, Pixel{-0.4, 0.6}},
+		{"orthographic parallel ray", orthographic, Ray{P: Pt(0.1, 0.2, -2), D: V3(0, -1, 0)}, Pixel{0.1, -1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.camera.RayToScreen(tt.ray)
+			if diff := cmp.Diff([]float64{tt.want.X, tt.want.Y}, []float64{got.X, got.Y}, approxFloatOpt); diff != "" {
+				t.Errorf("failure, diff: %s", diff)
+			}
+		})
+	}
+}
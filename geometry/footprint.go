@@ -0,0 +1,53 @@
+package geometry
+
+import "math"
+
+// Footprint is the change in texture coordinates (u,v) from a pixel to its neighbors, along the x and y axes
+// of the image. It spans the parallelogram of the texture that is seen through the pixel.
+type Footprint struct {
+	DUDX, DVDX float64 // towards the next pixel along x
+	DUDY, DVDY float64 // towards the next pixel along y
+}
+
+// footprintSamples is the number of samples along each side of the footprint, see ForEachSample
+const footprintSamples = 2
+
+func (f Footprint) IsZero() bool {
+	return f == Footprint{}
+}
+
+// Extent returns the width and height of the smallest axis-aligned box around the footprint
+func (f Footprint) Extent() (float64, float64) {
+	return math.Abs(f.DUDX) + math.Abs(f.DUDY), math.Abs(f.DVDX) + math.Abs(f.DVDY)
+}
+
+// Transform returns the footprint in the coordinates (a*u + b*v, c*u + d*v)
+func (f Footprint) Transform(a, b, c, d float64) Footprint {
+	return Footprint{
+		DUDX: a*f.DUDX + b*f.DVDX,
+		DVDX: c*f.DUDX + d*f.DVDX,
+		DUDY: a*f.DUDY + b*f.DVDY,
+		DVDY: c*f.DUDY + d*f.DVDY,
+	}
+}
+
+// Scale returns the footprint in the coordinates (s*u, s*v)
+func (f Footprint) Scale(s float64) Footprint {
+	return f.Transform(s, 0, 0, s)
+}
+
+// ForEachSample calls fn with the points of a grid spread evenly across the footprint around (u,v)
+func (f Footprint) ForEachSample(u, v float64, fn func(u, v float64)) {
+	for i := range footprintSamples {
+		a := (float64(i)+0.5)/footprintSamples - 0.5
+		for j := range footprintSamples {
+			b := (float64(j)+0.5)/footprintSamples - 0.5
+			fn(u+a*f.DUDX+b*f.DUDY, v+a*f.DVDX+b*f.DVDY)
+		}
+	}
+}
+
+// SampleWeight returns the weight of each of the points of ForEachSample in their average
+func (f Footprint) SampleWeight() float64 {
+	return 1 / float64(footprintSamples*footprintSamples)
+}
@@ -46,9 +46,9 @@ type RasterLine struct {
 	BDepth float64
 }
 
-func newRasterLine(aP, bP Point) *RasterLine {
-	a, aDepth := aP.ToPixel()
-	b, bDepth := bP.ToPixel()
+func newRasterLine(aP, bP Point, c Camera) *RasterLine {
+	a, aDepth := c.ToPixel(aP)
+	b, bDepth := c.ToPixel(bP)
 	if a == nil || b == nil {
 		panic(fmt.Errorf("Point is unexpectedly behind camera %s %s", aP, bP))
 	}
@@ -109,6 +109,7 @@ func (l RasterLine) String() string {
 	return fmt.Sprintf("Raster Line (A: %s, B: %s)", l.A, l.B)
 }
 
-func (l Line) CropToScreenView() *RasterLine {
-	return newRasterLine(l.A, l.B)
+// CropToScreenView returns the line as projected onto the screen by the camera, cropped to the screen
+func (l Line) CropToScreenView(c Camera) *RasterLine {
+	return newRasterLine(l.A, l.B, c)
 }
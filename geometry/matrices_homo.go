@@ -191,13 +191,14 @@ func (m HomogeneusMatrix) Inverse() (HomogeneusMatrix, bool) {
 	if !ok {
 		return HomogeneusMatrix{}, false
 	}
-	// have to add upper right 1x3 vector
-	upperThird := Vector3D{m.D1, m.D2, m.D3}
-	afterUpperThird := upperInverse.MultVect(upperThird)
-	answer := upperInverse.toHomogenous()
-	answer.D1 = afterUpperThird.X
-	answer.D2 = afterUpperThird.Y
-	answer.D3 = afterUpperThird.Z
+	// the translation in the right column is undone after the inverse of the upper 3x3 matrix
+	translation := Vector3D{m.A4, m.B4, m.C4}
+	inverseTranslation := upperInverse.MultVect(translation).ScalarMultiply(-1 / m.D4)
+	answer := upperInverse.ScalarMult(1 / m.D4).toHomogenous()
+	answer.A4 = inverseTranslation.X
+	answer.B4 = inverseTranslation.Y
+	answer.C4 = inverseTranslation.Z
+	answer.D4 = 1 / m.D4
 
 	return answer, true
 }
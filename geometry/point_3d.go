@@ -45,17 +45,6 @@ func (p Point) IsInFrontOfCamera(minDepth float64) bool {
 	return p.Z < minDepth
 }
 
-// return Screen pixel, as well as the z-depth (not actual depth)
-func (p Point) ToPixel() (*Pixel, float64) {
-	if p.Z > 0 {
-		return nil, 0
-	}
-	return &Pixel{
-		p.X / -p.Z,
-		p.Y / -p.Z,
-	}, -p.Z
-}
-
 func (p Point) LinesAroundPoint() []Line {
 	uD := V3(0, .2, 0)
 	rD := V3(.2, 0, 0)
@@ -67,3 +56,15 @@ func (p Point) LinesAroundPoint() []Line {
 		{A: p, B: Point(p.Vector().AddVector(bD))},
 	}
 }
+
+// Axis returns the coordinate of p along the axis, 0 for X, 1 for Y, 2 for Z
+func (p Point) Axis(axis int) float64 {
+	switch axis {
+	case 0:
+		return p.X
+	case 1:
+		return p.Y
+	default:
+		return p.Z
+	}
+}
@@ -0,0 +1,33 @@
+package geometry
+
+import "fmt"
+
+// Ray is a half-line starting at point P, going in direction D.
+// D doesn't have to be a unit vector, points on the ray are P + t*D for t >= 0
+type Ray struct {
+	P Point    // origin point
+	D Vector3D // direction vector describing the ray
+
+	// optional rays through the neighboring pixels, nil for rays that aren't cast from the camera
+	Differential *RayDifferential
+}
+
+// RayDifferential holds the rays through the pixels next to that of a camera ray, which show
+// how much of a surface the pixel covers
+type RayDifferential struct {
+	X Ray // through the next pixel along the image's x axis
+	Y Ray // through the next pixel along the image's y axis
+}
+
+func (r Ray) PointAt(t float64) Point {
+	return Point(r.P.Vector().AddVector(r.D.ScalarMultiply(t)))
+}
+
+func (r Ray) String() string {
+	return fmt.Sprintf("Ray from %s towards %s", r.P, r.D)
+}
+
+// InverseDirection returns the component-wise inverse of the ray direction, for use with AABB.IntersectRay
+func (r Ray) InverseDirection() Vector3D {
+	return Vector3D{1 / r.D.X, 1 / r.D.Y, 1 / r.D.Z}
+}
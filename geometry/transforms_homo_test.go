@@ -31,3 +31,29 @@ func TestPointTowards(t *testing.T) {
 		})
 	}
 }
+
+func TestHomogeneusInverse(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		matrix HomogeneusMatrix
+	}{
+		{"translation", TranslationMatrix(V3(1, 2, 3))},
+		{"rotation", RotateMatrixY(0.7)},
+		{"scale", ScaleMatrix(2)},
+		{"combined", MatrixProduct(TranslationMatrix(V3(-1, 0, 4)), RotateMatrixX(1.1), ScaleMatrix(0.5))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inverse, ok := tt.matrix.Inverse()
+			if !ok {
+				t.Fatalf("Inverse() of %s is not valid", tt.matrix)
+			}
+			p := Pt(0.3, -2, 5).ToHomogenous()
+			if diff := cmp.Diff(p, inverse.MultVect(tt.matrix.MultVect(p)), approxFloatOpt); diff != "" {
+				t.Errorf("point not mapped back by the inverse, diff: %s", diff)
+			}
+		})
+	}
+}
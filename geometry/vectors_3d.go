@@ -75,3 +75,8 @@ func (v Vector3D) Unit() Vector3D {
 	}
 	return v.ScalarMultiply(1 / d)
 }
+
+// Axis returns the coordinate of v along the axis, 0 for X, 1 for Y, 2 for Z
+func (v Vector3D) Axis(axis int) float64 {
+	return Point(v).Axis(axis)
+}
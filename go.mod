@@ -3,11 +3,13 @@ module github.com/libeks/go-scene-renderer
 go 1.22
 
 require (
-	github.com/aquilax/go-perlin v1.1.0 // indirect
-	github.com/crazy3lf/colorconv v1.2.0 // indirect
-	github.com/google/go-cmp v0.6.0 // indirect
-	github.com/muesli/clusters v0.0.0-20200529215643-2700303c1762 // indirect
-	github.com/muesli/kmeans v0.3.1 // indirect
-	github.com/schollz/progressbar v1.0.0 // indirect
-	golang.org/x/sync v0.6.0 // indirect
+	github.com/aquilax/go-perlin v1.1.0
+	github.com/crazy3lf/colorconv v1.2.0
+	github.com/muesli/clusters v0.0.0-20200529215643-2700303c1762
+	github.com/muesli/kmeans v0.3.1
+	github.com/schollz/progressbar v1.0.0
+	golang.org/x/sync v0.6.0
+	gopkg.in/yaml.v3 v3.0.1
 )
+
+require github.com/google/go-cmp v0.6.0
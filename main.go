@@ -7,15 +7,48 @@ import (
 	"os"
 	"path/filepath"
 	"runtime/pprof"
+	"strings"
+	"text/tabwriter"
 
 	"github.com/libeks/go-scene-renderer/renderer"
+	"github.com/libeks/go-scene-renderer/scenefile"
+	"github.com/libeks/go-scene-renderer/scenes"
 )
 
 const (
-	PNG_FORMAT      = "png"
-	MP4_FORMAT      = "mp4"
-	do_pprof        = true
-	image_timestamp = 0.3
+	PNG_FORMAT  = "png"
+	MP4_FORMAT  = "mp4"
+	GIF_FORMAT  = "gif"
+	APNG_FORMAT = "apng"
+	PFM_FORMAT  = "pfm"
+	EXR_FORMAT  = "exr"
+	do_pprof    = true
+	allScenes   = "all" // value of -scene that renders every registered scene
+)
+
+var (
+	imageFlag       = flag.String("image", "default", "image options, either <width>,<height>,<interpolate> or one of default/test/intermediate/hidef/iphone/widescreen/vertical. The default is the scene's own preset, if it has one")
+	videoFlag       = flag.String("video", "default", "video options, either <width>,<height>,<interpolate>,<nframes>,<frameRate> or one of default/test/intermediate/hidef/iphone/widescreen/vertical. The default is the scene's own preset, if it has one")
+	sceneFlag       = flag.String("scene", "IntegratedCrossColors", "Name of the registered scene to render, or all to render every one of them, with %s in the output file replaced by the scene's name")
+	sceneFile       = flag.String("scene-file", "", "YAML or JSON file describing the scene to render, in place of a registered -scene")
+	listScenes      = flag.Bool("list-scenes", false, "List the registered scenes, with their descriptions and presets")
+	timestamp       = flag.Float64("t", 0.3, "Time within the scene's animation, between 0 and 1, at which still images are rendered")
+	wireframe       = flag.Bool("wireframe", false, "Render the scene only using triangle wireframes")
+	triDepth        = flag.Bool("tridepth", false, "Render only the number of triangles considered in each render window")
+	backendFlag     = flag.String("backend", "raycast", "Render backend, either raycast, or raster to rasterize triangles into a depth buffer")
+	rayDepth        = flag.Int("raydepth", -1, "Number of bounces for reflected and refracted rays, overrides the preset's value if non-negative")
+	samplerFlag     = flag.String("sampler", "random", "Placement of the interpolation samples within pixels, either random, stratified, halton or sobol")
+	filterFlag      = flag.String("filter", "box", "Reconstruction filter combining samples into pixels, either box, tent or mitchell")
+	adaptive        = flag.Float64("adaptive", 0, "If positive, pixels whose samples differ by more than this in any color channel get more samples")
+	pngFrames       = flag.Bool("pngframes", false, "Write video frames to PNG files in .tmp/ and encode them afterwards, rather than streaming them into ffmpeg")
+	resume          = flag.Bool("resume", false, "Keep the video frames in .tmp/ that an earlier render of the same scene and preset completed, only rendering the rest. Implies -pngframes")
+	loopCount       = flag.Int("loop", 0, "Number of times GIF and APNG animations are played, 0 loops forever")
+	framePalette    = flag.Bool("framepalette", false, "Quantize each GIF frame to its own palette, rather than one shared by all frames")
+	dither          = flag.Bool("dither", false, "Dither GIF frames when quantizing them to their palette")
+	coordinatorAddr = flag.String("coordinator", "", "If set, serve the frames of an mp4 video to workers on this address, e.g. :8080, rather than rendering them")
+	workerURL       = flag.String("worker", "", "If set, render frames for the coordinator at this URL, e.g. http://localhost:8080, started with the same scene and flags. No output file is needed")
+	frameFormatFlag = flag.String("frameformat", "png", "Format of PNG images and of video frames written with -pngframes, either png, png16, pfm, exr or exrzip. Images with a .pfm or .exr extension default to that format")
+	shutterAngle    = flag.Float64("shutter", 0, "Shutter angle in degrees for motion blur in videos, samples each frame over that part of the frame interval, combined with the interpolation count")
 )
 
 func main() {
@@ -31,49 +64,61 @@ func main() {
 		defer pprof.StopCPUProfile()
 	}
 
-	var imageFlag = flag.String("image", "default", "image options, either <width>,<height>,<interpolate> or one of default/test/hidef")
-	var videoFlag = flag.String("video", "default", "video options, either <width>,<height>,<interpolate>,<nframes>,<frameRate> or one of default/test/intermediate/hidef")
-	var wireframe = flag.Bool("wireframe", false, "Render the scene only using triangle wireframes")
-	var triDepth = flag.Bool("tridepth", false, "Render only the number of triangles considered in each render window")
-
 	flag.Parse()
+	if *listScenes {
+		printScenes()
+		return
+	}
 	argsWithoutProg := flag.Args()
-	if len(argsWithoutProg) != 1 {
+	if len(argsWithoutProg) != 1 && *workerURL == "" {
 		log.Fatal("Insufficient arguments, expect <outputfile>.")
 	}
 
-	scene := getScene()
-	outFile, err := filepath.Abs(argsWithoutProg[0])
-	if err != nil {
-		log.Fatalf("Invalid file path %s", err)
-	}
-	format := filepath.Ext(outFile)
-	if format == "" {
-		log.Fatalf("Please provide an output file with a correct extension")
-	}
-	format = format[1:]
-
-	switch format {
-	case PNG_FORMAT:
-		imagePreset, err := renderer.ParseImagePreset(*imageFlag)
+	var entries []scenes.Entry
+	if *sceneFile != "" {
+		entry, err := scenefile.Load(*sceneFile)
 		if err != nil {
 			log.Fatalf("%s", err)
 		}
-		err = renderer.RenderPNG(scene.GetFrame(image_timestamp), imagePreset, outFile, *wireframe, *triDepth)
+		entries = []scenes.Entry{entry}
+	} else if *sceneFlag == allScenes {
+		entries = scenes.Registered()
+	} else {
+		entry, err := scenes.Lookup(*sceneFlag)
 		if err != nil {
-			fmt.Printf("Failure %s\n", err)
+			log.Fatalf("%s", err)
+		}
+		entries = []scenes.Entry{entry}
+	}
+
+	if *workerURL != "" {
+		if len(entries) != 1 {
+			log.Fatalf("Workers render a single scene, not %s", *sceneFlag)
 		}
-	case MP4_FORMAT:
-		videoPreset, err := renderer.ParseVideoPreset(*videoFlag)
+		videoPreset, err := getVideoPreset(entries[0])
 		if err != nil {
 			log.Fatalf("%s", err)
 		}
-		err = renderer.RenderVideo(scene, videoPreset, outFile, *wireframe, *triDepth)
-		if err != nil {
+		if err := renderer.RunWorker(entries[0].Scene(), videoPreset, *workerURL, *wireframe, *triDepth); err != nil {
+			log.Fatalf("Failure %s", err)
+		}
+		return
+	}
+
+	outPattern := argsWithoutProg[0]
+	if len(entries) > 1 && !strings.Contains(outPattern, "%s") {
+		log.Fatalf("Rendering %d scenes, expect %%s in the output file to be replaced by each scene's name", len(entries))
+	}
+	failed := 0
+	for _, entry := range entries {
+		outFile := strings.ReplaceAll(outPattern, "%s", entry.Name)
+		if len(entries) > 1 {
+			fmt.Printf("Rendering %s to %s\n", entry.Name, outFile)
+		}
+		if err := renderScene(entry, outFile); err != nil {
 			fmt.Printf("Failure %s\n", err)
+			failed += 1
 		}
-	default:
-		log.Fatalf("Unknown format %s", format)
 	}
 
 	if do_pprof {
@@ -86,4 +131,143 @@ func main() {
 			log.Fatal("could not write memory profile: ", err)
 		}
 	}
+	if failed > 0 {
+		log.Fatalf("%d of %d scenes failed to render", failed, len(entries))
+	}
+}
+
+// renderScene renders the scene to the output file, in the format given by its extension
+func renderScene(entry scenes.Entry, outFile string) error {
+	outFile, err := filepath.Abs(outFile)
+	if err != nil {
+		return fmt.Errorf("invalid file path %s", err)
+	}
+	format := filepath.Ext(outFile)
+	if format == "" {
+		return fmt.Errorf("please provide an output file with a correct extension")
+	}
+	format = format[1:]
+
+	switch format {
+	case PNG_FORMAT, PFM_FORMAT, EXR_FORMAT:
+		imagePreset, err := getImagePreset(entry, format)
+		if err != nil {
+			return err
+		}
+		return renderer.RenderPNG(entry.Scene().GetFrame(*timestamp), imagePreset, outFile, *wireframe, *triDepth)
+	case MP4_FORMAT, GIF_FORMAT, APNG_FORMAT:
+		videoPreset, err := getVideoPreset(entry)
+		if err != nil {
+			return err
+		}
+		switch format {
+		case GIF_FORMAT:
+			return renderer.RenderGIF(entry.Scene(), videoPreset, outFile, *wireframe, *triDepth)
+		case APNG_FORMAT:
+			return renderer.RenderAPNG(entry.Scene(), videoPreset, outFile, *wireframe, *triDepth)
+		default:
+			if *coordinatorAddr != "" {
+				return renderer.RenderVideoDistributed(videoPreset, *coordinatorAddr, outFile)
+			}
+			return renderer.RenderVideo(entry.Scene(), videoPreset, outFile, *wireframe, *triDepth)
+		}
+	default:
+		return fmt.Errorf("unknown format %s", format)
+	}
+}
+
+// getImagePreset returns the preset that the scene's still is rendered with, in the format of the file extension
+func getImagePreset(entry scenes.Entry, extension string) (renderer.ImagePreset, error) {
+	backend, sampler, filter, frameFormat, err := parseRenderFlags()
+	if err != nil {
+		return renderer.ImagePreset{}, err
+	}
+	imagePreset, err := renderer.ParseImagePreset(presetName(*imageFlag, entry.ImagePreset))
+	if err != nil {
+		return renderer.ImagePreset{}, err
+	}
+	if *rayDepth >= 0 {
+		imagePreset = imagePreset.WithRayDepth(*rayDepth)
+	}
+	imagePreset = imagePreset.WithBackend(backend).WithSampler(sampler).WithFilter(filter).WithAdaptive(*adaptive)
+	return imagePreset.WithFrameFormat(imageFrameFormat(extension, frameFormat)), nil
+}
+
+// getVideoPreset returns the preset that the scene's video is rendered with
+func getVideoPreset(entry scenes.Entry) (renderer.VideoPreset, error) {
+	backend, sampler, filter, frameFormat, err := parseRenderFlags()
+	if err != nil {
+		return renderer.VideoPreset{}, err
+	}
+	videoPreset, err := renderer.ParseVideoPreset(presetName(*videoFlag, entry.VideoPreset))
+	if err != nil {
+		return renderer.VideoPreset{}, err
+	}
+	if *rayDepth >= 0 {
+		videoPreset.ImagePreset = videoPreset.ImagePreset.WithRayDepth(*rayDepth)
+	}
+	videoPreset.ImagePreset = videoPreset.ImagePreset.WithBackend(backend).WithSampler(sampler).WithFilter(filter).WithAdaptive(*adaptive)
+	videoPreset.ImagePreset = videoPreset.ImagePreset.WithFrameFormat(frameFormat)
+	if *shutterAngle > 0 {
+		videoPreset = videoPreset.WithShutterAngle(*shutterAngle)
+	}
+	sceneKey := entry.Name
+	if entry.Version != "" {
+		sceneKey += "@" + entry.Version
+	}
+	return videoPreset.WithPNGFrames(*pngFrames || *resume).WithResume(sceneKey, *resume).WithLoopCount(*loopCount).WithPalette(*framePalette, *dither), nil
+}
+
+// parseRenderFlags parses the flags shared by images and videos
+func parseRenderFlags() (renderer.Backend, renderer.Sampler, renderer.Filter, renderer.FrameFormat, error) {
+	backend, err := renderer.ParseBackend(*backendFlag)
+	if err != nil {
+		return 0, 0, 0, 0, err
+	}
+	sampler, err := renderer.ParseSampler(*samplerFlag)
+	if err != nil {
+		return 0, 0, 0, 0, err
+	}
+	filter, err := renderer.ParseFilter(*filterFlag)
+	if err != nil {
+		return 0, 0, 0, 0, err
+	}
+	frameFormat, err := renderer.ParseFrameFormat(*frameFormatFlag)
+	if err != nil {
+		return 0, 0, 0, 0, err
+	}
+	return backend, sampler, filter, frameFormat, nil
+}
+
+// presetName returns the preset requested by the flag, or the scene's own preset if the flag is left at the default
+func presetName(flagVal, scenePreset string) string {
+	if flagVal == "default" && scenePreset != "" {
+		return scenePreset
+	}
+	return flagVal
+}
+
+func printScenes() {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "NAME\tIMAGE\tVIDEO\tDESCRIPTION\n")
+	for _, entry := range scenes.Registered() {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", entry.Name, presetName("default", entry.ImagePreset), presetName("default", entry.VideoPreset), entry.Description)
+	}
+	w.Flush()
+}
+
+// imageFrameFormat returns the format that an image with the file extension is written in,
+// using the flag's format if it matches the extension
+func imageFrameFormat(extension string, flagFormat renderer.FrameFormat) renderer.FrameFormat {
+	if flagFormat.Extension() == extension {
+		return flagFormat
+	}
+	switch extension {
+	case PFM_FORMAT:
+		return renderer.FramePFM
+	case EXR_FORMAT:
+		return renderer.FrameEXR
+	default:
+		return flagFormat
+	}
 }
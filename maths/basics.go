@@ -14,3 +14,31 @@ func Sigmoid(v float64) float64 {
 func Radius(x, y float64) float64 {
 	return math.Sqrt(x*x + y*y)
 }
+
+// SquareWaveAverage returns the average over [x-w/2, x+w/2] of the square wave that is 1 on [0,1)
+// and 0 on [1,2), repeating with a period of 2. It is the box-filtered, band-limited version of the wave
+func SquareWaveAverage(x, w float64) float64 {
+	if w <= 0 {
+		if x-2*math.Floor(x/2) < 1 {
+			return 1
+		}
+		return 0
+	}
+	return (squareWaveIntegral(x+w/2) - squareWaveIntegral(x-w/2)) / w
+}
+
+// squareWaveIntegral is the integral of the square wave of SquareWaveAverage from 0 to x
+func squareWaveIntegral(x float64) float64 {
+	periods := math.Floor(x / 2)
+	return periods + min(x-2*periods, 1)
+}
+
+// SmoothMin returns the minimum of a and b, with the corner where they are equal rounded off over a width
+// of k, by a quadratic polynomial. It is at most k/4 below the minimum, and the same as min if k is 0.
+func SmoothMin(a, b, k float64) float64 {
+	if k <= 0 {
+		return min(a, b)
+	}
+	h := max(k-math.Abs(a-b), 0) / k
+	return min(a, b) - h*h*k/4
+}
@@ -2,8 +2,14 @@ package maths
 
 import "math"
 
-// return all the roots, if any, smallest first
+// return all the roots, if any, smallest first. If a is zero, returns the root of the linear equation
 func QuadraticRoots(a float64, b float64, c float64) []float64 {
+	if a == 0 {
+		if b == 0 {
+			return []float64{}
+		}
+		return []float64{-c / b}
+	}
 	d := b*b - 4*a*c
 	if d < 0 {
 		return []float64{}
@@ -13,5 +19,5 @@ func QuadraticRoots(a float64, b float64, c float64) []float64 {
 	}
 	x1 := (-b - math.Sqrt(d)) / (2 * a)
 	x2 := (-b + math.Sqrt(d)) / (2 * a)
-	return []float64{x1, x2}
+	return []float64{min(x1, x2), max(x1, x2)}
 }
@@ -0,0 +1,102 @@
+package maths
+
+import (
+	"math"
+	"slices"
+)
+
+// CubicRoots returns the real roots of a*x^3 + b*x^2 + c*x + d, smallest first.
+// Falls back to QuadraticRoots if a is zero.
+func CubicRoots(a, b, c, d float64) []float64 {
+	if a == 0 {
+		return QuadraticRoots(b, c, d)
+	}
+	b, c, d = b/a, c/a, d/a
+	// substitute x = y - b/3 to get the depressed cubic y^3 + p*y + q
+	shift := -b / 3
+	p := c - b*b/3
+	q := 2*b*b*b/27 - b*c/3 + d
+	roots := []float64{}
+	discriminant := q*q/4 + p*p*p/27
+	switch {
+	case p == 0 && q == 0:
+		roots = append(roots, 0)
+	case discriminant > 0:
+		// a single real root, by Cardano's formula
+		s := math.Sqrt(discriminant)
+		roots = append(roots, math.Cbrt(-q/2+s)+math.Cbrt(-q/2-s))
+	default:
+		// three real roots, by the trigonometric method
+		m := 2 * math.Sqrt(-p/3)
+		theta := math.Acos(max(-1, min(1, 3*q/(p*m))))
+		for k := range 3 {
+			roots = append(roots, m*math.Cos((theta-2*math.Pi*float64(k))/3))
+		}
+	}
+	for i := range roots {
+		roots[i] += shift
+	}
+	slices.Sort(roots)
+	return roots
+}
+
+// QuarticRoots returns the real roots of a*x^4 + b*x^3 + c*x^2 + d*x + e, smallest first, with double roots
+// returned once. Falls back to CubicRoots if a is zero.
+//
+// Uses Ferrari's method, and polishes each root with a few steps of Newton's method, which repairs most of
+// the precision that the method loses.
+func QuarticRoots(a, b, c, d, e float64) []float64 {
+	if a == 0 {
+		return CubicRoots(b, c, d, e)
+	}
+	b, c, d, e = b/a, c/a, d/a, e/a
+	// substitute x = y - b/4 to get the depressed quartic y^4 + p*y^2 + q*y + r
+	shift := -b / 4
+	p := c - 3*b*b/8
+	q := b*b*b/8 - b*c/2 + d
+	r := -3*b*b*b*b/256 + b*b*c/16 - b*d/4 + e
+	ys := []float64{}
+	if math.Abs(q) < 1e-12 {
+		// biquadratic, solve for y^2
+		for _, y2 := range QuadraticRoots(1, p, r) {
+			if y2 >= 0 {
+				ys = append(ys, -math.Sqrt(y2), math.Sqrt(y2))
+			}
+		}
+	} else {
+		// factor into the quadratics y^2 + s*y + (p + 2m - q/s)/2 and y^2 - s*y + (p + 2m + q/s)/2
+		// with s = sqrt(2m), where m is the largest root of the resolvent cubic, positive when q is not zero
+		resolvent := CubicRoots(8, 8*p, 2*p*p-8*r, -q*q)
+		m := resolvent[len(resolvent)-1]
+		if m > 0 {
+			s := math.Sqrt(2 * m)
+			ys = append(ys, QuadraticRoots(1, s, (p+2*m-q/s)/2)...)
+			ys = append(ys, QuadraticRoots(1, -s, (p+2*m+q/s)/2)...)
+		}
+	}
+	roots := make([]float64, 0, len(ys))
+	for _, y := range ys {
+		roots = append(roots, polishQuarticRoot(b, c, d, e, y+shift))
+	}
+	slices.Sort(roots)
+	return slices.CompactFunc(roots, func(x, y float64) bool {
+		return math.Abs(x-y) <= 1e-9*max(1, math.Abs(x))
+	})
+}
+
+// polishQuarticRoot improves the root x of x^4 + b*x^3 + c*x^2 + d*x + e by Newton's method
+func polishQuarticRoot(b, c, d, e, x float64) float64 {
+	for range 3 {
+		f := (((x+b)*x+c)*x+d)*x + e
+		df := ((4*x+3*b)*x+2*c)*x + d
+		if df == 0 {
+			break
+		}
+		next := x - f/df
+		if math.IsNaN(next) || math.IsInf(next, 0) {
+			break
+		}
+		x = next
+	}
+	return x
+}
@@ -0,0 +1,99 @@
+package maths
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestCubicRoots(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		a, b, c, d float64
+		want       []float64
+	}{
+		{
+			name: "three roots",
+			// (x+1)(x-2)(x-3)
+			a: 1, b: -4, c: 1, d: 6,
+			want: []float64{-1, 2, 3},
+		},
+		{
+			name: "one root",
+			// (x-2)(x^2+1), scaled
+			a: 2, b: -4, c: 2, d: -4,
+			want: []float64{2},
+		},
+		{
+			name: "triple root",
+			// (x-1)^3
+			a: 1, b: -3, c: 3, d: -1,
+			want: []float64{1},
+		},
+		{
+			name: "quadratic",
+			a:    0, b: 1, c: 0, d: -4,
+			want: []float64{-2, 2},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := CubicRoots(tc.a, tc.b, tc.c, tc.d)
+			if diff := cmp.Diff(tc.want, got, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Errorf("CubicRoots() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestQuarticRoots(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		a, b, c, d, e float64
+		want          []float64
+	}{
+		{
+			name: "four roots",
+			// (x+2)(x+1)(x-1)(x-3)
+			a: 1, b: -1, c: -7, d: 1, e: 6,
+			want: []float64{-2, -1, 1, 3},
+		},
+		{
+			name: "biquadratic",
+			// (x^2-1)(x^2-4), scaled
+			a: 3, b: 0, c: -15, d: 0, e: 12,
+			want: []float64{-2, -1, 1, 2},
+		},
+		{
+			name: "two roots",
+			// (x-1)(x-2)(x^2+1)
+			a: 1, b: -3, c: 3, d: -3, e: 2,
+			want: []float64{1, 2},
+		},
+		{
+			name: "double roots",
+			// (x-1)^2(x+1)^2
+			a: 1, b: 0, c: -2, d: 0, e: 1,
+			want: []float64{-1, 1},
+		},
+		{
+			name: "no roots",
+			// (x^2+1)(x^2+2x+2)
+			a: 1, b: 2, c: 3, d: 2, e: 2,
+			want: []float64{},
+		},
+		{
+			name: "ray across a torus",
+			// a ray through the center of a torus with radii 1 and 0.25, along its plane, from 5 units away, (t-5)^4 - 2.125(t-5)^2 + 0.87890625
+			a: 1, b: -20, c: 147.875, d: -478.75, e: 572.75390625,
+			want: []float64{3.75, 4.25, 5.75, 6.25},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := QuarticRoots(tc.a, tc.b, tc.c, tc.d, tc.e)
+			if diff := cmp.Diff(tc.want, got, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Errorf("QuarticRoots() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package objects
 
 import (
 	"fmt"
+	"math"
 
 	"github.com/libeks/go-scene-renderer/colors"
 	"github.com/libeks/go-scene-renderer/geometry"
@@ -18,13 +19,15 @@ func DynamicBasicObject(b BasicObject, colorer textures.DynamicTransparentTextur
 // dynamicBasicObject is a BasicObject with a DynamicTexture, which can be evaluated for a specific frame
 type dynamicBasicObject struct {
 	BasicObject
-	Colorer textures.DynamicTransparentTexture
+	Colorer  textures.DynamicTransparentTexture
+	Material textures.Material
 }
 
 func (t dynamicBasicObject) Frame(f float64) StaticBasicObject {
 	return StaticBasicObject{
 		BasicObject: t.BasicObject,
 		Colorer:     t.Colorer.GetFrame(f),
+		Material:    t.Material,
 	}
 }
 
@@ -33,9 +36,15 @@ func (t dynamicBasicObject) ApplyMatrix(m geometry.HomogeneusMatrix) *dynamicBas
 	return &dynamicBasicObject{
 		BasicObject: newBasicObject,
 		Colorer:     t.Colorer,
+		Material:    t.Material,
 	}
 }
 
+func (t dynamicBasicObject) WithMaterial(m textures.Material) dynamicBasicObject {
+	t.Material = m
+	return t
+}
+
 // func (t dynamicBasicObject) GetBoundingBox() BoundingBox {
 // 	return t.BasicObject.GetBoundingBox()
 // }
@@ -61,25 +70,171 @@ type StaticBasicObject struct {
 	BasicObject
 	// Colorer will be evaluated with two parameters (b,c), each from (0,1), but b+c<1.0
 	// it describes the coordinates on the BasicObject from A towards B and C, respectively
-	Colorer textures.TransparentTexture
+	Colorer  textures.TransparentTexture
+	Material textures.Material
+}
+
+// Hit describes the visible point on a StaticBasicObject that a ray intersects
+type Hit struct {
+	Color    colors.Color
+	Alpha    float64           // opacity of the surface at the hit, 1 is opaque
+	Depth    float64           // z-depth of the hit, the bigger, the farther the object
+	T        float64           // ray parameter of the hit, the hit is at r.P + T * r.D
+	Point    geometry.Point    // location of the hit, in camera space
+	Normal   geometry.Vector3D // unit surface normal at the hit, pointing away from the object
+	Material textures.Material
+
+	filter *hitFilter // set for hits of camera rays, whose color is not yet filtered, see Filtered
 }
 
-// returns the color of the BasicObject at a ray
-// emanating from the camera at (0,0,0), pointed in the direction
-// (x,y, -1), with perspective
+// hitFilter is what it takes to filter the color of a hit. Finding the footprint casts four more rays at the object,
+// so it is put off until the hit is known not to be hidden behind others.
+type hitFilter struct {
+	object StaticBasicObject
+	ray    geometry.Ray
+	int    intersection
+}
+
+// Filtered returns the hit with its color averaged over the footprint of the pixel of the camera ray that found it.
+// Other hits are returned as they are.
+func (h Hit) Filtered() Hit {
+	if h.filter == nil {
+		return h
+	}
+	color := h.filter.object.colorAt(h.filter.ray, h.filter.int)
+	h.Color, h.Alpha = color.Color, color.A
+	h.filter = nil
+	return h
+}
+
+// returns the color of the BasicObject along a ray, which may start anywhere, such as on a camera lens,
 // and a z-index. The bigger the index, the farther the object.
-func (t StaticBasicObject) GetColorDepth(x, y float64) (*colors.Color, float64) {
-	intersections := t.RayIntersectLocalCoords(ray{geometry.OriginPoint, geometry.V3(x, y, -1)})
-	if len(intersections) == 0 {
+func (t StaticBasicObject) GetColorDepth(r geometry.Ray) (*colors.Color, float64) {
+	hit := t.IntersectRay(r)
+	if hit == nil {
 		return nil, 0
 	}
+	return &hit.Color, hit.Depth
+}
+
+// GetHit returns the closest non-transparent point on the BasicObject along the ray
+// emanating from the camera through the screen coordinates (x,y), or nil if there is none
+func (t StaticBasicObject) GetHit(c geometry.Camera, x, y float64) *Hit {
+	return t.IntersectRay(c.Ray(x, y))
+}
+
+// IntersectRay returns the closest non-transparent point on the BasicObject along an arbitrary ray,
+// or nil if there is none
+func (t StaticBasicObject) IntersectRay(r geometry.Ray) *Hit {
+	intersections := t.RayIntersectLocalCoords(r)
 	for _, int := range intersections {
-		colorPtr := t.Colorer.GetTextureColor(int.b, int.c)
-		if colorPtr != nil {
-			return colorPtr, int.zDepth
+		color := t.colorAt(r, int)
+		if !color.IsTransparent() {
+			hit := t.hitAt(r, int, color)
+			return &hit
+		}
+	}
+	return nil
+}
+
+// Hits returns all non-transparent points on the BasicObject along an arbitrary ray, closest first.
+// The colors of the hits of camera rays are those at the center of the pixel, see Hit.Filtered.
+func (t StaticBasicObject) Hits(r geometry.Ray) []Hit {
+	var hits []Hit
+	for _, int := range t.RayIntersectLocalCoords(r) {
+		color := t.Colorer.GetTextureColor(int.b, int.c)
+		if color.IsTransparent() {
+			continue
+		}
+		hit := t.hitAt(r, int, color)
+		if r.Differential != nil {
+			hit.filter = &hitFilter{object: t, ray: r, int: int}
+		}
+		hits = append(hits, hit)
+	}
+	return hits
+}
+
+// colorAt returns the color of the texture at the intersection of the ray. If the ray is cast from the camera,
+// the color is averaged over the footprint of its pixel.
+func (t StaticBasicObject) colorAt(r geometry.Ray, int intersection) colors.AlphaColor {
+	if r.Differential == nil {
+		return t.Colorer.GetTextureColor(int.b, int.c)
+	}
+	return textures.FilteredTransparentColor(t.Colorer, int.b, int.c, t.footprint(r, int))
+}
+
+// footprint returns the change in texture coordinates from the intersection of the ray to where the rays
+// through the neighboring pixels hit the object
+func (t StaticBasicObject) footprint(r geometry.Ray, int intersection) geometry.Footprint {
+	dudx, dvdx := t.coordsChange(r, r.Differential.X, int)
+	dudy, dvdy := t.coordsChange(r, r.Differential.Y, int)
+	return geometry.Footprint{DUDX: dudx, DVDX: dvdx, DUDY: dudy, DVDY: dvdy}
+}
+
+// coordsChange returns the change in texture coordinates between the intersection of r and that of
+// its neighbor. The neighbor on the opposite side of r is tried as well, and the smaller change is kept,
+// so that the footprint doesn't span a seam in the texture coordinates, or the edge of the object.
+// If neither neighbor hits the object, the footprint is zero.
+func (t StaticBasicObject) coordsChange(r, neighbor geometry.Ray, int intersection) (float64, float64) {
+	opposite := geometry.Ray{
+		P: geometry.Point(r.P.Vector().ScalarMultiply(2).AddVector(neighbor.P.Vector().ScalarMultiply(-1))),
+		D: r.D.ScalarMultiply(2).AddVector(neighbor.D.ScalarMultiply(-1)),
+	}
+	du, dv := 0.0, 0.0
+	found := false
+	for i, ray := range []geometry.Ray{neighbor, opposite} {
+		next, ok := t.nearestIntersection(ray, int.t)
+		if !ok {
+			continue
+		}
+		u, v := next.b-int.b, next.c-int.c
+		if i == 1 {
+			u, v = -u, -v
+		}
+		if !found || u*u+v*v < du*du+dv*dv {
+			du, dv = u, v
+			found = true
+		}
+	}
+	return du, dv
+}
+
+// nearestIntersection returns the intersection of the ray whose ray parameter is closest to t
+func (t StaticBasicObject) nearestIntersection(r geometry.Ray, rayT float64) (intersection, bool) {
+	var nearest intersection
+	found := false
+	for _, int := range t.RayIntersectLocalCoords(r) {
+		if !found || math.Abs(int.t-rayT) < math.Abs(nearest.t-rayT) {
+			nearest = int
+			found = true
+		}
+	}
+	return nearest, found
+}
+
+func (t StaticBasicObject) hitAt(r geometry.Ray, int intersection, color colors.AlphaColor) Hit {
+	return Hit{
+		Color:    color.Color,
+		Alpha:    color.A,
+		Depth:    int.zDepth,
+		T:        int.t,
+		Point:    r.PointAt(int.t),
+		Normal:   int.normal,
+		Material: t.Material,
+	}
+}
+
+// transmittance returns the fraction of light that passes through the BasicObject along the ray,
+// up to a ray parameter of maxT. Each point along the ray lets through 1 - alpha of it, as in compositing.
+func (t StaticBasicObject) transmittance(r geometry.Ray, maxT float64) float64 {
+	transmittance := 1.0
+	for _, int := range t.RayIntersectLocalCoords(r) {
+		if int.t < maxT {
+			transmittance *= 1 - t.Colorer.GetTextureColor(int.b, int.c).A
 		}
 	}
-	return nil, 0
+	return max(0, transmittance)
 }
 
 func (t StaticBasicObject) ApplyMatrix(m geometry.HomogeneusMatrix) StaticBasicObject {
@@ -87,6 +242,7 @@ func (t StaticBasicObject) ApplyMatrix(m geometry.HomogeneusMatrix) StaticBasicO
 	return StaticBasicObject{
 		BasicObject: newBasicObject,
 		Colorer:     t.Colorer,
+		Material:    t.Material,
 	}
 }
 
@@ -0,0 +1,122 @@
+package objects
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"github.com/libeks/go-scene-renderer/colors"
+	"github.com/libeks/go-scene-renderer/geometry"
+	"github.com/libeks/go-scene-renderer/textures"
+)
+
+// footprintTexture colors each point by the footprint it is looked up with, red for DUDX and green for DVDY
+type footprintTexture struct{}
+
+func (footprintTexture) GetTextureColor(u, v float64) colors.Color {
+	return colors.Black
+}
+
+func (footprintTexture) GetFilteredColor(u, v float64, f geometry.Footprint) colors.Color {
+	return colors.Color{R: f.DUDX, G: f.DVDY}
+}
+
+func TestFootprint(t *testing.T) {
+	// a 4x4 square at z=-2, its texture coordinates change by 1/4 per unit along x and y
+	square := Parallelogram(
+		geometry.Pt(-2, -2, -2), geometry.Pt(2, -2, -2), geometry.Pt(-2, 2, -2),
+		textures.OpaqueDynamicTexture(textures.StaticTexture(footprintTexture{})),
+	)
+	basics := square.Frame(0).Flatten()
+	// a unit of screen space is a unit at depth 1
+	camera := geometry.Camera{FOV: math.Pi / 2, AspectRatio: 1}
+	for _, tc := range []struct {
+		name string
+		ray  geometry.Ray
+		want colors.Color
+	}{
+		{
+			name: "camera ray",
+			ray: geometry.Ray{P: geometry.OriginPoint, D: geometry.V3(0.2, 0.3, -1), Differential: &geometry.RayDifferential{
+				X: geometry.Ray{P: geometry.OriginPoint, D: geometry.V3(0.21, 0.3, -1)},
+				Y: geometry.Ray{P: geometry.OriginPoint, D: geometry.V3(0.2, 0.31, -1)},
+			}},
+			want: colors.Color{R: 0.005, G: 0.005},
+		},
+		{
+			// the neighbors past the edge of the square miss it, the ones on the other side are used instead
+			name: "at the edge",
+			ray: geometry.Ray{P: geometry.OriginPoint, D: geometry.V3(0.995, 0.995, -1), Differential: &geometry.RayDifferential{
+				X: geometry.Ray{P: geometry.OriginPoint, D: geometry.V3(1.005, 0.995, -1)},
+				Y: geometry.Ray{P: geometry.OriginPoint, D: geometry.V3(0.995, 1.005, -1)},
+			}},
+			want: colors.Color{R: 0.005, G: 0.005},
+		},
+		{
+			name: "from the camera",
+			ray:  camera.PixelRay(0, 0, 0.01, 0.01, geometry.Vector2D{}),
+			want: colors.Color{R: 0.005, G: 0.005},
+		},
+		{
+			name: "secondary ray",
+			ray:  geometry.Ray{P: geometry.OriginPoint, D: geometry.V3(0.2, 0.3, -1)},
+			want: colors.Black,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var hit *Hit
+			for _, basic := range basics {
+				if h := basic.IntersectRay(tc.ray); h != nil {
+					hit = h
+				}
+			}
+			if hit == nil {
+				t.Fatalf("no hit")
+			}
+			if diff := cmp.Diff(tc.want, hit.Color, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Errorf("footprint mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// countingObject counts how many rays it is intersected with
+type countingObject struct {
+	BasicObject
+	intersections *int
+}
+
+func (o countingObject) RayIntersectLocalCoords(r geometry.Ray) []intersection {
+	*o.intersections += 1
+	return o.BasicObject.RayIntersectLocalCoords(r)
+}
+
+func TestFootprintOnlyForVisibleHits(t *testing.T) {
+	front := NewStaticBasicObject(Tri(geometry.Pt(-10, -10, -2), geometry.Pt(30, -10, -2), geometry.Pt(-10, 30, -2)), textures.OpaqueTexture(footprintTexture{}))
+	backIntersections := 0
+	back := NewStaticBasicObject(
+		countingObject{Tri(geometry.Pt(-100, -100, -4), geometry.Pt(300, -100, -4), geometry.Pt(-100, 300, -4)), &backIntersections},
+		textures.OpaqueTexture(textures.Uniform(colors.Red)),
+	)
+	ray := geometry.Ray{P: geometry.OriginPoint, D: geometry.V3(0.2, 0.3, -1), Differential: &geometry.RayDifferential{
+		X: geometry.Ray{P: geometry.OriginPoint, D: geometry.V3(0.21, 0.3, -1)},
+		Y: geometry.Ray{P: geometry.OriginPoint, D: geometry.V3(0.2, 0.31, -1)},
+	}}
+	hits := ObjectList{back, front}.VisibleHits(ray)
+	if len(hits) != 1 {
+		t.Fatalf("VisibleHits() returned %d hits, want only the front one", len(hits))
+	}
+	// the footprint of the hidden hit would have cast four more rays at the back object
+	if backIntersections != 1 {
+		t.Errorf("hidden object was intersected %d times, want once", backIntersections)
+	}
+	want := front.IntersectRay(ray).Color
+	if diff := cmp.Diff(want, hits[0].Filtered().Color, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+		t.Errorf("Filtered() mismatch (-want +got):\n%s", diff)
+	}
+	if want == colors.Black {
+		t.Errorf("filtered color is the unfiltered one")
+	}
+}
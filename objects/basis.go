@@ -0,0 +1,190 @@
+package objects
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/libeks/go-scene-renderer/geometry"
+)
+
+// UnitBasis returns the basis of the world coordinates, centered at the origin
+func UnitBasis() Basis {
+	return NewBasis(geometry.Point{X: 0, Y: 0, Z: 0}, [3]geometry.Vector3D{geometry.V3(1, 0, 0), geometry.V3(0, 1, 0), geometry.V3(0, 0, 1)})
+}
+
+// NewBasis returns the basis with the center and axes, along with the inverse of its axes, which maps
+// points into local coordinates for every ray, and so is computed only once
+func NewBasis(center geometry.Point, axes [3]geometry.Vector3D) Basis {
+	b := Basis{Center: center, Axes: axes}
+	b.inverse, b.inverted = b.invert()
+	return b
+}
+
+// Basis places the local coordinates of an object in space, the local point (x,y,z) is at
+// Center + x*Axes[0] + y*Axes[1] + z*Axes[2]. Objects are defined in their local coordinates, with the second
+// axis pointing up. The axes don't have to be perpendicular or of unit length, transforms may scale and shear
+// the object.
+type Basis struct {
+	Center geometry.Point
+	Axes   [3]geometry.Vector3D
+
+	inverse  geometry.Matrix3D // maps vectors from the center into local coordinates, see toLocal
+	inverted bool              // false if the basis is flat, or was not created by NewBasis
+}
+
+func (b Basis) ApplyMatrix(m geometry.HomogeneusMatrix) Basis {
+	center, ok := m.MultVect(b.Center.ToHomogenous()).ToPoint()
+	if !ok {
+		panic(fmt.Errorf("could not apply matrix %s to point %s", m, b.Center))
+	}
+	m3D := m.Slice3DMatrix()
+	return NewBasis(center, [3]geometry.Vector3D{m3D.MultVect(b.Axes[0]), m3D.MultVect(b.Axes[1]), m3D.MultVect(b.Axes[2])})
+}
+
+func (b Basis) String() string {
+	return fmt.Sprintf("at %s with axes %s, %s, %s", b.Center, b.Axes[0], b.Axes[1], b.Axes[2])
+}
+
+// toWorld returns the point at the local coordinates
+func (b Basis) toWorld(local geometry.Vector3D) geometry.Point {
+	v := b.Center.Vector().
+		AddVector(b.Axes[0].ScalarMultiply(local.X)).
+		AddVector(b.Axes[1].ScalarMultiply(local.Y)).
+		AddVector(b.Axes[2].ScalarMultiply(local.Z))
+	return geometry.Point(v)
+}
+
+// toLocal returns the matrix that maps vectors from the center into local coordinates.
+// The bool is false if the basis is flat.
+func (b Basis) toLocal() (geometry.Matrix3D, bool) {
+	if b.inverted {
+		return b.inverse, true
+	}
+	return b.invert()
+}
+
+// invert returns the inverse of the matrix with the axes as its columns, false if the basis is flat
+func (b Basis) invert() (geometry.Matrix3D, bool) {
+	return geometry.Matrix3D{
+		A1: b.Axes[0].X, A2: b.Axes[1].X, A3: b.Axes[2].X,
+		B1: b.Axes[0].Y, B2: b.Axes[1].Y, B3: b.Axes[2].Y,
+		C1: b.Axes[0].Z, C2: b.Axes[1].Z, C3: b.Axes[2].Z,
+	}.Inverse()
+}
+
+// localPoint returns the local coordinates of the point, false if the basis is flat
+func (b Basis) localPoint(p geometry.Point) (geometry.Vector3D, bool) {
+	toLocal, ok := b.toLocal()
+	if !ok {
+		return geometry.Vector3D{}, false
+	}
+	return toLocal.MultVect(p.Subtract(b.Center)), true
+}
+
+// localRay returns the ray in local coordinates, false if the basis is flat. As the mapping is affine,
+// the ray reaches the same points at the same ray parameters in both coordinates.
+func (b Basis) localRay(r geometry.Ray) (localRay, bool) {
+	toLocal, ok := b.toLocal()
+	if !ok {
+		return localRay{}, false
+	}
+	return localRay{
+		o:       toLocal.MultVect(r.P.Subtract(b.Center)),
+		d:       toLocal.MultVect(r.D),
+		toLocal: toLocal,
+	}, true
+}
+
+// corners returns the corners of the local box from lo to hi, ordered as by geometry.AABB.Corners
+func (b Basis) corners(lo, hi geometry.Vector3D) [8]geometry.Point {
+	var corners [8]geometry.Point
+	for i := range corners {
+		local := lo
+		if i&1 != 0 {
+			local.X = hi.X
+		}
+		if i&2 != 0 {
+			local.Y = hi.Y
+		}
+		if i&4 != 0 {
+			local.Z = hi.Z
+		}
+		corners[i] = b.toWorld(local)
+	}
+	return corners
+}
+
+// bounds returns the axis-aligned box around the local box from lo to hi
+func (b Basis) bounds(lo, hi geometry.Vector3D) geometry.AABB {
+	corners := b.corners(lo, hi)
+	return geometry.AABBFromPoints(corners[:]...)
+}
+
+// boundingBox returns the box on screen around the local box from lo to hi, as seen by the camera
+func (b Basis) boundingBox(lo, hi geometry.Vector3D, c geometry.Camera) BoundingBox {
+	bounds := b.bounds(lo, hi)
+	return wireframeBoundingBox(boxWireframe(b.corners(lo, hi), c), -bounds.Max.Z, -bounds.Min.Z)
+}
+
+// circle returns the lines of a polygon inscribed in the local circle of the radius around the up axis,
+// at the height y
+func (b Basis) circle(y, radius float64) []geometry.Line {
+	return ellipse(b.toWorld(geometry.V3(0, y, 0)), b.Axes[2].ScalarMultiply(radius), b.Axes[0].ScalarMultiply(radius), circleSegments)
+}
+
+// localRay is a ray in the local coordinates of a Basis
+type localRay struct {
+	o, d    geometry.Vector3D
+	toLocal geometry.Matrix3D
+}
+
+func (l localRay) at(t float64) geometry.Vector3D {
+	return l.o.AddVector(l.d.ScalarMultiply(t))
+}
+
+// worldNormal returns the unit normal in world coordinates of a surface with the local normal.
+// Normals are gradients, so they map by the transpose of toLocal.
+func (l localRay) worldNormal(n geometry.Vector3D) geometry.Vector3D {
+	m := l.toLocal
+	return geometry.V3(
+		m.A1*n.X+m.B1*n.Y+m.C1*n.Z,
+		m.A2*n.X+m.B2*n.Y+m.C2*n.Z,
+		m.A3*n.X+m.B3*n.Y+m.C3*n.Z,
+	).Unit()
+}
+
+// localHit is an intersection found in local coordinates
+type localHit struct {
+	t      float64
+	b, c   float64
+	normal geometry.Vector3D // in local coordinates
+}
+
+// intersections returns the hits in front of the ray origin as intersections in world coordinates,
+// ordered by their distance along the ray
+func (l localRay) intersections(r geometry.Ray, hits []localHit) []intersection {
+	slices.SortFunc(hits, func(a, b localHit) int {
+		return cmp.Compare(a.t, b.t)
+	})
+	intersections := []intersection{}
+	for _, hit := range hits {
+		if hit.t <= 0 {
+			continue
+		}
+		p := r.PointAt(hit.t)
+		intersections = append(intersections, intersection{hit.b, hit.c, -p.Z, hit.t, l.worldNormal(hit.normal)})
+	}
+	return intersections
+}
+
+// longitude returns the angle of the local point around the up axis, from 0 to 1, increasing clockwise
+// when seen from above, with 0 along the third axis. This matches the longitude of Sphere.
+func longitude(local geometry.Vector3D) float64 {
+	b := math.Atan2(-local.X, local.Z) / (2 * math.Pi)
+	if b < 0 {
+		b += 1
+	}
+	return b
+}
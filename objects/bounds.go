@@ -0,0 +1,82 @@
+package objects
+
+import (
+	"slices"
+
+	"github.com/libeks/go-scene-renderer/geometry"
+)
+
+// fullScreenBB covers the whole screen, for objects that extend infinitely
+func fullScreenBB(minZDepth, maxZDepth float64) BoundingBox {
+	return BoundingBox{
+		TopLeft:     geometry.Pixel{X: -1, Y: -1},
+		BottomRight: geometry.Pixel{X: 1, Y: 1},
+		MinZDepth:   max(0, minZDepth),
+		MaxZDepth:   maxZDepth,
+	}
+}
+
+// aabbBoundingBox returns the box on screen around the axis-aligned box, as seen by the camera.
+// Infinite boxes cover the whole screen.
+func aabbBoundingBox(b geometry.AABB, c geometry.Camera) BoundingBox {
+	if b.IsEmpty() {
+		return EmptyBB
+	}
+	if isInfinite(b) {
+		return fullScreenBB(-b.Max.Z, -b.Min.Z)
+	}
+	return wireframeBoundingBox(boxWireframe(b.Corners(), c), -b.Max.Z, -b.Min.Z)
+}
+
+// wireframeBoundingBox returns the box on screen around the lines, with the range of z-depths of the object
+func wireframeBoundingBox(lines []geometry.RasterLine, minZDepth, maxZDepth float64) BoundingBox {
+	if len(lines) == 0 {
+		return EmptyBB
+	}
+	xs, ys := make([]float64, 0, 2*len(lines)), make([]float64, 0, 2*len(lines))
+	for _, line := range lines {
+		xs = append(xs, line.A.X, line.B.X)
+		ys = append(ys, line.A.Y, line.B.Y)
+	}
+	return BoundingBox{
+		TopLeft: geometry.Pixel{
+			X: max(slices.Min(xs), -1.0),
+			Y: max(slices.Min(ys), -1.0),
+		},
+		BottomRight: geometry.Pixel{
+			X: min(slices.Max(xs), 1.0),
+			Y: min(slices.Max(ys), 1.0),
+		},
+		MinZDepth: max(0, minZDepth),
+		MaxZDepth: maxZDepth,
+	}
+}
+
+// boxWireframe returns the 12 edges of the box with the corners, ordered as by geometry.AABB.Corners,
+// cropped to what the camera sees
+func boxWireframe(corners [8]geometry.Point, c geometry.Camera) []geometry.RasterLine {
+	lines := []geometry.Line{}
+	for i := range corners {
+		for _, bit := range []int{1, 2, 4} {
+			if i&bit == 0 {
+				lines = append(lines, geometry.Line{A: corners[i], B: corners[i|bit]})
+			}
+		}
+	}
+	return cropLines(lines, c)
+}
+
+// cropLines returns the parts of the lines in front of the camera, as seen on screen
+func cropLines(lines []geometry.Line, c geometry.Camera) []geometry.RasterLine {
+	ret := []geometry.RasterLine{}
+	for _, l := range lines {
+		inFront := l.CropToFrontOfCamera(-c.Near)
+		if inFront == nil {
+			continue
+		}
+		if rasterLine := inFront.CropToScreenView(c); rasterLine != nil {
+			ret = append(ret, *rasterLine)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,127 @@
+package objects
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/libeks/go-scene-renderer/geometry"
+	"github.com/libeks/go-scene-renderer/textures"
+)
+
+func DynamicBox(b Box, colorer textures.DynamicTransparentTexture) dynamicBasicObject {
+	return dynamicBasicObject{
+		BasicObject: b,
+		Colorer:     colorer,
+	}
+}
+
+// UnitBox returns the box from (-1,-1,-1) to (1,1,1)
+func UnitBox() Box {
+	return Box{UnitBasis()}
+}
+
+// NewAxisAlignedBox returns the box with the opposite corners min and max
+func NewAxisAlignedBox(min, max geometry.Point) Box {
+	half := max.Subtract(min).ScalarMultiply(0.5)
+	return Box{NewBasis(
+		geometry.Point(min.Vector().AddVector(half)),
+		[3]geometry.Vector3D{geometry.V3(half.X, 0, 0), geometry.V3(0, half.Y, 0), geometry.V3(0, 0, half.Z)},
+	)}
+}
+
+// Box is a solid box around Center, with Axes from the center to the middle of three of its faces,
+// each half of an edge of the box, so that it spans (-1,1) along each axis of its basis. The axes don't have
+// to be perpendicular, transforms may shear the box. Each face is textured with the whole texture,
+// with (b,c) from (0,0) to (1,1), like the faces of a cube of parallelograms.
+//
+// implements Solid
+type Box struct {
+	Basis
+}
+
+var (
+	unitMin = geometry.V3(-1, -1, -1)
+	unitMax = geometry.V3(1, 1, 1)
+)
+
+func (b Box) ApplyMatrix(m geometry.HomogeneusMatrix) BasicObject {
+	return Box{b.Basis.ApplyMatrix(m)}
+}
+
+func (b Box) GetBoundingBox(c geometry.Camera) BoundingBox {
+	return b.boundingBox(unitMin, unitMax, c)
+}
+
+func (b Box) GetBounds() geometry.AABB {
+	return b.bounds(unitMin, unitMax)
+}
+
+// GetWireframe returns the edges of the box
+func (b Box) GetWireframe(c geometry.Camera) []geometry.RasterLine {
+	return boxWireframe(b.corners(unitMin, unitMax), c)
+}
+
+func (b Box) String() string {
+	return fmt.Sprintf("Box %s", b.Basis)
+}
+
+func (b Box) Contains(p geometry.Point) bool {
+	local, ok := b.localPoint(p)
+	return ok && math.Abs(local.X) < 1 && math.Abs(local.Y) < 1 && math.Abs(local.Z) < 1
+}
+
+// RayIntersectLocalCoords returns where the ray enters and exits the box, by clipping it to the slabs
+// between opposite faces in the coordinates of the box
+func (b Box) RayIntersectLocalCoords(r geometry.Ray) []intersection {
+	l, ok := b.localRay(r)
+	if !ok {
+		return nil
+	}
+	tNear, tFar := math.Inf(-1), math.Inf(1)
+	nearAxis, farAxis := 0, 0
+	for axis := range 3 {
+		oa, da := l.o.Axis(axis), l.d.Axis(axis)
+		if da == 0 {
+			if math.Abs(oa) > 1 {
+				// parallel to the slab, outside of it
+				return nil
+			}
+			continue
+		}
+		t0, t1 := (-1-oa)/da, (1-oa)/da
+		if t0 > t1 {
+			t0, t1 = t1, t0
+		}
+		if t0 > tNear {
+			tNear, nearAxis = t0, axis
+		}
+		if t1 < tFar {
+			tFar, farAxis = t1, axis
+		}
+	}
+	if tNear > tFar || tFar <= 0 {
+		return nil
+	}
+	return l.intersections(r, []localHit{boxHit(l, tNear, nearAxis), boxHit(l, tFar, farAxis)})
+}
+
+// boxHit returns the hit of the local ray at t, on the face across the axis
+func boxHit(l localRay, t float64, axis int) localHit {
+	local := l.at(t)
+	normal := geometry.Vector3D{}
+	switch axis {
+	case 0:
+		normal.X = math.Copysign(1, local.X)
+	case 1:
+		normal.Y = math.Copysign(1, local.Y)
+	default:
+		normal.Z = math.Copysign(1, local.Z)
+	}
+	bAxis, cAxis := (axis+1)%3, (axis+2)%3
+	return localHit{
+		t:      t,
+		b:      max(0, min(1, (local.Axis(bAxis)+1)/2)),
+		c:      max(0, min(1, (local.Axis(cAxis)+1)/2)),
+		normal: normal,
+	}
+}
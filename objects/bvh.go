@@ -0,0 +1,284 @@
+package objects
+
+import (
+	"math"
+
+	"github.com/libeks/go-scene-renderer/geometry"
+)
+
+const (
+	bvhLeafSize = 4  // nodes with at most this many objects are not split further
+	bvhBins     = 12 // number of candidate split planes per axis evaluated with the surface area heuristic
+)
+
+// BVH is a RayQuerier that organizes objects into a bounding volume hierarchy, a tree of
+// axis-aligned boxes, so that a ray only needs to be checked against objects in boxes it passes through
+type BVH struct {
+	nodes     []bvhNode
+	objects   []StaticBasicObject // ordered so that each leaf refers to a contiguous range
+	unbounded ObjectList          // objects with infinite bounds, which are always checked
+}
+
+type bvhNode struct {
+	bounds geometry.AABB
+	// interior nodes have count == 0, with children at indices left and left+1
+	// leaves contain objects[start:start+count]
+	left  int
+	start int
+	count int
+}
+
+// a reference to an object while building the hierarchy, with its bounds precomputed
+type bvhItem struct {
+	obj      StaticBasicObject
+	bounds   geometry.AABB
+	centroid geometry.Point
+}
+
+// NewBVH builds a bounding volume hierarchy over the objects, splitting nodes using the surface area heuristic
+func NewBVH(objs []StaticBasicObject) *BVH {
+	bvh := &BVH{}
+	items := make([]bvhItem, 0, len(objs))
+	for _, obj := range objs {
+		bounds := obj.GetBounds()
+		if bounds.IsEmpty() {
+			continue
+		}
+		if isInfinite(bounds) {
+			bvh.unbounded = append(bvh.unbounded, obj)
+			continue
+		}
+		items = append(items, bvhItem{obj, bounds, bounds.Centroid()})
+	}
+	if len(items) == 0 {
+		return bvh
+	}
+	bvh.nodes = make([]bvhNode, 1, 2*len(items)/bvhLeafSize+1)
+	bvh.objects = make([]StaticBasicObject, 0, len(items))
+	bvh.build(0, items)
+	return bvh
+}
+
+func isInfinite(b geometry.AABB) bool {
+	for axis := range 3 {
+		if math.IsInf(b.Min.Axis(axis), 0) || math.IsInf(b.Max.Axis(axis), 0) {
+			return true
+		}
+	}
+	return false
+}
+
+// build fills in the node at index i to contain the items, recursively creating its children
+func (bvh *BVH) build(i int, items []bvhItem) {
+	bounds := geometry.EmptyAABB
+	centroidBounds := geometry.EmptyAABB
+	for _, item := range items {
+		bounds = bounds.Union(item.bounds)
+		centroidBounds = centroidBounds.AddPoint(item.centroid)
+	}
+	bvh.nodes[i].bounds = bounds
+	mid := splitItems(items, bounds, centroidBounds)
+	if mid <= 0 {
+		bvh.nodes[i].start = len(bvh.objects)
+		bvh.nodes[i].count = len(items)
+		for _, item := range items {
+			bvh.objects = append(bvh.objects, item.obj)
+		}
+		return
+	}
+	left := len(bvh.nodes)
+	bvh.nodes[i].left = left
+	bvh.nodes = append(bvh.nodes, bvhNode{}, bvhNode{})
+	bvh.build(left, items[:mid])
+	bvh.build(left+1, items[mid:])
+}
+
+// splitItems partitions the items in place along the split plane with the lowest surface area heuristic cost,
+// returning the number of items in the first half. It returns 0 if the items should stay in a leaf.
+func splitItems(items []bvhItem, bounds, centroidBounds geometry.AABB) int {
+	if len(items) <= bvhLeafSize {
+		return 0
+	}
+	axis := centroidBounds.LongestAxis()
+	lo, hi := centroidBounds.Min.Axis(axis), centroidBounds.Max.Axis(axis)
+	if hi <= lo {
+		// all centroids coincide, there is no plane to split them by
+		return 0
+	}
+	binOf := func(item bvhItem) int {
+		return min(bvhBins-1, int(bvhBins*(item.centroid.Axis(axis)-lo)/(hi-lo)))
+	}
+	var binCounts [bvhBins]int
+	var binBounds [bvhBins]geometry.AABB
+	for i := range binBounds {
+		binBounds[i] = geometry.EmptyAABB
+	}
+	for _, item := range items {
+		b := binOf(item)
+		binCounts[b]++
+		binBounds[b] = binBounds[b].Union(item.bounds)
+	}
+	// cost of splitting after bin i, proportional to the expected number of objects a ray has to check
+	var costs [bvhBins - 1]float64
+	leftBounds, leftCount := geometry.EmptyAABB, 0
+	for i := range bvhBins - 1 {
+		leftBounds = leftBounds.Union(binBounds[i])
+		leftCount += binCounts[i]
+		costs[i] = leftBounds.SurfaceArea() * float64(leftCount)
+	}
+	rightBounds, rightCount := geometry.EmptyAABB, 0
+	for i := bvhBins - 1; i > 0; i-- {
+		rightBounds = rightBounds.Union(binBounds[i])
+		rightCount += binCounts[i]
+		costs[i-1] += rightBounds.SurfaceArea() * float64(rightCount)
+	}
+	bestBin, bestCost := 0, math.Inf(1)
+	for i, cost := range costs {
+		if cost < bestCost {
+			bestBin, bestCost = i, cost
+		}
+	}
+	if bestCost >= bounds.SurfaceArea()*float64(len(items)) && len(items) <= 2*bvhLeafSize {
+		// splitting isn't worth it
+		return 0
+	}
+	// partition the items, so that those in bins up to bestBin come first
+	mid := 0
+	for i, item := range items {
+		if binOf(item) <= bestBin {
+			items[i], items[mid] = items[mid], items[i]
+			mid++
+		}
+	}
+	if mid == 0 || mid == len(items) {
+		return 0
+	}
+	return mid
+}
+
+func (bvh *BVH) ClosestHit(r geometry.Ray) *Hit {
+	closest := bvh.unbounded.ClosestHit(r)
+	maxT := math.MaxFloat64
+	if closest != nil {
+		maxT = closest.T
+	}
+	if len(bvh.nodes) == 0 {
+		return closest
+	}
+	invD := r.InverseDirection()
+	stack := make([]int, 0, 64)
+	stack = append(stack, 0)
+	for len(stack) > 0 {
+		node := bvh.nodes[stack[len(stack)-1]]
+		stack = stack[:len(stack)-1]
+		if _, _, ok := node.bounds.IntersectRay(r, invD, maxT); !ok {
+			continue
+		}
+		if node.count > 0 {
+			for _, obj := range bvh.objects[node.start : node.start+node.count] {
+				hit := obj.IntersectRay(r)
+				if hit != nil && hit.T < maxT {
+					maxT = hit.T
+					closest = hit
+				}
+			}
+			continue
+		}
+		// visit the nearer child first, it is more likely to contain the closest hit, pruning the farther one
+		near, far := node.left, node.left+1
+		nearT, _, nearOK := bvh.nodes[near].bounds.IntersectRay(r, invD, maxT)
+		farT, _, farOK := bvh.nodes[far].bounds.IntersectRay(r, invD, maxT)
+		if nearOK && farOK && farT < nearT {
+			near, far = far, near
+		}
+		if farOK || nearOK {
+			stack = append(stack, far, near)
+		}
+	}
+	return closest
+}
+
+func (bvh *BVH) VisibleHits(r geometry.Ray) []Hit {
+	hits, _ := bvh.CountedVisibleHits(r)
+	return hits
+}
+
+// CountedVisibleHits returns the same hits as VisibleHits, along with the number of nodes of the hierarchy
+// that the ray visited, as a measure of the work it took to find them
+func (bvh *BVH) CountedVisibleHits(r geometry.Ray) ([]Hit, int) {
+	hits := bvh.unbounded.VisibleHits(r)
+	maxT := math.MaxFloat64
+	if len(hits) > 0 && hits[len(hits)-1].Alpha >= 1 {
+		maxT = hits[len(hits)-1].T
+	}
+	if len(bvh.nodes) == 0 {
+		return hits, 0
+	}
+	invD := r.InverseDirection()
+	stack := make([]int, 0, 64)
+	stack = append(stack, 0)
+	visits := 0
+	for len(stack) > 0 {
+		node := bvh.nodes[stack[len(stack)-1]]
+		stack = stack[:len(stack)-1]
+		visits++
+		if _, _, ok := node.bounds.IntersectRay(r, invD, maxT); !ok {
+			continue
+		}
+		if node.count > 0 {
+			for _, obj := range bvh.objects[node.start : node.start+node.count] {
+				for _, hit := range obj.Hits(r) {
+					if hit.T >= maxT {
+						break
+					}
+					hits = append(hits, hit)
+					if hit.Alpha >= 1 {
+						// nothing behind an opaque hit is visible
+						maxT = hit.T
+						break
+					}
+				}
+			}
+			continue
+		}
+		// as in ClosestHit, visiting the nearer child first prunes more of the farther one
+		near, far := node.left, node.left+1
+		nearT, _, nearOK := bvh.nodes[near].bounds.IntersectRay(r, invD, maxT)
+		farT, _, farOK := bvh.nodes[far].bounds.IntersectRay(r, invD, maxT)
+		if nearOK && farOK && farT < nearT {
+			near, far = far, near
+		}
+		if farOK || nearOK {
+			stack = append(stack, far, near)
+		}
+	}
+	return FrontToBack(hits), visits
+}
+
+func (bvh *BVH) Transmittance(r geometry.Ray, maxT float64) float64 {
+	transmittance := bvh.unbounded.Transmittance(r, maxT)
+	if transmittance == 0 || len(bvh.nodes) == 0 {
+		return transmittance
+	}
+	invD := r.InverseDirection()
+	stack := make([]int, 0, 64)
+	stack = append(stack, 0)
+	for len(stack) > 0 {
+		node := bvh.nodes[stack[len(stack)-1]]
+		stack = stack[:len(stack)-1]
+		if _, _, ok := node.bounds.IntersectRay(r, invD, maxT); !ok {
+			continue
+		}
+		if node.count > 0 {
+			for _, obj := range bvh.objects[node.start : node.start+node.count] {
+				transmittance *= obj.transmittance(r, maxT)
+				if transmittance == 0 {
+					return 0
+				}
+			}
+			continue
+		}
+		stack = append(stack, node.left, node.left+1)
+	}
+	return transmittance
+}
@@ -0,0 +1,134 @@
+package objects
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"github.com/libeks/go-scene-renderer/colors"
+	"github.com/libeks/go-scene-renderer/geometry"
+	"github.com/libeks/go-scene-renderer/sampler"
+	"github.com/libeks/go-scene-renderer/textures"
+)
+
+// randomScene returns n objects of various kinds scattered around the origin, some of them see-through,
+// along with a floor plane, which has infinite bounds
+func randomScene(rnd *rand.Rand, n int) []StaticBasicObject {
+	point := func() geometry.Point {
+		return geometry.Pt(rnd.Float64()*8-4, rnd.Float64()*8-4, rnd.Float64()*8-4)
+	}
+	objs := []StaticBasicObject{
+		NewStaticBasicObject(NewPlane(geometry.Pt(0, -5, 0), geometry.V3(0, 1, 0)), textures.OpaqueTexture(textures.Uniform(colors.Gray))),
+	}
+	for i := range n {
+		var obj BasicObject
+		center := point()
+		switch i % 4 {
+		case 0:
+			obj = Tri(center, point(), point())
+		case 1:
+			obj = UnitSphere().ApplyMatrix(geometry.MatrixProduct(geometry.TranslationMatrix(center.Vector()), geometry.ScaleMatrix(0.1+rnd.Float64()/2)))
+		case 2:
+			obj = NewAxisAlignedBox(center, geometry.Point(center.Vector().AddVector(geometry.V3(rnd.Float64(), rnd.Float64(), rnd.Float64()))))
+		case 3:
+			obj = NewDisc(center, point().Vector(), 0.1+rnd.Float64()/2)
+		}
+		color := colors.Color{R: rnd.Float64(), G: rnd.Float64(), B: rnd.Float64()}
+		texture := textures.OpaqueTexture(textures.Uniform(color))
+		if i%3 == 0 {
+			texture = textures.GetDynamicTransparentTexture(
+				textures.StaticTexture(textures.Uniform(color)),
+				textures.StaticTransparency(textures.ConstantTransparency(0.5)),
+			).GetFrame(0)
+		}
+		objs = append(objs, NewStaticBasicObject(obj, texture))
+	}
+	return objs
+}
+
+func TestBVHMatchesObjectList(t *testing.T) {
+	for _, n := range []int{0, 1, 5, 50, 500} {
+		t.Run(fmt.Sprintf("%d objects", n), func(t *testing.T) {
+			rnd := rand.New(rand.NewSource(int64(n)))
+			objs := randomScene(rnd, n)
+			bvh := NewBVH(objs)
+			list := ObjectList(objs)
+			opts := []cmp.Option{cmpopts.EquateApprox(0, 1e-9), cmpopts.EquateEmpty(), cmp.AllowUnexported(Hit{})}
+			for range 500 {
+				r := geometry.Ray{
+					P: geometry.Pt(rnd.Float64()*16-8, rnd.Float64()*16-8, rnd.Float64()*16-8),
+					D: geometry.V3(rnd.NormFloat64(), rnd.NormFloat64(), rnd.NormFloat64()),
+				}
+				if diff := cmp.Diff(list.ClosestHit(r), bvh.ClosestHit(r), opts...); diff != "" {
+					t.Fatalf("ClosestHit(%s) mismatch (-want +got):\n%s", r, diff)
+				}
+				if diff := cmp.Diff(list.VisibleHits(r), bvh.VisibleHits(r), opts...); diff != "" {
+					t.Fatalf("VisibleHits(%s) mismatch (-want +got):\n%s", r, diff)
+				}
+				maxT := rnd.Float64() * 10
+				if want, got := list.Transmittance(r, maxT), bvh.Transmittance(r, maxT); math.Abs(want-got) > 1e-9 {
+					t.Fatalf("Transmittance(%s, %v) = %v, want %v", r, maxT, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestBVHAxisAlignedRays(t *testing.T) {
+	// rays parallel to an axis have infinite inverse directions along the others
+	objs := randomScene(rand.New(rand.NewSource(1)), 50)
+	bvh := NewBVH(objs)
+	list := ObjectList(objs)
+	for _, d := range []geometry.Vector3D{geometry.V3(1, 0, 0), geometry.V3(0, -1, 0), geometry.V3(0, 0, 1)} {
+		for _, p := range []geometry.Point{geometry.Pt(-8, 0.5, 0.5), geometry.Pt(0.5, 8, 0.5), geometry.Pt(0.5, 0.5, -8), geometry.Pt(1, 2, -math.Pi)} {
+			r := geometry.Ray{P: p, D: d}
+			if diff := cmp.Diff(list.VisibleHits(r), bvh.VisibleHits(r), cmpopts.EquateApprox(0, 1e-9), cmpopts.EquateEmpty(), cmp.AllowUnexported(Hit{})); diff != "" {
+				t.Errorf("VisibleHits(%s) mismatch (-want +got):\n%s", r, diff)
+			}
+		}
+	}
+}
+
+// BenchmarkHeightMap times building a BVH over a height map, and finding the hits of rays through it,
+// compared to checking every triangle
+//
+//	go test ./objects -run XXX -bench BenchmarkHeightMap
+func BenchmarkHeightMap(b *testing.B) {
+	// N=200 makes 2*199*199 = 79202 triangles
+	heightMap := HeightMap{
+		Height:   sampler.DynamicFromAnimated(sampler.NewPerlinNoise()),
+		Gradient: colors.Grayscale,
+		N:        200,
+	}
+	objs := heightMap.Frame(0).Flatten()
+	// rays from above the map, through a grid of points spanning it
+	rays := []geometry.Ray{}
+	for i := range 32 {
+		for j := range 32 {
+			x, z := float64(i)/16-1, float64(j)/16-1
+			rays = append(rays, geometry.Ray{P: geometry.Pt(0, 3, 3), D: geometry.Pt(x, 0, z).Subtract(geometry.Pt(0, 3, 3))})
+		}
+	}
+	b.Run("build", func(b *testing.B) {
+		for range b.N {
+			NewBVH(objs)
+		}
+	})
+	for _, tc := range []struct {
+		name    string
+		querier RayQuerier
+	}{
+		{name: "BVH", querier: NewBVH(objs)},
+		{name: "ObjectList", querier: ObjectList(objs)},
+	} {
+		b.Run(tc.name, func(b *testing.B) {
+			for i := range b.N {
+				tc.querier.VisibleHits(rays[i%len(rays)])
+			}
+		})
+	}
+}
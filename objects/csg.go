@@ -0,0 +1,138 @@
+package objects
+
+import (
+	"fmt"
+
+	"github.com/libeks/go-scene-renderer/geometry"
+	"github.com/libeks/go-scene-renderer/textures"
+)
+
+// CSGOperation is the way that a CSG combines its two solids
+type CSGOperation int
+
+const (
+	CSGUnion        CSGOperation = iota // the points inside either solid
+	CSGIntersection                     // the points inside both solids
+	CSGDifference                       // the points inside the first solid, but not the second
+)
+
+func (op CSGOperation) String() string {
+	switch op {
+	case CSGUnion:
+		return "union"
+	case CSGIntersection:
+		return "intersection"
+	case CSGDifference:
+		return "difference"
+	}
+	return fmt.Sprintf("CSGOperation(%d)", int(op))
+}
+
+// contains returns whether a point is inside the combination, given whether it is inside A and B
+func (op CSGOperation) contains(inA, inB bool) bool {
+	switch op {
+	case CSGIntersection:
+		return inA && inB
+	case CSGDifference:
+		return inA && !inB
+	}
+	return inA || inB
+}
+
+func DynamicCSG(s *CSG, colorer textures.DynamicTransparentTexture) dynamicBasicObject {
+	return dynamicBasicObject{
+		BasicObject: s,
+		Colorer:     colorer,
+	}
+}
+
+// Union returns the solid of the points inside either a or b
+func Union(a, b Solid) *CSG {
+	return &CSG{Operation: CSGUnion, A: a, B: b}
+}
+
+// Intersect returns the solid of the points inside both a and b
+func Intersect(a, b Solid) *CSG {
+	return &CSG{Operation: CSGIntersection, A: a, B: b}
+}
+
+// Subtract returns the solid of the points inside a, with b cut out of it
+func Subtract(a, b Solid) *CSG {
+	return &CSG{Operation: CSGDifference, A: a, B: b}
+}
+
+// CSG is a solid built from two others by constructive solid geometry. A ray intersects it wherever it crosses
+// the surface of either solid, and that crossing moves it into or out of the combination. The texture
+// coordinates are those of the solid whose surface is hit, and CSGs can be nested.
+//
+// implements Solid
+type CSG struct {
+	Operation CSGOperation
+	A         Solid
+	B         Solid
+}
+
+func (s *CSG) ApplyMatrix(m geometry.HomogeneusMatrix) BasicObject {
+	return &CSG{
+		Operation: s.Operation,
+		A:         s.A.ApplyMatrix(m).(Solid),
+		B:         s.B.ApplyMatrix(m).(Solid),
+	}
+}
+
+func (s *CSG) GetBoundingBox(c geometry.Camera) BoundingBox {
+	return aabbBoundingBox(s.GetBounds(), c)
+}
+
+func (s *CSG) GetBounds() geometry.AABB {
+	switch s.Operation {
+	case CSGIntersection:
+		return s.A.GetBounds().Intersect(s.B.GetBounds())
+	case CSGDifference:
+		return s.A.GetBounds()
+	}
+	return s.A.GetBounds().Union(s.B.GetBounds())
+}
+
+// GetWireframe returns the wireframes of both solids
+func (s *CSG) GetWireframe(c geometry.Camera) []geometry.RasterLine {
+	return append(s.A.GetWireframe(c), s.B.GetWireframe(c)...)
+}
+
+func (s *CSG) String() string {
+	return fmt.Sprintf("CSG %s of (%s) and (%s)", s.Operation, s.A, s.B)
+}
+
+func (s *CSG) Contains(p geometry.Point) bool {
+	return s.Operation.contains(s.A.Contains(p), s.B.Contains(p))
+}
+
+// RayIntersectLocalCoords merges the intersections of both solids along the ray, and keeps the ones where
+// the ray enters or exits the combination. Whether the ray enters or exits a solid at an intersection
+// is given by the direction of its normal.
+func (s *CSG) RayIntersectLocalCoords(r geometry.Ray) []intersection {
+	aInts, bInts := s.A.RayIntersectLocalCoords(r), s.B.RayIntersectLocalCoords(r)
+	inA, inB := s.A.Contains(r.P), s.B.Contains(r.P)
+	inside := s.Operation.contains(inA, inB)
+	intersections := []intersection{}
+	for len(aInts) > 0 || len(bInts) > 0 {
+		var int intersection
+		if len(bInts) == 0 || (len(aInts) > 0 && aInts[0].t <= bInts[0].t) {
+			int, aInts = aInts[0], aInts[1:]
+			inA = int.normal.DotProduct(r.D) < 0
+		} else {
+			int, bInts = bInts[0], bInts[1:]
+			inB = int.normal.DotProduct(r.D) < 0
+		}
+		if s.Operation.contains(inA, inB) == inside {
+			continue
+		}
+		inside = !inside
+		if (int.normal.DotProduct(r.D) < 0) != inside {
+			// the surface of a solid that is cut out of the combination faces into that solid
+			int.normal = int.normal.ScalarMultiply(-1)
+		}
+		intersections = append(intersections, int)
+	}
+	return intersections
+}
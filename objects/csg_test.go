@@ -0,0 +1,197 @@
+package objects
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"github.com/libeks/go-scene-renderer/geometry"
+)
+
+// surfaceHit is the part of an intersection that tests compare
+type surfaceHit struct {
+	T      float64
+	Normal geometry.Vector3D
+	B, C   float64
+}
+
+// surfaceHits returns the ray parameters and normals of the intersections, and their texture coordinates
+// if withCoords is set
+func surfaceHits(ints []intersection, withCoords bool) []surfaceHit {
+	hits := []surfaceHit{}
+	for _, int := range ints {
+		hit := surfaceHit{T: int.t, Normal: int.normal}
+		if withCoords {
+			hit.B, hit.C = int.b, int.c
+		}
+		hits = append(hits, hit)
+	}
+	return hits
+}
+
+func sphereAt(x float64) *Sphere {
+	return UnitSphere().ApplyMatrix(geometry.TranslationMatrix(geometry.V3(x, 0, 0))).(*Sphere)
+}
+
+func TestSolidIntersections(t *testing.T) {
+	alongX := geometry.Ray{P: geometry.Pt(-5, 0, 0), D: geometry.V3(1, 0, 0)}
+	// a box from (0,-1,-1) to (2,1,1), covering the right half of the unit sphere
+	rightBox := UnitBox().ApplyMatrix(geometry.TranslationMatrix(geometry.V3(1, 0, 0))).(Box)
+	floor := NewHalfSpace(geometry.Pt(0, -1, 0), geometry.V3(0, 1, 0))
+	for _, tc := range []struct {
+		name       string
+		solid      Solid
+		ray        geometry.Ray
+		withCoords bool
+		want       []surfaceHit
+	}{
+		{
+			name:       "box",
+			solid:      UnitBox(),
+			ray:        geometry.Ray{P: geometry.Pt(0.5, 0.5, 5), D: geometry.V3(0, 0, -1)},
+			withCoords: true,
+			want: []surfaceHit{
+				{T: 4, Normal: geometry.V3(0, 0, 1), B: 0.75, C: 0.75},
+				{T: 6, Normal: geometry.V3(0, 0, -1), B: 0.75, C: 0.75},
+			},
+		},
+		{
+			name:  "rotated box",
+			solid: UnitBox().ApplyMatrix(geometry.RotateMatrixY(math.Pi / 4)).(Box),
+			ray:   geometry.Ray{P: geometry.Pt(0, 0, 5), D: geometry.V3(0, 0, -1)},
+			want: []surfaceHit{
+				{T: 5 - math.Sqrt2, Normal: geometry.V3(1/math.Sqrt2, 0, 1/math.Sqrt2)},
+				{T: 5 + math.Sqrt2, Normal: geometry.V3(-1/math.Sqrt2, 0, -1/math.Sqrt2)},
+			},
+		},
+		{
+			name:       "half-space",
+			solid:      floor,
+			ray:        geometry.Ray{P: geometry.OriginPoint, D: geometry.V3(0.5, -1, -1.25)},
+			withCoords: true,
+			want:       []surfaceHit{{T: 1, Normal: geometry.V3(0, 1, 0), B: 0.5, C: 0.25}},
+		},
+		{
+			name:  "half-space seen from inside",
+			solid: floor,
+			ray:   geometry.Ray{P: geometry.Pt(0, -2, 0), D: geometry.V3(0, 1, 0)},
+			want:  []surfaceHit{{T: 1, Normal: geometry.V3(0, 1, 0)}},
+		},
+		{
+			name:  "union",
+			solid: Union(sphereAt(-0.5), sphereAt(0.5)),
+			ray:   alongX,
+			want: []surfaceHit{
+				{T: 3.5, Normal: geometry.V3(-1, 0, 0)},
+				{T: 6.5, Normal: geometry.V3(1, 0, 0)},
+			},
+		},
+		{
+			name:  "lens",
+			solid: Intersect(sphereAt(-0.5), sphereAt(0.5)),
+			ray:   alongX,
+			want: []surfaceHit{
+				{T: 4.5, Normal: geometry.V3(-1, 0, 0)},
+				{T: 5.5, Normal: geometry.V3(1, 0, 0)},
+			},
+		},
+		{
+			name:  "sphere bitten by a box",
+			solid: Subtract(sphereAt(0), rightBox),
+			ray:   alongX,
+			want: []surfaceHit{
+				{T: 4, Normal: geometry.V3(-1, 0, 0)},
+				// the face of the box cut into the sphere faces into the box
+				{T: 5, Normal: geometry.V3(1, 0, 0)},
+			},
+		},
+		{
+			name:  "starting inside the part that is cut out",
+			solid: Subtract(sphereAt(0), rightBox),
+			ray:   geometry.Ray{P: geometry.Pt(0.5, 0, 0), D: geometry.V3(-1, 0, 0)},
+			want: []surfaceHit{
+				{T: 0.5, Normal: geometry.V3(1, 0, 0)},
+				{T: 1.5, Normal: geometry.V3(-1, 0, 0)},
+			},
+		},
+		{
+			name:  "missing the part that is left",
+			solid: Subtract(sphereAt(0), rightBox),
+			ray:   geometry.Ray{P: geometry.Pt(0.5, 0, 5), D: geometry.V3(0, 0, -1)},
+			want:  []surfaceHit{},
+		},
+		{
+			name:  "hemisphere",
+			solid: Intersect(sphereAt(0), NewHalfSpace(geometry.OriginPoint, geometry.V3(0, 1, 0))),
+			ray:   geometry.Ray{P: geometry.Pt(0, 5, 0), D: geometry.V3(0, -1, 0)},
+			want: []surfaceHit{
+				{T: 5, Normal: geometry.V3(0, 1, 0)},
+				{T: 6, Normal: geometry.V3(0, -1, 0)},
+			},
+		},
+		{
+			name:  "box with a hole",
+			solid: Subtract(UnitBox(), NewCylinder(geometry.Pt(0, -2, 0), geometry.Pt(0, 2, 0), 0.5)),
+			ray:   alongX,
+			want: []surfaceHit{
+				{T: 4, Normal: geometry.V3(-1, 0, 0)},
+				{T: 4.5, Normal: geometry.V3(1, 0, 0)},
+				{T: 5.5, Normal: geometry.V3(-1, 0, 0)},
+				{T: 6, Normal: geometry.V3(1, 0, 0)},
+			},
+		},
+		{
+			name: "nested",
+			solid: Subtract(
+				Union(sphereAt(-0.5), sphereAt(0.5)),
+				UnitBox().ApplyMatrix(geometry.ScaleMatrix(0.25)).(Box),
+			),
+			ray: alongX,
+			want: []surfaceHit{
+				{T: 3.5, Normal: geometry.V3(-1, 0, 0)},
+				{T: 4.75, Normal: geometry.V3(1, 0, 0)},
+				{T: 5.25, Normal: geometry.V3(-1, 0, 0)},
+				{T: 6.5, Normal: geometry.V3(1, 0, 0)},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := surfaceHits(tc.solid.RayIntersectLocalCoords(tc.ray), tc.withCoords)
+			if diff := cmp.Diff(tc.want, got, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Errorf("RayIntersectLocalCoords() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCSGBounds(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		csg  *CSG
+		want geometry.AABB
+	}{
+		{
+			name: "union",
+			csg:  Union(sphereAt(-0.5), sphereAt(0.5)),
+			want: geometry.AABB{Min: geometry.Pt(-1.5, -1, -1), Max: geometry.Pt(1.5, 1, 1)},
+		},
+		{
+			name: "intersection with a half-space",
+			csg:  Intersect(sphereAt(0), NewHalfSpace(geometry.OriginPoint, geometry.V3(0, 1, 0))),
+			want: geometry.AABB{Min: geometry.Pt(-1, -1, -1), Max: geometry.Pt(1, 1, 1)},
+		},
+		{
+			name: "difference",
+			csg:  Subtract(sphereAt(-0.5), sphereAt(0.5)),
+			want: geometry.AABB{Min: geometry.Pt(-1.5, -1, -1), Max: geometry.Pt(0.5, 1, 1)},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, tc.csg.GetBounds()); diff != "" {
+				t.Errorf("GetBounds() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
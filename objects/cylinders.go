@@ -0,0 +1,214 @@
+package objects
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/libeks/go-scene-renderer/geometry"
+	"github.com/libeks/go-scene-renderer/maths"
+	"github.com/libeks/go-scene-renderer/textures"
+)
+
+// coneSlant is the length of the side of the unit cone, from its apex to the rim of its base
+var coneSlant = math.Sqrt(5)
+
+func DynamicCylinder(cyl Cylinder, colorer textures.DynamicTransparentTexture) dynamicBasicObject {
+	return dynamicBasicObject{
+		BasicObject: cyl,
+		Colorer:     colorer,
+	}
+}
+
+// UnitCylinder returns the cylinder of radius 1 around the y axis, from y=-1 to y=1
+func UnitCylinder() Cylinder {
+	return Cylinder{UnitBasis()}
+}
+
+// NewCylinder returns the capped cylinder with the radius, with the centers of its caps at bottom and top
+func NewCylinder(bottom, top geometry.Point, radius float64) Cylinder {
+	return Cylinder{axialBasis(bottom, top, radius)}
+}
+
+// axialBasis returns the basis with the center halfway between bottom and top, in which bottom is at y=-1,
+// top at y=1, and the other two axes have the length of the radius
+func axialBasis(bottom, top geometry.Point, radius float64) Basis {
+	half := top.Subtract(bottom).ScalarMultiply(0.5)
+	u, v := planeAxes(half)
+	return NewBasis(
+		geometry.Point(bottom.Vector().AddVector(half)),
+		[3]geometry.Vector3D{v.ScalarMultiply(radius), half, u.ScalarMultiply(radius)},
+	)
+}
+
+// Cylinder is a solid capped cylinder, of radius 1 around the up axis of its basis, from y=-1 to y=1.
+// It is textured like a Sphere, b is the longitude, and c goes from the center of the top cap at 0,
+// over its side, to the center of the bottom cap at 1, proportionally to the distance along the surface.
+//
+// implements Solid
+type Cylinder struct {
+	Basis
+}
+
+func (cyl Cylinder) ApplyMatrix(m geometry.HomogeneusMatrix) BasicObject {
+	return Cylinder{cyl.Basis.ApplyMatrix(m)}
+}
+
+func (cyl Cylinder) GetBoundingBox(c geometry.Camera) BoundingBox {
+	return cyl.boundingBox(unitMin, unitMax, c)
+}
+
+func (cyl Cylinder) GetBounds() geometry.AABB {
+	return cyl.bounds(unitMin, unitMax)
+}
+
+// GetWireframe returns the rims of the caps, and four lines along the side
+func (cyl Cylinder) GetWireframe(c geometry.Camera) []geometry.RasterLine {
+	lines := append(cyl.circle(-1, 1), cyl.circle(1, 1)...)
+	for _, v := range []geometry.Vector3D{geometry.V3(1, 0, 0), geometry.V3(0, 0, 1), geometry.V3(-1, 0, 0), geometry.V3(0, 0, -1)} {
+		lines = append(lines, geometry.Line{A: cyl.toWorld(v.AddVector(geometry.V3(0, -1, 0))), B: cyl.toWorld(v.AddVector(geometry.V3(0, 1, 0)))})
+	}
+	return cropLines(lines, c)
+}
+
+func (cyl Cylinder) String() string {
+	return fmt.Sprintf("Cylinder %s", cyl.Basis)
+}
+
+func (cyl Cylinder) Contains(p geometry.Point) bool {
+	local, ok := cyl.localPoint(p)
+	return ok && math.Abs(local.Y) < 1 && local.X*local.X+local.Z*local.Z < 1
+}
+
+// RayIntersectLocalCoords returns where the ray crosses the side of the cylinder between the caps,
+// and where it crosses the caps inside their rims
+func (cyl Cylinder) RayIntersectLocalCoords(r geometry.Ray) []intersection {
+	l, ok := cyl.localRay(r)
+	if !ok {
+		return nil
+	}
+	o, d := l.o, l.d
+	hits := []localHit{}
+	if d.X != 0 || d.Z != 0 {
+		for _, t := range maths.QuadraticRoots(d.X*d.X+d.Z*d.Z, 2*(o.X*d.X+o.Z*d.Z), o.X*o.X+o.Z*o.Z-1) {
+			p := l.at(t)
+			if math.Abs(p.Y) <= 1 {
+				hits = append(hits, localHit{t: t, b: longitude(p), c: (2 - p.Y) / 4, normal: geometry.V3(p.X, 0, p.Z)})
+			}
+		}
+	}
+	for _, y := range []float64{-1, 1} {
+		t, ok := capIntersect(l, y)
+		if !ok {
+			continue
+		}
+		p := l.at(t)
+		if rho := math.Hypot(p.X, p.Z); rho < 1 {
+			// the profile from the top center is 1 across the top cap, 2 along the side, and 1 across the bottom
+			c := rho / 4
+			if y < 0 {
+				c = 1 - rho/4
+			}
+			hits = append(hits, localHit{t: t, b: longitude(p), c: c, normal: geometry.V3(0, y, 0)})
+		}
+	}
+	return l.intersections(r, hits)
+}
+
+// capIntersect returns the ray parameter at which the local ray crosses the horizontal plane at height y,
+// false if it is parallel to it
+func capIntersect(l localRay, y float64) (float64, bool) {
+	if l.d.Y == 0 {
+		return 0, false
+	}
+	return (y - l.o.Y) / l.d.Y, true
+}
+
+func DynamicCone(cone Cone, colorer textures.DynamicTransparentTexture) dynamicBasicObject {
+	return dynamicBasicObject{
+		BasicObject: cone,
+		Colorer:     colorer,
+	}
+}
+
+// UnitCone returns the cone around the y axis, with its apex at y=1, and its base of radius 1 at y=-1
+func UnitCone() Cone {
+	return Cone{UnitBasis()}
+}
+
+// NewCone returns the capped cone with the center of the base and the apex, with the radius of the base
+func NewCone(base, apex geometry.Point, radius float64) Cone {
+	return Cone{axialBasis(base, apex, radius)}
+}
+
+// Cone is a solid cone around the up axis of its basis, with its apex at y=1, and its base of radius 1 at y=-1.
+// It is textured like a Sphere, b is the longitude, and c goes from the apex at 0, down its side, to
+// the center of the base at 1, proportionally to the distance along the surface.
+//
+// implements Solid
+type Cone struct {
+	Basis
+}
+
+func (cone Cone) ApplyMatrix(m geometry.HomogeneusMatrix) BasicObject {
+	return Cone{cone.Basis.ApplyMatrix(m)}
+}
+
+func (cone Cone) GetBoundingBox(c geometry.Camera) BoundingBox {
+	return cone.boundingBox(unitMin, unitMax, c)
+}
+
+func (cone Cone) GetBounds() geometry.AABB {
+	return cone.bounds(unitMin, unitMax)
+}
+
+// GetWireframe returns the rim of the base, and four lines from it to the apex
+func (cone Cone) GetWireframe(c geometry.Camera) []geometry.RasterLine {
+	lines := cone.circle(-1, 1)
+	apex := cone.toWorld(geometry.V3(0, 1, 0))
+	for _, v := range []geometry.Vector3D{geometry.V3(1, -1, 0), geometry.V3(0, -1, 1), geometry.V3(-1, -1, 0), geometry.V3(0, -1, -1)} {
+		lines = append(lines, geometry.Line{A: cone.toWorld(v), B: apex})
+	}
+	return cropLines(lines, c)
+}
+
+func (cone Cone) String() string {
+	return fmt.Sprintf("Cone %s", cone.Basis)
+}
+
+func (cone Cone) Contains(p geometry.Point) bool {
+	local, ok := cone.localPoint(p)
+	if !ok || math.Abs(local.Y) >= 1 {
+		return false
+	}
+	rho := (1 - local.Y) / 2
+	return local.X*local.X+local.Z*local.Z < rho*rho
+}
+
+// RayIntersectLocalCoords returns where the ray crosses the side of the cone, where the radius at height y
+// is (1-y)/2, and where it crosses the base inside its rim
+func (cone Cone) RayIntersectLocalCoords(r geometry.Ray) []intersection {
+	l, ok := cone.localRay(r)
+	if !ok {
+		return nil
+	}
+	o, d := l.o, l.d
+	hits := []localHit{}
+	// x^2 + z^2 = ((1-y)/2)^2, which also holds on the mirrored cone above the apex
+	a := d.X*d.X + d.Z*d.Z - d.Y*d.Y/4
+	b := 2*(o.X*d.X+o.Z*d.Z) + (1-o.Y)*d.Y/2
+	c := o.X*o.X + o.Z*o.Z - (1-o.Y)*(1-o.Y)/4
+	for _, t := range maths.QuadraticRoots(a, b, c) {
+		p := l.at(t)
+		if math.Abs(p.Y) <= 1 {
+			s := coneSlant * (1 - p.Y) / 2
+			hits = append(hits, localHit{t: t, b: longitude(p), c: s / (coneSlant + 1), normal: geometry.V3(p.X, (1-p.Y)/4, p.Z)})
+		}
+	}
+	if t, ok := capIntersect(l, -1); ok {
+		p := l.at(t)
+		if rho := math.Hypot(p.X, p.Z); rho < 1 {
+			hits = append(hits, localHit{t: t, b: longitude(p), c: (coneSlant + 1 - rho) / (coneSlant + 1), normal: geometry.V3(0, -1, 0)})
+		}
+	}
+	return l.intersections(r, hits)
+}
@@ -12,6 +12,9 @@ type HeightMap struct {
 	Gradient colors.Gradient
 	Height   sampler.DynamicSampler
 	N        int
+	// optional texture spanning the whole map, with (0,0) at x=-1, z=-1 and (1,1) at x=1, z=1.
+	// If unset, the map is colored by its height through the gradient
+	Texture textures.DynamicTransparentTexture
 }
 
 // func (o HeightMap) getAt(x, y, t float64) float64 {
@@ -20,35 +23,42 @@ type HeightMap struct {
 
 func (o HeightMap) Frame(t float64) StaticObject {
 	sampler := o.Height.GetFrame(t)
+	var texture textures.TransparentTexture
+	if o.Texture != nil {
+		texture = o.Texture.GetFrame(t)
+	}
+	uv := func(x, y float64) geometry.Vector2D {
+		return geometry.Vector2D{X: (x + 1) / 2, Y: (y + 1) / 2}
+	}
 	triangles := []StaticBasicObject{}
 	zMult := 1.0
-	for xd := range o.N {
-		for yd := range o.N {
+	// N vertices along each side make N-1 cells
+	for xd := range o.N - 1 {
+		for yd := range o.N - 1 {
 			dx, dy := 2/float64(o.N-1), 2/float64(o.N-1)
 			x, y := (2*float64(xd)/float64(o.N-1))-1.0, (2*float64(yd)/float64(o.N-1))-1.0
 
 			a, b, c, d := sampler.GetValue(x, y), sampler.GetValue(x, y+dy), sampler.GetValue(x+dx, y), sampler.GetValue(x+dx, y+dy)
+			lower := Tri(geometry.Pt(x, zMult*a, y), geometry.Pt(x, zMult*b, y+dy), geometry.Pt(x+dx, zMult*c, y))
+			upper := Tri(geometry.Pt(x+dx, zMult*d, y+dy), geometry.Pt(x, zMult*b, y+dy), geometry.Pt(x+dx, zMult*c, y))
+			if texture != nil {
+				triangles = append(triangles,
+					NewStaticBasicObject(lower.WithUVs(uv(x, y), uv(x, y+dy), uv(x+dx, y)), texture),
+					NewStaticBasicObject(upper.WithUVs(uv(x+dx, y+dy), uv(x, y+dy), uv(x+dx, y)), texture),
+				)
+				continue
+			}
 			triangles = append(triangles,
 				NewStaticBasicObject(
-					&Triangle{
-						A: geometry.Pt(x, zMult*a, y),
-						B: geometry.Pt(x, zMult*b, y+dy),
-						C: geometry.Pt(x+dx, zMult*c, y),
-					},
+					lower,
 					textures.OpaqueTexture(textures.TriangleGradientInterpolationTexture{
 						Gradient: o.Gradient,
 
 						A: a, B: b, C: c, D: d,
 					}),
 				),
-			)
-			triangles = append(triangles,
 				NewStaticBasicObject(
-					&Triangle{
-						A: geometry.Pt(x+dx, zMult*d, y+dy),
-						B: geometry.Pt(x, zMult*b, y+dy),
-						C: geometry.Pt(x+dx, zMult*c, y),
-					},
+					upper,
 					textures.OpaqueTexture(textures.TriangleGradientInterpolationTexture{
 						Gradient: o.Gradient,
 
@@ -85,11 +95,7 @@ func (o HeightMapCircle) Frame(t float64) StaticObject {
 			if inCircle(x, y) && inCircle(x+dx, y) && inCircle(x, y+dy) {
 				triangles = append(triangles,
 					NewStaticBasicObject(
-						&Triangle{
-							A: geometry.Pt(x, zMult*o.getAt(x, y, t), y),
-							B: geometry.Pt(x, zMult*o.getAt(x, y+dy, t), y+dy),
-							C: geometry.Pt(x+dx, zMult*o.getAt(x+dx, y, t), y),
-						},
+						Tri(geometry.Pt(x, zMult*o.getAt(x, y, t), y), geometry.Pt(x, zMult*o.getAt(x, y+dy, t), y+dy), geometry.Pt(x+dx, zMult*o.getAt(x+dx, y, t), y)),
 						textures.OpaqueTexture(textures.TriangleGradientTexture(
 							o.Gradient.Interpolate(o.getAt(x, y, t)),
 							o.Gradient.Interpolate(o.getAt(x, y+dy, t)),
@@ -102,11 +108,7 @@ func (o HeightMapCircle) Frame(t float64) StaticObject {
 			if inCircle(x+dx, y+dy) && inCircle(x+dx, y) && inCircle(x, y+dy) {
 				triangles = append(triangles,
 					NewStaticBasicObject(
-						&Triangle{
-							A: geometry.Pt(x+dx, zMult*o.getAt(x+dx, y+dy, t), y+dy),
-							B: geometry.Pt(x, zMult*o.getAt(x, y+dx, t), y+dy),
-							C: geometry.Pt(x+dx, zMult*o.getAt(x+dx, y, t), y),
-						},
+						Tri(geometry.Pt(x+dx, zMult*o.getAt(x+dx, y+dy, t), y+dy), geometry.Pt(x, zMult*o.getAt(x, y+dx, t), y+dy), geometry.Pt(x+dx, zMult*o.getAt(x+dx, y, t), y)),
 						textures.OpaqueTexture(textures.TriangleGradientTexture(
 							o.Gradient.Interpolate(o.getAt(x+dx, y+dy, t)),
 							o.Gradient.Interpolate(o.getAt(x, y+dy, t)),
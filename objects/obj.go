@@ -0,0 +1,329 @@
+package objects
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/libeks/go-scene-renderer/colors"
+	"github.com/libeks/go-scene-renderer/geometry"
+	"github.com/libeks/go-scene-renderer/textures"
+)
+
+// LoadOBJ reads a Wavefront OBJ mesh into an object of triangles, along with the MTL material libraries
+// and the texture images that it refers to, which are looked up relative to the OBJ file
+func LoadOBJ(file string) (DynamicObject, error) {
+	return ReadOBJ(os.DirFS(filepath.Dir(file)), filepath.Base(file))
+}
+
+// ReadOBJ reads the OBJ mesh name from fsys, see LoadOBJ.
+// Polygons are split into a fan of triangles, so they are expected to be convex. Triangles with
+// vertex normals are smooth, and those with texture coordinates are mapped onto their material's
+// diffuse texture map. Statements other than vertices, faces and materials are ignored.
+func ReadOBJ(fsys fs.FS, name string) (DynamicObject, error) {
+	p := &objParser{
+		fsys:      fsys,
+		dir:       path.Dir(name),
+		materials: map[string]objMaterial{},
+		material:  defaultOBJMaterial,
+	}
+	if err := p.parseFile(name, p.objStatement); err != nil {
+		return DynamicObject{}, err
+	}
+	if len(p.basics) == 0 {
+		return DynamicObject{}, fmt.Errorf("%s: no faces", name)
+	}
+	return DynamicObjectFromBasics(p.basics...), nil
+}
+
+// objMaterial is the part of an MTL material that is rendered
+type objMaterial struct {
+	diffuse colors.Color     // Kd, multiplies the texture if there is one
+	texture textures.Texture // map_Kd, nil if there is none
+	alpha   float64          // d, or 1-Tr
+}
+
+var defaultOBJMaterial = objMaterial{diffuse: colors.White, alpha: 1}
+
+type objParser struct {
+	fsys fs.FS
+	dir  string // directory of the OBJ file, that other files are relative to
+
+	positions []geometry.Point
+	uvs       []geometry.Vector2D
+	normals   []geometry.Vector3D
+	materials map[string]objMaterial
+	material  objMaterial // material of the faces that follow
+
+	basics []dynamicBasicObject
+}
+
+// objVertex holds the indices of a face's corner, uv and normal are -1 if it has none
+type objVertex struct {
+	position int
+	uv       int
+	normal   int
+}
+
+// parseFile calls statement with the keyword and arguments of each line of the file,
+// prefixing its errors with the file name and line number
+func (p *objParser) parseFile(name string, statement func(keyword string, args []string) error) error {
+	f, err := p.fsys.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+		if err := statement(fields[0], fields[1:]); err != nil {
+			return fmt.Errorf("%s:%d: %w", name, line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+func (p *objParser) objStatement(keyword string, args []string) error {
+	switch keyword {
+	case "v":
+		v, err := parseFloats(args, 3, 4)
+		if err != nil {
+			return err
+		}
+		p.positions = append(p.positions, geometry.Pt(v[0], v[1], v[2]))
+	case "vt":
+		v, err := parseFloats(args, 1, 3)
+		if err != nil {
+			return err
+		}
+		v = append(v, 0)
+		p.uvs = append(p.uvs, geometry.Vector2D{X: v[0], Y: v[1]})
+	case "vn":
+		v, err := parseFloats(args, 3, 3)
+		if err != nil {
+			return err
+		}
+		normal := geometry.V3(v[0], v[1], v[2])
+		if normal.Mag() == 0 {
+			return fmt.Errorf("normal has zero length")
+		}
+		p.normals = append(p.normals, normal.Unit())
+	case "f":
+		return p.face(args)
+	case "mtllib":
+		for _, lib := range args {
+			lib = path.Join(p.dir, lib)
+			if err := p.parseFile(lib, p.mtlStatement(path.Dir(lib))); err != nil {
+				return err
+			}
+		}
+	case "usemtl":
+		if len(args) != 1 {
+			return fmt.Errorf("expected a material name, got %d arguments", len(args))
+		}
+		material, ok := p.materials[args[0]]
+		if !ok {
+			return fmt.Errorf("unknown material '%s'", args[0])
+		}
+		p.material = material
+	}
+	return nil
+}
+
+// mtlStatement returns a parser of the statements of an MTL library in dir, adding its materials to p.materials
+func (p *objParser) mtlStatement(dir string) func(keyword string, args []string) error {
+	current := ""
+	return func(keyword string, args []string) error {
+		if keyword == "newmtl" {
+			if len(args) != 1 {
+				return fmt.Errorf("expected a material name, got %d arguments", len(args))
+			}
+			current = args[0]
+			p.materials[current] = defaultOBJMaterial
+			return nil
+		}
+		material, ok := p.materials[current]
+		if !ok {
+			switch keyword {
+			case "Kd", "map_Kd", "d", "Tr":
+				return fmt.Errorf("%s before newmtl", keyword)
+			}
+			return nil
+		}
+		switch keyword {
+		case "Kd":
+			v, err := parseFloats(args, 3, 3)
+			if err != nil {
+				return err
+			}
+			material.diffuse = colors.Color{R: v[0], G: v[1], B: v[2]}
+		case "map_Kd":
+			if len(args) == 0 {
+				return fmt.Errorf("expected a texture file")
+			}
+			// options come before the file name
+			file := path.Join(dir, args[len(args)-1])
+			texture, err := p.readTexture(file)
+			if err != nil {
+				return err
+			}
+			material.texture = texture
+		case "d", "Tr":
+			v, err := parseFloats(args, 1, 1)
+			if err != nil {
+				return err
+			}
+			material.alpha = v[0]
+			if keyword == "Tr" {
+				material.alpha = 1 - v[0]
+			}
+		}
+		p.materials[current] = material
+		return nil
+	}
+}
+
+func (p *objParser) readTexture(file string) (textures.Texture, error) {
+	f, err := p.fsys.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	texture, err := textures.ReadImageTexture(f)
+	if err != nil {
+		return nil, fmt.Errorf("could not read image %s: %w", file, err)
+	}
+	return texture, nil
+}
+
+// face adds the triangles of a polygon, as a fan around its first vertex
+func (p *objParser) face(args []string) error {
+	if len(args) < 3 {
+		return fmt.Errorf("face needs at least 3 vertices, got %d", len(args))
+	}
+	vertices := make([]objVertex, len(args))
+	for i, arg := range args {
+		v, err := p.vertex(arg)
+		if err != nil {
+			return err
+		}
+		vertices[i] = v
+	}
+	for i := 1; i+1 < len(vertices); i++ {
+		p.triangle(vertices[0], vertices[i], vertices[i+1])
+	}
+	return nil
+}
+
+// vertex parses a face's corner, written as v, v/vt, v//vn or v/vt/vn
+func (p *objParser) vertex(arg string) (objVertex, error) {
+	parts := strings.Split(arg, "/")
+	if len(parts) > 3 {
+		return objVertex{}, fmt.Errorf("could not parse face vertex '%s', expect v, v/vt, v//vn or v/vt/vn", arg)
+	}
+	v := objVertex{uv: -1, normal: -1}
+	var err error
+	if v.position, err = objIndex(parts[0], len(p.positions)); err != nil {
+		return objVertex{}, err
+	}
+	if len(parts) > 1 && parts[1] != "" {
+		if v.uv, err = objIndex(parts[1], len(p.uvs)); err != nil {
+			return objVertex{}, err
+		}
+	}
+	if len(parts) > 2 {
+		if v.normal, err = objIndex(parts[2], len(p.normals)); err != nil {
+			return objVertex{}, err
+		}
+	}
+	return v, nil
+}
+
+// objIndex returns the 0-based index of a 1-based OBJ index into a list of n elements,
+// negative indices count back from the end of the list
+func objIndex(s string, n int) (int, error) {
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("could not parse index '%s'", s)
+	}
+	if i < 0 {
+		i += n + 1
+	}
+	if i < 1 || i > n {
+		return 0, fmt.Errorf("index %s out of range, there are %d elements", s, n)
+	}
+	return i - 1, nil
+}
+
+func (p *objParser) triangle(a, b, c objVertex) {
+	pa, pb, pc := p.positions[a.position], p.positions[b.position], p.positions[c.position]
+	if pb.Subtract(pa).CrossProduct(pc.Subtract(pa)).Mag() == 0 {
+		// degenerate triangles have no normal, and can't be seen anyway
+		return
+	}
+	tri := Tri(pa, pb, pc)
+	if a.normal >= 0 && b.normal >= 0 && c.normal >= 0 {
+		tri = SmoothTri(pa, pb, pc, p.normals[a.normal], p.normals[b.normal], p.normals[c.normal])
+	}
+	material := p.material
+	if a.uv >= 0 && b.uv >= 0 && c.uv >= 0 {
+		tri = tri.WithUVs(p.uvs[a.uv], p.uvs[b.uv], p.uvs[c.uv])
+	} else {
+		// without texture coordinates, the texture map can't be placed
+		material.texture = nil
+	}
+	p.basics = append(p.basics, DynamicBasicObject(tri, material))
+}
+
+func (m objMaterial) GetFrame(float64) textures.TransparentTexture {
+	return m
+}
+
+// GetTextureColor returns the material's color at the texture coordinates (u,v)
+func (m objMaterial) GetTextureColor(u, v float64) colors.AlphaColor {
+	color := m.diffuse
+	if m.texture != nil {
+		color = m.texture.GetTextureColor(u, v).Multiply(color)
+	}
+	return color.WithAlpha(m.alpha)
+}
+
+// GetFilteredColor returns the material's color at (u,v), averaging its texture over the footprint
+func (m objMaterial) GetFilteredColor(u, v float64, f geometry.Footprint) colors.AlphaColor {
+	color := m.diffuse
+	if m.texture != nil {
+		color = textures.FilteredTextureColor(m.texture, u, v, f).Multiply(color)
+	}
+	return color.WithAlpha(m.alpha)
+}
+
+// parseFloats parses between minN and maxN numbers
+func parseFloats(args []string, minN, maxN int) ([]float64, error) {
+	if len(args) < minN || len(args) > maxN {
+		if minN == maxN {
+			return nil, fmt.Errorf("expected %d numbers, got %d", minN, len(args))
+		}
+		return nil, fmt.Errorf("expected %d to %d numbers, got %d", minN, maxN, len(args))
+	}
+	values := make([]float64, len(args))
+	for i, arg := range args {
+		v, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse number '%s'", arg)
+		}
+		values[i] = v
+	}
+	return values, nil
+}
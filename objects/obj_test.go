@@ -0,0 +1,139 @@
+package objects
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/libeks/go-scene-renderer/colors"
+	"github.com/libeks/go-scene-renderer/geometry"
+)
+
+const quadOBJ = `# a unit quad in the z=0 plane, facing +z
+mtllib quad.mtl
+v 0 0 0
+v 1 0 0
+v 1 1 0
+v 0 1 0
+vt 0 0
+vt 1 0
+vt 1 1
+vt 0 1
+vn 0 0 1
+usemtl painted
+f 1/1/1 2/2/1 3/3/1 4/4/1
+`
+
+const quadMTL = `newmtl painted
+Kd 1 1 1
+map_Kd textures/halves.png
+`
+
+// halvesPNG returns a 2x1 image, red on the left and blue on the right
+func halvesPNG(t *testing.T) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	img.Set(0, 0, color.RGBA{R: 255, A: 255})
+	img.Set(1, 0, color.RGBA{B: 255, A: 255})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestReadOBJ(t *testing.T) {
+	fsys := fstest.MapFS{
+		"models/quad.obj":            {Data: []byte(quadOBJ)},
+		"models/quad.mtl":            {Data: []byte(quadMTL)},
+		"models/textures/halves.png": {Data: halvesPNG(t)},
+	}
+	obj, err := ReadOBJ(fsys, "models/quad.obj")
+	if err != nil {
+		t.Fatalf("ReadOBJ: %s", err)
+	}
+	basics := obj.Frame(0).Flatten()
+	if len(basics) != 2 {
+		t.Fatalf("got %d triangles, want the quad split into 2", len(basics))
+	}
+	for _, tc := range []struct {
+		x, y float64
+		want colors.Color
+	}{
+		{x: 0.25, y: 0.2, want: colors.Red},
+		{x: 0.75, y: 0.2, want: colors.Blue},
+		{x: 0.25, y: 0.8, want: colors.Red},
+		{x: 0.75, y: 0.8, want: colors.Blue},
+	} {
+		ray := geometry.Ray{P: geometry.Pt(tc.x, tc.y, 1), D: geometry.V3(0, 0, -1)}
+		var hit *Hit
+		for _, basic := range basics {
+			if h := basic.IntersectRay(ray); h != nil {
+				hit = h
+			}
+		}
+		if hit == nil {
+			t.Errorf("no hit at (%v, %v)", tc.x, tc.y)
+			continue
+		}
+		if diff := cmp.Diff(tc.want, hit.Color); diff != "" {
+			t.Errorf("color at (%v, %v) mismatch (-want +got):\n%s", tc.x, tc.y, diff)
+		}
+		if diff := cmp.Diff(geometry.V3(0, 0, 1), hit.Normal); diff != "" {
+			t.Errorf("normal at (%v, %v) mismatch (-want +got):\n%s", tc.x, tc.y, diff)
+		}
+	}
+}
+
+func TestSmoothTriangleNormal(t *testing.T) {
+	tri := SmoothTri(
+		geometry.Pt(0, 0, 0), geometry.Pt(1, 0, 0), geometry.Pt(0, 1, 0),
+		geometry.V3(0, 0, 1), geometry.V3(1, 0, 0), geometry.V3(0, 0, 1),
+	)
+	got := tri.NormalAt(0.5, 0)
+	want := geometry.V3(1, 0, 1).Unit()
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("NormalAt() mismatch (-want +got):\n%s", diff)
+	}
+	// rotating the triangle rotates its normals along with it
+	rotated := tri.ApplyMatrix(geometry.RotateMatrixY(math.Pi / 2)).(*Triangle)
+	if n := rotated.NormalAt(0, 0); n.AddVector(geometry.V3(-1, 0, 0)).Mag() > 1e-9 {
+		t.Errorf("rotated normal = %s, want %s", n, geometry.V3(1, 0, 0))
+	}
+}
+
+func TestReadOBJErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		obj     string
+		wantErr string
+	}{
+		{name: "index out of range", obj: "v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1 2 4\n", wantErr: "model.obj:4: index 4 out of range"},
+		{name: "bad number", obj: "v 0 0 zero\n", wantErr: "model.obj:1: could not parse number 'zero'"},
+		{name: "too few vertices", obj: "v 0 0 0\nv 1 0 0\nf 1 2\n", wantErr: "model.obj:3: face needs at least 3 vertices"},
+		{name: "bad vertex", obj: "v 0 0 0\nf 1/1/1/1 1 1\n", wantErr: "model.obj:2: could not parse face vertex"},
+		{name: "unknown material", obj: "usemtl gold\n", wantErr: "model.obj:1: unknown material 'gold'"},
+		{name: "missing library", obj: "mtllib missing.mtl\n", wantErr: "model.obj:1: open missing.mtl"},
+		{name: "bad library", obj: "mtllib bad.mtl\n", wantErr: "model.obj:1: bad.mtl:2: expected 3 numbers, got 2"},
+		{name: "missing texture", obj: "mtllib notexture.mtl\n", wantErr: "notexture.mtl:2: open nothing.png"},
+		{name: "no faces", obj: "v 0 0 0\n", wantErr: "model.obj: no faces"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fsys := fstest.MapFS{
+				"model.obj":     {Data: []byte(tc.obj)},
+				"bad.mtl":       {Data: []byte("newmtl bad\nKd 1 1\n")},
+				"notexture.mtl": {Data: []byte("newmtl bad\nmap_Kd nothing.png\n")},
+			}
+			_, err := ReadOBJ(fsys, "model.obj")
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("ReadOBJ() error = %v, want one containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,262 @@
+package objects
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/libeks/go-scene-renderer/geometry"
+	"github.com/libeks/go-scene-renderer/textures"
+)
+
+func DynamicHalfSpace(h HalfSpace, colorer textures.DynamicTransparentTexture) dynamicBasicObject {
+	return dynamicBasicObject{
+		BasicObject: h,
+		Colorer:     colorer,
+	}
+}
+
+// NewHalfSpace returns the half-space behind the plane through p, facing the normal, tiled by unit squares
+func NewHalfSpace(p geometry.Point, normal geometry.Vector3D) HalfSpace {
+	u, v := planeAxes(normal)
+	return HalfSpace{Point: p, U: u, V: v}
+}
+
+// planeAxes returns perpendicular unit vectors u and v, along the plane with the normal, such that u×v
+// points along the normal
+func planeAxes(normal geometry.Vector3D) (geometry.Vector3D, geometry.Vector3D) {
+	n := normal.Unit()
+	// start from any axis that isn't close to the normal
+	u := geometry.V3(1, 0, 0)
+	if math.Abs(n.X) > 0.9 {
+		u = geometry.V3(0, 1, 0)
+	}
+	u = u.AddVector(n.ScalarMultiply(-u.DotProduct(n))).Unit()
+	return u, n.CrossProduct(u)
+}
+
+// HalfSpace is the solid behind the plane through Point spanned by U and V, extending infinitely away from
+// the normal U×V. The plane is tiled by the texture, one tile per parallelogram spanned by U and V,
+// so that the point Point + x*U + y*V has texture coordinates of the fractional parts of x and y.
+//
+// implements Solid
+type HalfSpace struct {
+	Point geometry.Point
+	U     geometry.Vector3D
+	V     geometry.Vector3D
+}
+
+func (h HalfSpace) ApplyMatrix(m geometry.HomogeneusMatrix) BasicObject {
+	p, ok := m.MultVect(h.Point.ToHomogenous()).ToPoint()
+	if !ok {
+		panic(fmt.Errorf("could not apply matrix %s to point %s", m, h.Point))
+	}
+	m3D := m.Slice3DMatrix()
+	u, v := m3D.MultVect(h.U), m3D.MultVect(h.V)
+	if m3D.Determinant() < 0 {
+		// a mirroring transform flips the cross product, swap the axes to keep the normal pointing outwards
+		u, v = v, u
+	}
+	return HalfSpace{Point: p, U: u, V: v}
+}
+
+// GetBoundingBox covers the whole screen, as the plane extends infinitely
+func (h HalfSpace) GetBoundingBox(c geometry.Camera) BoundingBox {
+	return fullScreenBB(0, math.Inf(1))
+}
+
+func (h HalfSpace) GetBounds() geometry.AABB {
+	return geometry.InfiniteAABB
+}
+
+// GetWireframe returns the outline of the tile at Point, the plane itself has no edges
+func (h HalfSpace) GetWireframe(c geometry.Camera) []geometry.RasterLine {
+	return h.surface().GetWireframe(c)
+}
+
+// tileOutline returns the edges of the parallelogram at p spanned by u and v
+func tileOutline(p geometry.Point, u, v geometry.Vector3D) []geometry.Line {
+	pu := geometry.Point(p.Vector().AddVector(u))
+	pv := geometry.Point(p.Vector().AddVector(v))
+	puv := geometry.Point(pu.Vector().AddVector(v))
+	return []geometry.Line{{A: p, B: pu}, {A: p, B: pv}, {A: pu, B: puv}, {A: pv, B: puv}}
+}
+
+func (h HalfSpace) String() string {
+	return fmt.Sprintf("HalfSpace at %s spanned by %s and %s", h.Point, h.U, h.V)
+}
+
+func (h HalfSpace) Contains(p geometry.Point) bool {
+	return p.Subtract(h.Point).DotProduct(h.U.CrossProduct(h.V)) < 0
+}
+
+func (h HalfSpace) RayIntersectLocalCoords(r geometry.Ray) []intersection {
+	return h.surface().RayIntersectLocalCoords(r)
+}
+
+// surface returns the plane that bounds the half-space
+func (h HalfSpace) surface() Plane {
+	return Plane{Point: h.Point, U: h.U, V: h.V}
+}
+
+func DynamicPlane(p Plane, colorer textures.DynamicTransparentTexture) dynamicBasicObject {
+	return dynamicBasicObject{
+		BasicObject: p,
+		Colorer:     colorer,
+	}
+}
+
+// NewPlane returns the plane through p, facing the normal, tiled by unit squares
+func NewPlane(p geometry.Point, normal geometry.Vector3D) Plane {
+	u, v := planeAxes(normal)
+	return Plane{Point: p, U: u, V: v}
+}
+
+// Plane is the infinite plane through Point spanned by U and V, with the normal U×V. It is tiled by the texture
+// like the surface of a HalfSpace, but it is only a surface, so it doesn't implement Solid.
+//
+// implements BasicObject
+type Plane struct {
+	Point geometry.Point
+	U     geometry.Vector3D
+	V     geometry.Vector3D
+}
+
+func (p Plane) ApplyMatrix(m geometry.HomogeneusMatrix) BasicObject {
+	h := HalfSpace(p).ApplyMatrix(m).(HalfSpace)
+	return h.surface()
+}
+
+// GetBoundingBox covers the whole screen, as the plane extends infinitely
+func (p Plane) GetBoundingBox(c geometry.Camera) BoundingBox {
+	return fullScreenBB(0, math.Inf(1))
+}
+
+func (p Plane) GetBounds() geometry.AABB {
+	return geometry.InfiniteAABB
+}
+
+// GetWireframe returns the outline of the tile at Point, the plane itself has no edges
+func (p Plane) GetWireframe(c geometry.Camera) []geometry.RasterLine {
+	return cropLines(tileOutline(p.Point, p.U, p.V), c)
+}
+
+func (p Plane) String() string {
+	return fmt.Sprintf("Plane at %s spanned by %s and %s", p.Point, p.U, p.V)
+}
+
+func (p Plane) RayIntersectLocalCoords(r geometry.Ray) []intersection {
+	n := p.U.CrossProduct(p.V)
+	t, ok := planeIntersect(p.Point, n, r)
+	if !ok {
+		return nil
+	}
+	point := r.PointAt(t)
+	x, y := planeCoords(point.Subtract(p.Point), p.U, p.V)
+	return []intersection{{x - math.Floor(x), y - math.Floor(y), -point.Z, t, n.Unit()}}
+}
+
+func DynamicDisc(d Disc, colorer textures.DynamicTransparentTexture) dynamicBasicObject {
+	return dynamicBasicObject{
+		BasicObject: d,
+		Colorer:     colorer,
+	}
+}
+
+// NewDisc returns the disc around center with the radius, facing the normal
+func NewDisc(center geometry.Point, normal geometry.Vector3D, radius float64) Disc {
+	u, v := planeAxes(normal)
+	return Disc{Center: center, U: u.ScalarMultiply(radius), V: v.ScalarMultiply(radius)}
+}
+
+// Disc is the flat disc of the points Center + x*U + y*V with x^2 + y^2 <= 1, which is an ellipse if U and V
+// aren't perpendicular or of the same length. It has the normal U×V. The disc is inscribed in the texture,
+// the point Center + x*U + y*V has the texture coordinates ((x+1)/2, (y+1)/2).
+//
+// implements BasicObject
+type Disc struct {
+	Center geometry.Point
+	U      geometry.Vector3D
+	V      geometry.Vector3D
+}
+
+func (d Disc) ApplyMatrix(m geometry.HomogeneusMatrix) BasicObject {
+	center, ok := m.MultVect(d.Center.ToHomogenous()).ToPoint()
+	if !ok {
+		panic(fmt.Errorf("could not apply matrix %s to point %s", m, d.Center))
+	}
+	m3D := m.Slice3DMatrix()
+	return Disc{Center: center, U: m3D.MultVect(d.U), V: m3D.MultVect(d.V)}
+}
+
+func (d Disc) GetBoundingBox(c geometry.Camera) BoundingBox {
+	return aabbBoundingBox(d.GetBounds(), c)
+}
+
+// GetBounds returns the tight bounds of the ellipse, along each axis it extends by the length of
+// the vector of the components of U and V
+func (d Disc) GetBounds() geometry.AABB {
+	extent := geometry.V3(math.Hypot(d.U.X, d.V.X), math.Hypot(d.U.Y, d.V.Y), math.Hypot(d.U.Z, d.V.Z))
+	return geometry.AABB{
+		Min: geometry.Point(d.Center.Vector().AddVector(extent.ScalarMultiply(-1))),
+		Max: geometry.Point(d.Center.Vector().AddVector(extent)),
+	}
+}
+
+// GetWireframe returns the rim of the disc
+func (d Disc) GetWireframe(c geometry.Camera) []geometry.RasterLine {
+	return cropLines(ellipse(d.Center, d.U, d.V, circleSegments), c)
+}
+
+func (d Disc) String() string {
+	return fmt.Sprintf("Disc at %s spanned by %s and %s", d.Center, d.U, d.V)
+}
+
+func (d Disc) RayIntersectLocalCoords(r geometry.Ray) []intersection {
+	n := d.U.CrossProduct(d.V)
+	t, ok := planeIntersect(d.Center, n, r)
+	if !ok {
+		return nil
+	}
+	p := r.PointAt(t)
+	x, y := planeCoords(p.Subtract(d.Center), d.U, d.V)
+	if x*x+y*y > 1 {
+		return nil
+	}
+	return []intersection{{(x + 1) / 2, (y + 1) / 2, -p.Z, t, n.Unit()}}
+}
+
+// circleSegments is the number of lines that wireframes draw circles with
+const circleSegments = 24
+
+// ellipse returns the lines of a polygon with n sides inscribed in the ellipse of the points
+// center + cos(a)*u + sin(a)*v
+func ellipse(center geometry.Point, u, v geometry.Vector3D, n int) []geometry.Line {
+	points := make([]geometry.Point, n)
+	for i := range points {
+		angle := 2 * math.Pi * float64(i) / float64(n)
+		points[i] = geometry.Point(center.Vector().AddVector(u.ScalarMultiply(math.Cos(angle))).AddVector(v.ScalarMultiply(math.Sin(angle))))
+	}
+	lines := make([]geometry.Line, n)
+	for i := range points {
+		lines[i] = geometry.Line{A: points[i], B: points[(i+1)%n]}
+	}
+	return lines
+}
+
+// planeIntersect returns the ray parameter at which the ray crosses the plane through p with the normal n,
+// false if it doesn't cross it in front of its origin
+func planeIntersect(p geometry.Point, n geometry.Vector3D, r geometry.Ray) (float64, bool) {
+	denominator := n.DotProduct(r.D)
+	if denominator == 0 {
+		return 0, false
+	}
+	t := p.Subtract(r.P).DotProduct(n) / denominator
+	return t, t > 0
+}
+
+// planeCoords returns (x,y) such that w = x*u + y*v, for w along the plane spanned by u and v
+func planeCoords(w, u, v geometry.Vector3D) (float64, float64) {
+	n := u.CrossProduct(v)
+	nn := n.DotProduct(n)
+	return w.CrossProduct(v).DotProduct(n) / nn, u.CrossProduct(w).DotProduct(n) / nn
+}
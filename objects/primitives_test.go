@@ -0,0 +1,266 @@
+package objects
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"github.com/libeks/go-scene-renderer/geometry"
+)
+
+func TestPrimitiveIntersections(t *testing.T) {
+	alongX := geometry.Ray{P: geometry.Pt(-5, 0, 0), D: geometry.V3(1, 0, 0)}
+	down := func(x, z float64) geometry.Ray {
+		return geometry.Ray{P: geometry.Pt(x, 5, z), D: geometry.V3(0, -1, 0)}
+	}
+	// the unit normal of the cone's side, from the axis outwards in the direction of x
+	coneNormal := func(x float64) geometry.Vector3D {
+		return geometry.V3(2*x, 1, 0).Unit()
+	}
+	for _, tc := range []struct {
+		name       string
+		object     BasicObject
+		ray        geometry.Ray
+		withCoords bool
+		want       []surfaceHit
+	}{
+		{
+			name:       "axis-aligned box",
+			object:     NewAxisAlignedBox(geometry.Pt(0, 0, 0), geometry.Pt(2, 4, 6)),
+			ray:        geometry.Ray{P: geometry.Pt(1, 2, 10), D: geometry.V3(0, 0, -1)},
+			withCoords: true,
+			want: []surfaceHit{
+				{T: 4, Normal: geometry.V3(0, 0, 1), B: 0.5, C: 0.5},
+				{T: 10, Normal: geometry.V3(0, 0, -1), B: 0.5, C: 0.5},
+			},
+		},
+		{
+			name:       "cylinder side",
+			object:     UnitCylinder(),
+			ray:        geometry.Ray{P: geometry.Pt(0, 0, 5), D: geometry.V3(0, 0, -1)},
+			withCoords: true,
+			want: []surfaceHit{
+				{T: 4, Normal: geometry.V3(0, 0, 1), B: 0, C: 0.5},
+				{T: 6, Normal: geometry.V3(0, 0, -1), B: 0.5, C: 0.5},
+			},
+		},
+		{
+			name:       "cylinder caps",
+			object:     UnitCylinder(),
+			ray:        down(0.5, 0),
+			withCoords: true,
+			want: []surfaceHit{
+				{T: 4, Normal: geometry.V3(0, 1, 0), B: 0.75, C: 0.125},
+				{T: 6, Normal: geometry.V3(0, -1, 0), B: 0.75, C: 0.875},
+			},
+		},
+		{
+			name:   "cylinder between points",
+			object: NewCylinder(geometry.Pt(0, 0, 0), geometry.Pt(0, 4, 0), 2),
+			ray:    geometry.Ray{P: geometry.Pt(-5, 1, 0), D: geometry.V3(1, 0, 0)},
+			want: []surfaceHit{
+				{T: 3, Normal: geometry.V3(-1, 0, 0)},
+				{T: 7, Normal: geometry.V3(1, 0, 0)},
+			},
+		},
+		{
+			name:   "lying cylinder",
+			object: NewCylinder(geometry.Pt(-1, 0, 0), geometry.Pt(1, 0, 0), 0.5),
+			ray:    alongX,
+			want: []surfaceHit{
+				{T: 4, Normal: geometry.V3(-1, 0, 0)},
+				{T: 6, Normal: geometry.V3(1, 0, 0)},
+			},
+		},
+		{
+			name:       "cone side",
+			object:     UnitCone(),
+			ray:        alongX,
+			withCoords: true,
+			want: []surfaceHit{
+				{T: 4.5, Normal: coneNormal(-1), B: 0.25, C: coneSlant / 2 / (coneSlant + 1)},
+				{T: 5.5, Normal: coneNormal(1), B: 0.75, C: coneSlant / 2 / (coneSlant + 1)},
+			},
+		},
+		{
+			name:       "cone from below",
+			object:     UnitCone(),
+			ray:        geometry.Ray{P: geometry.Pt(0.25, -5, 0), D: geometry.V3(0, 1, 0)},
+			withCoords: true,
+			want: []surfaceHit{
+				{T: 4, Normal: geometry.V3(0, -1, 0), B: 0.75, C: (coneSlant + 0.75) / (coneSlant + 1)},
+				{T: 5.5, Normal: coneNormal(1), B: 0.75, C: coneSlant / 4 / (coneSlant + 1)},
+			},
+		},
+		{
+			name:   "missing the cone above its apex",
+			object: UnitCone(),
+			ray:    geometry.Ray{P: geometry.Pt(-5, 2, 0), D: geometry.V3(1, 0, 0)},
+			want:   []surfaceHit{},
+		},
+		{
+			name:       "torus across",
+			object:     UnitTorus(0.25),
+			ray:        alongX,
+			withCoords: true,
+			want: []surfaceHit{
+				{T: 3.75, Normal: geometry.V3(-1, 0, 0), B: 0.25, C: 0},
+				{T: 4.25, Normal: geometry.V3(1, 0, 0), B: 0.25, C: 0.5},
+				{T: 5.75, Normal: geometry.V3(-1, 0, 0), B: 0.75, C: 0.5},
+				{T: 6.25, Normal: geometry.V3(1, 0, 0), B: 0.75, C: 0},
+			},
+		},
+		{
+			name:       "torus from above",
+			object:     UnitTorus(0.25),
+			ray:        down(1, 0),
+			withCoords: true,
+			want: []surfaceHit{
+				{T: 4.75, Normal: geometry.V3(0, 1, 0), B: 0.75, C: 0.25},
+				{T: 5.25, Normal: geometry.V3(0, -1, 0), B: 0.75, C: 0.75},
+			},
+		},
+		{
+			name:   "through the hole of the torus",
+			object: UnitTorus(0.25),
+			ray:    down(0, 0),
+			want:   []surfaceHit{},
+		},
+		{
+			name:   "torus facing the camera",
+			object: NewTorus(geometry.OriginPoint, geometry.V3(0, 0, 1), 2, 0.5),
+			ray:    geometry.Ray{P: geometry.Pt(2, 0, 5), D: geometry.V3(0, 0, -1)},
+			want: []surfaceHit{
+				{T: 4.5, Normal: geometry.V3(0, 0, 1)},
+				{T: 5.5, Normal: geometry.V3(0, 0, -1)},
+			},
+		},
+		{
+			name:       "disc",
+			object:     NewDisc(geometry.OriginPoint, geometry.V3(0, 0, 1), 2),
+			ray:        geometry.Ray{P: geometry.Pt(1, 0, 5), D: geometry.V3(0, 0, -1)},
+			withCoords: true,
+			want:       []surfaceHit{{T: 5, Normal: geometry.V3(0, 0, 1), B: 0.75, C: 0.5}},
+		},
+		{
+			name:   "outside the disc",
+			object: NewDisc(geometry.OriginPoint, geometry.V3(0, 0, 1), 2),
+			ray:    geometry.Ray{P: geometry.Pt(1.5, 1.5, 5), D: geometry.V3(0, 0, -1)},
+			want:   []surfaceHit{},
+		},
+		{
+			name:       "plane from below",
+			object:     NewPlane(geometry.Pt(0, -1, 0), geometry.V3(0, 1, 0)),
+			ray:        geometry.Ray{P: geometry.Pt(0.25, -2, -0.5), D: geometry.V3(0, 1, 0)},
+			withCoords: true,
+			want:       []surfaceHit{{T: 1, Normal: geometry.V3(0, 1, 0), B: 0.25, C: 0.5}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := surfaceHits(tc.object.RayIntersectLocalCoords(tc.ray), tc.withCoords)
+			if diff := cmp.Diff(tc.want, got, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Errorf("RayIntersectLocalCoords() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPrimitiveContains(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		solid Solid
+		p     geometry.Point
+		want  bool
+	}{
+		{name: "inside the cylinder", solid: UnitCylinder(), p: geometry.Pt(0, 0.9, 0.9), want: true},
+		{name: "beside the cylinder", solid: UnitCylinder(), p: geometry.Pt(0.8, 0, 0.8), want: false},
+		{name: "above the cylinder", solid: UnitCylinder(), p: geometry.Pt(0, 1.1, 0), want: false},
+		{name: "inside the cone", solid: UnitCone(), p: geometry.Pt(0, 0.5, 0.2), want: true},
+		{name: "beside the cone", solid: UnitCone(), p: geometry.Pt(0, 0.5, 0.3), want: false},
+		{name: "inside the torus", solid: UnitTorus(0.25), p: geometry.Pt(1, 0.2, 0), want: true},
+		{name: "in the hole of the torus", solid: UnitTorus(0.25), p: geometry.OriginPoint, want: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.solid.Contains(tc.p); got != tc.want {
+				t.Errorf("Contains(%s) = %v, want %v", tc.p, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPrimitiveBounds(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		object BasicObject
+		want   geometry.AABB
+	}{
+		{
+			name:   "cylinder",
+			object: NewCylinder(geometry.OriginPoint, geometry.Pt(0, 4, 0), 2),
+			want:   geometry.AABB{Min: geometry.Pt(-2, 0, -2), Max: geometry.Pt(2, 4, 2)},
+		},
+		{
+			name:   "torus",
+			object: UnitTorus(0.25).ApplyMatrix(geometry.TranslationMatrix(geometry.V3(0, 1, 0))),
+			want:   geometry.AABB{Min: geometry.Pt(-1.25, 0.75, -1.25), Max: geometry.Pt(1.25, 1.25, 1.25)},
+		},
+		{
+			name:   "tilted disc",
+			object: NewDisc(geometry.OriginPoint, geometry.V3(0, 1, 1), 1),
+			want: geometry.AABB{
+				Min: geometry.Pt(-1, -1/math.Sqrt2, -1/math.Sqrt2),
+				Max: geometry.Pt(1, 1/math.Sqrt2, 1/math.Sqrt2),
+			},
+		},
+		{
+			name:   "plane",
+			object: NewPlane(geometry.OriginPoint, geometry.V3(0, 1, 0)),
+			want:   geometry.InfiniteAABB,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, tc.object.GetBounds(), cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Errorf("GetBounds() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestBasisLocalPoint(t *testing.T) {
+	// scaled, rotated, sheared and moved
+	m := geometry.MatrixProduct(
+		geometry.TranslationMatrix(geometry.V3(1, -2, 3)),
+		geometry.RotateMatrixY(0.3),
+		geometry.HomogeneusMatrix{A1: 2, A2: 0.5, B2: 3, C3: 0.5, D4: 1},
+	)
+	transformed := UnitBasis().ApplyMatrix(m)
+	if !transformed.inverted {
+		t.Errorf("ApplyMatrix() didn't compute the inverse of the basis")
+	}
+	local := geometry.V3(0.25, -0.5, 0.75)
+	for _, tc := range []struct {
+		name  string
+		basis Basis
+	}{
+		{name: "transformed", basis: transformed},
+		// a literal has no inverse computed up front
+		{name: "literal", basis: Basis{Center: transformed.Center, Axes: transformed.Axes}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := tc.basis.localPoint(tc.basis.toWorld(local))
+			if !ok {
+				t.Fatalf("localPoint() found the basis flat")
+			}
+			if diff := cmp.Diff(local, got, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Errorf("localPoint() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+	// squashed along z
+	flat := UnitBasis().ApplyMatrix(geometry.HomogeneusMatrix{A1: 1, B2: 1, D4: 1})
+	if _, ok := flat.localPoint(geometry.Pt(1, 2, 3)); ok {
+		t.Errorf("localPoint() in a flat basis succeeded")
+	}
+}
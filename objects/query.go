@@ -0,0 +1,69 @@
+package objects
+
+import (
+	"cmp"
+	"math"
+	"slices"
+
+	"github.com/libeks/go-scene-renderer/geometry"
+)
+
+// RayQuerier answers ray queries against a set of StaticBasicObjects, all in camera space
+type RayQuerier interface {
+	// ClosestHit returns the closest non-transparent hit along the ray, or nil if there is none
+	ClosestHit(r geometry.Ray) *Hit
+	// VisibleHits returns the non-transparent hits along the ray, sorted by ray parameter,
+	// up to and including the closest opaque one
+	VisibleHits(r geometry.Ray) []Hit
+	// Transmittance returns the fraction of light that passes through the objects along the ray,
+	// with a ray parameter below maxT. It is 0 if an opaque object blocks the ray.
+	Transmittance(r geometry.Ray, maxT float64) float64
+}
+
+// ObjectList is a RayQuerier that checks every object in turn
+type ObjectList []StaticBasicObject
+
+func (l ObjectList) ClosestHit(r geometry.Ray) *Hit {
+	var closest *Hit
+	minT := math.MaxFloat64
+	for _, obj := range l {
+		hit := obj.IntersectRay(r)
+		if hit != nil && hit.T < minT {
+			minT = hit.T
+			closest = hit
+		}
+	}
+	return closest
+}
+
+func (l ObjectList) VisibleHits(r geometry.Ray) []Hit {
+	var hits []Hit
+	for _, obj := range l {
+		hits = append(hits, obj.Hits(r)...)
+	}
+	return FrontToBack(hits)
+}
+
+func (l ObjectList) Transmittance(r geometry.Ray, maxT float64) float64 {
+	transmittance := 1.0
+	for _, obj := range l {
+		transmittance *= obj.transmittance(r, maxT)
+		if transmittance == 0 {
+			return 0
+		}
+	}
+	return transmittance
+}
+
+// FrontToBack sorts the hits by ray parameter, dropping those hidden behind the closest opaque hit
+func FrontToBack(hits []Hit) []Hit {
+	slices.SortFunc(hits, func(a, b Hit) int {
+		return cmp.Compare(a.T, b.T)
+	})
+	for i, hit := range hits {
+		if hit.Alpha >= 1 {
+			return hits[:i+1]
+		}
+	}
+	return hits
+}
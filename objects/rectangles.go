@@ -12,43 +12,33 @@ func GradientParallelogram(a, b, c geometry.Point, colorA, colorB, colorC, color
 
 	return DynamicObjectFromBasics(
 		DynamicBasicObject(
-			&Triangle{
-				A: a,
-				B: b,
-				C: c,
-			},
+			Tri(a, b, c),
 			textures.OpaqueDynamicTexture(textures.StaticTexture(textures.TriangleGradientTexture(colorA, colorB, colorC))),
 		),
 		DynamicBasicObject(
-			&Triangle{
-				A: d,
-				B: c,
-				C: b,
-			},
+			Tri(d, c, b),
 			textures.OpaqueDynamicTexture(textures.StaticTexture(textures.TriangleGradientTexture(colorD, colorC, colorB))),
 		),
 	)
 }
 
+// Parallelogram returns the parallelogram with corners a, b and c, and the fourth one opposite a.
+// The texture spans the whole parallelogram, with (0,0) at a, (1,0) at b and (0,1) at c.
 func Parallelogram(a, b, c geometry.Point, texture textures.DynamicTransparentTexture) DynamicObject {
 	d := geometry.Point(c.Add(geometry.Point(b.Subtract(a))))
 
 	return DynamicObjectFromBasics(
 		DynamicBasicObject(
-			&Triangle{
-				A: a,
-				B: b,
-				C: c,
-			},
+			Tri(a, b, c).WithUVs(
+				geometry.Vector2D{X: 0, Y: 0}, geometry.Vector2D{X: 1, Y: 0}, geometry.Vector2D{X: 0, Y: 1},
+			),
 			texture,
 		),
 		DynamicBasicObject(
-			&Triangle{
-				A: d,
-				B: c,
-				C: b,
-			},
-			textures.RotateDynamicTexture180(texture),
+			Tri(d, c, b).WithUVs(
+				geometry.Vector2D{X: 1, Y: 1}, geometry.Vector2D{X: 0, Y: 1}, geometry.Vector2D{X: 1, Y: 0},
+			),
+			texture,
 		),
 	)
 }
@@ -85,27 +75,15 @@ func AxisAlignedPointer() DynamicObject {
 	offset := 0.2
 	return DynamicObjectFromBasics(
 		DynamicBasicObject(
-			&Triangle{
-				A: geometry.Pt(0, 0, 0),
-				B: geometry.Pt(1, 0, 0),
-				C: geometry.Pt(0, offset, 0),
-			},
+			Tri(geometry.Pt(0, 0, 0), geometry.Pt(1, 0, 0), geometry.Pt(0, offset, 0)),
 			textures.OpaqueDynamicTexture(textures.StaticTexture(textures.HorizontalGradient{Gradient: colors.SimpleGradient{Start: colors.Green, End: colors.Green}})),
 		),
 		DynamicBasicObject(
-			&Triangle{
-				A: geometry.Pt(0, 0, 0),
-				B: geometry.Pt(0, 1, 0),
-				C: geometry.Pt(0, 0, -offset),
-			},
+			Tri(geometry.Pt(0, 0, 0), geometry.Pt(0, 1, 0), geometry.Pt(0, 0, -offset)),
 			textures.OpaqueDynamicTexture(textures.StaticTexture(textures.HorizontalGradient{Gradient: colors.SimpleGradient{Start: colors.Red, End: colors.Red}})),
 		),
 		DynamicBasicObject(
-			&Triangle{
-				A: geometry.Pt(0, 0, 0),
-				B: geometry.Pt(0, 0, -1),
-				C: geometry.Pt(offset, 0, 0),
-			},
+			Tri(geometry.Pt(0, 0, 0), geometry.Pt(0, 0, -1), geometry.Pt(offset, 0, 0)),
 			textures.OpaqueDynamicTexture(textures.StaticTexture(textures.HorizontalGradient{Gradient: colors.SimpleGradient{Start: colors.Blue, End: colors.Blue}})),
 		),
 	)
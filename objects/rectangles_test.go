@@ -0,0 +1,67 @@
+package objects
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"github.com/libeks/go-scene-renderer/colors"
+	"github.com/libeks/go-scene-renderer/geometry"
+	"github.com/libeks/go-scene-renderer/textures"
+)
+
+// uvTexture colors each point by its texture coordinates, red for u and green for v
+type uvTexture struct{}
+
+func (uvTexture) GetTextureColor(u, v float64) colors.Color {
+	return colors.Color{R: u, G: v}
+}
+
+func TestParallelogramSpansTexture(t *testing.T) {
+	// a 4x2 parallelogram in the z=0 plane, sheared by 1 along x
+	obj := Parallelogram(
+		geometry.Pt(0, 0, 0), geometry.Pt(4, 0, 0), geometry.Pt(1, 2, 0),
+		textures.OpaqueDynamicTexture(textures.StaticTexture(uvTexture{})),
+	)
+	basics := obj.Frame(0).Flatten()
+	for _, tc := range []struct {
+		x, y float64
+		want colors.Color
+	}{
+		{x: 1, y: 0.5, want: colors.Color{R: 0.1875, G: 0.25}},
+		{x: 4.5, y: 1, want: colors.Color{R: 1, G: 0.5}},
+		// either side of the diagonal from b to c
+		{x: 2.49, y: 1, want: colors.Color{R: 0.4975, G: 0.5}},
+		{x: 2.51, y: 1, want: colors.Color{R: 0.5025, G: 0.5}},
+		{x: 4.5, y: 1.8, want: colors.Color{R: 0.9, G: 0.9}},
+	} {
+		ray := geometry.Ray{P: geometry.Pt(tc.x, tc.y, 1), D: geometry.V3(0, 0, -1)}
+		var hit *Hit
+		for _, basic := range basics {
+			if h := basic.IntersectRay(ray); h != nil {
+				hit = h
+			}
+		}
+		if hit == nil {
+			t.Errorf("no hit at (%v, %v)", tc.x, tc.y)
+			continue
+		}
+		if diff := cmp.Diff(tc.want, hit.Color, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+			t.Errorf("color at (%v, %v) mismatch (-want +got):\n%s", tc.x, tc.y, diff)
+		}
+	}
+}
+
+func TestTextureCoords(t *testing.T) {
+	tri := Tri(geometry.Pt(0, 0, 0), geometry.Pt(1, 0, 0), geometry.Pt(0, 1, 0))
+	if u, v := tri.TextureCoords(0.25, 0.5); u != 0.25 || v != 0.5 {
+		t.Errorf("TextureCoords() = (%v, %v) without UVs, want the triangle-local (0.25, 0.5)", u, v)
+	}
+	mapped := tri.WithUVs(geometry.Vector2D{X: 1, Y: 1}, geometry.Vector2D{X: 3, Y: 1}, geometry.Vector2D{X: 1, Y: 5})
+	// the texture coordinates move along with the triangle
+	moved := mapped.ApplyMatrix(geometry.TranslationMatrix(geometry.V3(2, 0, 0))).(*Triangle)
+	if u, v := moved.TextureCoords(0.25, 0.5); u != 1.5 || v != 3 {
+		t.Errorf("TextureCoords() = (%v, %v), want (1.5, 3)", u, v)
+	}
+}
@@ -0,0 +1,220 @@
+package objects
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/libeks/go-scene-renderer/geometry"
+	"github.com/libeks/go-scene-renderer/sdf"
+	"github.com/libeks/go-scene-renderer/textures"
+)
+
+const (
+	// sdfMaxSteps limits the number of steps that a ray marches through the bounds of an SDFObject
+	sdfMaxSteps = 512
+	// sdfHitDistance is how close to the surface a ray has to get to hit it, in local coordinates
+	sdfHitDistance = 1e-6
+	// sdfGradientStep is the offset at which the gradient of the distance is sampled, in local coordinates
+	sdfGradientStep = 1e-6
+	// sdfRefineSteps is the number of bisection steps that find the surface when a ray steps over it
+	sdfRefineSteps = 32
+)
+
+// SDFProjection is the way that the texture is placed on an SDFObject
+type SDFProjection int
+
+const (
+	// SDFTriplanar projects the texture along the local axis that the surface faces the most, stretched across
+	// the bounds of the shape, like onto the faces of the Box around it
+	SDFTriplanar SDFProjection = iota
+	// SDFSpherical wraps the texture around the local origin, like around a Sphere
+	SDFSpherical
+)
+
+func (p SDFProjection) String() string {
+	switch p {
+	case SDFTriplanar:
+		return "triplanar"
+	case SDFSpherical:
+		return "spherical"
+	}
+	return fmt.Sprintf("SDFProjection(%d)", int(p))
+}
+
+func DynamicSDF(s SDFObject, colorer textures.DynamicTransparentTexture) dynamicBasicObject {
+	return dynamicBasicObject{
+		BasicObject: s,
+		Colorer:     colorer,
+	}
+}
+
+// NewSDFObject returns the object of the shape, in world coordinates, textured with the projection
+func NewSDFObject(shape sdf.Shape, projection SDFProjection) SDFObject {
+	return SDFObject{Basis: UnitBasis(), Shape: shape, Projection: projection}
+}
+
+// SDFObject is the solid of a signed distance function, placed in space by its Basis, rendered by sphere
+// tracing: a ray steps along by the distance to the surface until it is close enough to hit it. Its normals
+// are the gradient of the distance. Transforms are applied to the basis, and rays are marched in the local
+// coordinates of the shape, so that they keep the distances of the shape even if they scale or shear it.
+//
+// implements Solid
+type SDFObject struct {
+	Basis
+	Shape      sdf.Shape
+	Projection SDFProjection
+}
+
+func (s SDFObject) ApplyMatrix(m geometry.HomogeneusMatrix) BasicObject {
+	return SDFObject{Basis: s.Basis.ApplyMatrix(m), Shape: s.Shape, Projection: s.Projection}
+}
+
+func (s SDFObject) GetBoundingBox(c geometry.Camera) BoundingBox {
+	bounds := s.Shape.Bounds()
+	if bounds.IsEmpty() {
+		return EmptyBB
+	}
+	return s.boundingBox(bounds.Min.Vector(), bounds.Max.Vector(), c)
+}
+
+func (s SDFObject) GetBounds() geometry.AABB {
+	bounds := s.Shape.Bounds()
+	if bounds.IsEmpty() {
+		return bounds
+	}
+	return s.bounds(bounds.Min.Vector(), bounds.Max.Vector())
+}
+
+// GetWireframe returns the edges of the bounds of the shape
+func (s SDFObject) GetWireframe(c geometry.Camera) []geometry.RasterLine {
+	bounds := s.Shape.Bounds()
+	if bounds.IsEmpty() {
+		return nil
+	}
+	return boxWireframe(s.corners(bounds.Min.Vector(), bounds.Max.Vector()), c)
+}
+
+func (s SDFObject) String() string {
+	return fmt.Sprintf("SDFObject %s, with %s projection", s.Basis, s.Projection)
+}
+
+func (s SDFObject) Contains(p geometry.Point) bool {
+	local, ok := s.localPoint(p)
+	return ok && s.Shape.Distance(geometry.Point(local)) < 0
+}
+
+// RayIntersectLocalCoords marches the ray through the bounds of the shape, and returns every crossing of its
+// surface, where it enters and exits the shape. Rays that start inside the shape first exit it.
+func (s SDFObject) RayIntersectLocalCoords(r geometry.Ray) []intersection {
+	l, ok := s.localRay(r)
+	if !ok {
+		return nil
+	}
+	speed := l.d.Mag()
+	if speed == 0 {
+		return nil
+	}
+	bounds := s.Shape.Bounds()
+	invD := geometry.V3(1/l.d.X, 1/l.d.Y, 1/l.d.Z)
+	t, tFar, ok := bounds.IntersectRay(geometry.Ray{P: geometry.Point(l.o), D: l.d}, invD, math.Inf(1))
+	if !ok {
+		return nil
+	}
+	hits := []localHit{}
+	inside := s.distance(l, t) < 0
+	prev := t
+	for range sdfMaxSteps {
+		if t > tFar {
+			break
+		}
+		d := s.distance(l, t)
+		if (d < 0) != inside {
+			// stepped over the surface, find it between the previous step and this one
+			t = s.refine(l, prev, t, inside)
+		} else if math.Abs(d) >= sdfHitDistance {
+			prev = t
+			t += math.Abs(d) / speed
+			continue
+		}
+		hits = append(hits, s.hitAt(l, t))
+		inside = !inside
+		// leave the surface before marching on, on the other side of it
+		t = s.leave(l, t, speed, inside)
+		prev = t
+	}
+	return l.intersections(r, hits)
+}
+
+// distance returns the distance to the surface of the point at t along the local ray
+func (s SDFObject) distance(l localRay, t float64) float64 {
+	return s.Shape.Distance(geometry.Point(l.at(t)))
+}
+
+// refine returns where the local ray crosses the surface between lo and hi, by bisection, where the point
+// at lo is inside if inside is set
+func (s SDFObject) refine(l localRay, lo, hi float64, inside bool) float64 {
+	for range sdfRefineSteps {
+		mid := (lo + hi) / 2
+		if (s.distance(l, mid) < 0) == inside {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return hi
+}
+
+// leave returns the first point past t at which the local ray is at least the hit distance away from the surface,
+// on the side given by inside
+func (s SDFObject) leave(l localRay, t, speed float64, inside bool) float64 {
+	step := sdfHitDistance / speed
+	for range sdfMaxSteps {
+		t += step
+		d := s.distance(l, t)
+		if (d < 0) == inside && math.Abs(d) >= sdfHitDistance {
+			break
+		}
+		step *= 2
+	}
+	return t
+}
+
+// hitAt returns the hit of the local ray at t, with the normal of the gradient of the distance
+func (s SDFObject) hitAt(l localRay, t float64) localHit {
+	p := geometry.Point(l.at(t))
+	normal := geometry.V3(
+		s.Shape.Distance(geometry.Pt(p.X+sdfGradientStep, p.Y, p.Z))-s.Shape.Distance(geometry.Pt(p.X-sdfGradientStep, p.Y, p.Z)),
+		s.Shape.Distance(geometry.Pt(p.X, p.Y+sdfGradientStep, p.Z))-s.Shape.Distance(geometry.Pt(p.X, p.Y-sdfGradientStep, p.Z)),
+		s.Shape.Distance(geometry.Pt(p.X, p.Y, p.Z+sdfGradientStep))-s.Shape.Distance(geometry.Pt(p.X, p.Y, p.Z-sdfGradientStep)),
+	)
+	b, c := s.textureCoords(p, normal)
+	return localHit{t: t, b: b, c: c, normal: normal}
+}
+
+// textureCoords returns the texture coordinates of the local point on the surface with the local normal
+func (s SDFObject) textureCoords(p geometry.Point, normal geometry.Vector3D) (float64, float64) {
+	v := p.Vector()
+	if s.Projection == SDFSpherical {
+		mag := v.Mag()
+		if mag == 0 {
+			return 0, 0
+		}
+		return longitude(v), math.Acos(max(-1, min(1, v.Y/mag))) / math.Pi
+	}
+	axis := 0
+	if math.Abs(normal.Y) > math.Abs(normal.Axis(axis)) {
+		axis = 1
+	}
+	if math.Abs(normal.Z) > math.Abs(normal.Axis(axis)) {
+		axis = 2
+	}
+	bounds := s.Shape.Bounds()
+	along := func(axis int) float64 {
+		lo, hi := bounds.Min.Axis(axis), bounds.Max.Axis(axis)
+		if hi <= lo {
+			return 0
+		}
+		return max(0, min(1, (p.Axis(axis)-lo)/(hi-lo)))
+	}
+	return along((axis + 1) % 3), along((axis + 2) % 3)
+}
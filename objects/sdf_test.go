@@ -0,0 +1,106 @@
+package objects
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"github.com/libeks/go-scene-renderer/geometry"
+	"github.com/libeks/go-scene-renderer/sdf"
+)
+
+func TestSDFObjectIntersections(t *testing.T) {
+	alongX := geometry.Ray{P: geometry.Pt(-5, 0, 0), D: geometry.V3(1, 0, 0)}
+	towardsCamera := geometry.Ray{P: geometry.Pt(0, 0, 5), D: geometry.V3(0, 0, -1)}
+	for _, tc := range []struct {
+		name       string
+		object     SDFObject
+		ray        geometry.Ray
+		withCoords bool
+		want       []surfaceHit
+	}{
+		{
+			name:       "sphere",
+			object:     NewSDFObject(sdf.Sphere(1), SDFSpherical),
+			ray:        towardsCamera,
+			withCoords: true,
+			want: []surfaceHit{
+				{T: 4, Normal: geometry.V3(0, 0, 1), B: 0, C: 0.5},
+				{T: 6, Normal: geometry.V3(0, 0, -1), B: 0.5, C: 0.5},
+			},
+		},
+		{
+			name:   "scaled and moved sphere",
+			object: NewSDFObject(sdf.Sphere(1), SDFSpherical).ApplyMatrix(geometry.MatrixProduct(geometry.TranslationMatrix(geometry.V3(0, 0, -1)), geometry.ScaleMatrix(2))).(SDFObject),
+			ray:    towardsCamera,
+			want: []surfaceHit{
+				{T: 4, Normal: geometry.V3(0, 0, 1)},
+				{T: 8, Normal: geometry.V3(0, 0, -1)},
+			},
+		},
+		{
+			name:   "from inside",
+			object: NewSDFObject(sdf.Sphere(1), SDFSpherical),
+			ray:    geometry.Ray{P: geometry.OriginPoint, D: geometry.V3(1, 0, 0)},
+			want:   []surfaceHit{{T: 1, Normal: geometry.V3(1, 0, 0)}},
+		},
+		{
+			name:       "triplanar box",
+			object:     NewSDFObject(sdf.Box(geometry.V3(1, 1, 1)), SDFTriplanar),
+			ray:        geometry.Ray{P: geometry.Pt(0.5, 0.5, 5), D: geometry.V3(0, 0, -1)},
+			withCoords: true,
+			want: []surfaceHit{
+				{T: 4, Normal: geometry.V3(0, 0, 1), B: 0.75, C: 0.75},
+				{T: 6, Normal: geometry.V3(0, 0, -1), B: 0.75, C: 0.75},
+			},
+		},
+		{
+			name: "blended spheres",
+			object: NewSDFObject(sdf.SmoothUnion(0.5,
+				sdf.Translate(sdf.Sphere(1), geometry.V3(-0.5, 0, 0)),
+				sdf.Translate(sdf.Sphere(1), geometry.V3(0.5, 0, 0)),
+			), SDFSpherical),
+			ray: alongX,
+			want: []surfaceHit{
+				{T: 3.5, Normal: geometry.V3(-1, 0, 0)},
+				{T: 6.5, Normal: geometry.V3(1, 0, 0)},
+			},
+		},
+		{
+			name: "ring",
+			object: NewSDFObject(sdf.Subtract(
+				sdf.Cylinder(1, 0.25),
+				sdf.Cylinder(0.5, 1),
+			), SDFTriplanar),
+			ray: alongX,
+			want: []surfaceHit{
+				{T: 4, Normal: geometry.V3(-1, 0, 0)},
+				{T: 4.5, Normal: geometry.V3(1, 0, 0)},
+				{T: 5.5, Normal: geometry.V3(-1, 0, 0)},
+				{T: 6, Normal: geometry.V3(1, 0, 0)},
+			},
+		},
+		{
+			name:   "through the hole of a torus",
+			object: NewSDFObject(sdf.Torus(1, 0.25), SDFSpherical),
+			ray:    geometry.Ray{P: geometry.Pt(0, 5, 0), D: geometry.V3(0, -1, 0)},
+			want:   []surfaceHit{},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := surfaceHits(tc.object.RayIntersectLocalCoords(tc.ray), tc.withCoords)
+			if diff := cmp.Diff(tc.want, got, cmpopts.EquateApprox(0, 1e-5)); diff != "" {
+				t.Errorf("RayIntersectLocalCoords() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSDFObjectBounds(t *testing.T) {
+	object := NewSDFObject(sdf.Torus(1, 0.25), SDFSpherical).ApplyMatrix(geometry.TranslationMatrix(geometry.V3(0, 1, 0)))
+	want := geometry.AABB{Min: geometry.Pt(-1.25, 0.75, -1.25), Max: geometry.Pt(1.25, 1.25, 1.25)}
+	if diff := cmp.Diff(want, object.GetBounds()); diff != "" {
+		t.Errorf("GetBounds() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -32,9 +32,6 @@ type Sphere struct {
 	Radius  float64
 	Forward geometry.Vector3D
 	Up      geometry.Vector3D
-
-	cached bool
-	bb     BoundingBox
 }
 
 func (s Sphere) ApplyMatrix(m geometry.HomogeneusMatrix) BasicObject {
@@ -57,22 +54,17 @@ func (s Sphere) ApplyMatrix(m geometry.HomogeneusMatrix) BasicObject {
 }
 
 // return the bounding box of the cube surrounding the sphere, which is an overestimate, but good enough
-func (s *Sphere) GetBoundingBox() BoundingBox {
-	if s.cached {
-		return s.bb
-	}
+func (s Sphere) GetBoundingBox(c geometry.Camera) BoundingBox {
 	xs, ys := []float64{}, []float64{}
-	rasterLines := s.GetWireframe()
+	rasterLines := s.GetWireframe(c)
 	for _, line := range rasterLines {
 		xs = append(xs, line.A.X, line.B.X)
 		ys = append(ys, line.A.Y, line.B.Y)
 	}
 	if len(xs) == 0 {
-		s.cached = true
-		s.bb = EmptyBB
-		return s.bb
+		return EmptyBB
 	}
-	bb := BoundingBox{
+	return BoundingBox{
 		TopLeft: geometry.Pixel{
 			X: max(slices.Min(xs), -1.0),
 			Y: max(slices.Min(ys), -1.0),
@@ -84,13 +76,18 @@ func (s *Sphere) GetBoundingBox() BoundingBox {
 		MinZDepth: max(0, -(s.Center.Z + s.Radius)),
 		MaxZDepth: -(s.Center.Z - s.Radius),
 	}
-	s.cached = true
-	s.bb = bb
-	return bb
+}
+
+func (s Sphere) GetBounds() geometry.AABB {
+	r := geometry.V3(s.Radius, s.Radius, s.Radius)
+	return geometry.AABB{
+		Min: geometry.Point(s.Center.Vector().AddVector(r.ScalarMultiply(-1))),
+		Max: geometry.Point(s.Center.Vector().AddVector(r)),
+	}
 }
 
 // Return the wireframe of the cube surrounding the sphere
-func (s Sphere) GetWireframe() []geometry.RasterLine {
+func (s Sphere) GetWireframe(c geometry.Camera) []geometry.RasterLine {
 	up := geometry.V3(0, 1, 0).ScalarMultiply(s.Radius)
 	left := geometry.V3(-1, 0, 0).ScalarMultiply(s.Radius)
 	away := geometry.V3(0, 0, -1).ScalarMultiply(s.Radius)
@@ -123,7 +120,7 @@ func (s Sphere) GetWireframe() []geometry.RasterLine {
 
 	ret := []geometry.RasterLine{}
 	for _, l := range sceneLines {
-		rasterLine := l.CropToScreenView()
+		rasterLine := l.CropToScreenView(c)
 		if rasterLine != nil {
 			ret = append(ret, *rasterLine)
 		}
@@ -131,6 +128,10 @@ func (s Sphere) GetWireframe() []geometry.RasterLine {
 	return ret
 }
 
+func (s Sphere) Contains(p geometry.Point) bool {
+	return p.Subtract(s.Center).Mag() < s.Radius
+}
+
 func (s Sphere) String() string {
 	return fmt.Sprintf("Sphere at %s with radius %.3f, up: %s: forward: %s", s.Center, s.Radius, s.Up, s.Forward)
 }
@@ -138,7 +139,7 @@ func (s Sphere) String() string {
 // return the intersection in triangle-local coordinates, in direction of A->B and A->C
 // bool signifies whether intersection is inside the triange
 // third float is the depth, in positive values
-func (s Sphere) RayIntersectLocalCoords(r ray) []intersection {
+func (s Sphere) RayIntersectLocalCoords(r geometry.Ray) []intersection {
 	// let w be the vector from sphere center to ray origin, it'll make the math simpler
 	w := r.P.Subtract(s.Center)
 	v := r.D
@@ -157,6 +158,7 @@ func (s Sphere) RayIntersectLocalCoords(r ray) []intersection {
 			fmt.Printf("ivect %s mag %v, radius %v\n", iVect, iVect.Mag(), s.Radius)
 			panic("Sphere vector is larger than radius")
 		}
+		normal := iVect.Unit()
 		// vertical component c
 		cComp := iVect.DotProduct(s.Up) / s.Radius
 		c := math.Acos(cComp)
@@ -168,7 +170,7 @@ func (s Sphere) RayIntersectLocalCoords(r ray) []intersection {
 		}
 		c = c / (math.Pi)     // so it's from 0 to 1
 		b = b / (2 * math.Pi) // so it's from 0 to 1
-		intersections = append(intersections, intersection{b, c, -intersectDot.Z})
+		intersections = append(intersections, intersection{b, c, -intersectDot.Z, root, normal})
 	}
 	return intersections
 }
@@ -0,0 +1,130 @@
+package objects
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/libeks/go-scene-renderer/geometry"
+	"github.com/libeks/go-scene-renderer/maths"
+	"github.com/libeks/go-scene-renderer/textures"
+)
+
+func DynamicTorus(t Torus, colorer textures.DynamicTransparentTexture) dynamicBasicObject {
+	return dynamicBasicObject{
+		BasicObject: t,
+		Colorer:     colorer,
+	}
+}
+
+// UnitTorus returns the torus around the y axis, with the center of its tube at radius 1, and the tube of
+// the minor radius
+func UnitTorus(minorRadius float64) Torus {
+	return Torus{Basis: UnitBasis(), MinorRadius: minorRadius}
+}
+
+// NewTorus returns the torus around center, facing the normal, with the radius to the center of its tube,
+// and the radius of its tube
+func NewTorus(center geometry.Point, normal geometry.Vector3D, majorRadius, minorRadius float64) Torus {
+	half := normal.Unit().ScalarMultiply(majorRadius)
+	return Torus{
+		Basis:       axialBasis(geometry.Point(center.Vector().AddVector(half.ScalarMultiply(-1))), geometry.Point(center.Vector().AddVector(half)), majorRadius),
+		MinorRadius: minorRadius / majorRadius,
+	}
+}
+
+// Torus is a solid ring around the up axis of its basis. The center of its tube is at radius 1 in the
+// horizontal plane, and the tube has the radius MinorRadius, in the local coordinates. b is the longitude
+// around the up axis, like for a Sphere, and c is the angle around the tube, starting at the outer rim,
+// and increasing over the top.
+//
+// implements Solid
+type Torus struct {
+	Basis
+	MinorRadius float64
+}
+
+func (t Torus) ApplyMatrix(m geometry.HomogeneusMatrix) BasicObject {
+	return Torus{Basis: t.Basis.ApplyMatrix(m), MinorRadius: t.MinorRadius}
+}
+
+// localExtent returns the corners of the local box around the torus
+func (t Torus) localExtent() (geometry.Vector3D, geometry.Vector3D) {
+	r := 1 + t.MinorRadius
+	return geometry.V3(-r, -t.MinorRadius, -r), geometry.V3(r, t.MinorRadius, r)
+}
+
+func (t Torus) GetBoundingBox(c geometry.Camera) BoundingBox {
+	lo, hi := t.localExtent()
+	return t.boundingBox(lo, hi, c)
+}
+
+func (t Torus) GetBounds() geometry.AABB {
+	return t.bounds(t.localExtent())
+}
+
+// GetWireframe returns the inner and outer rims, and the circles along the top and bottom of the tube
+func (t Torus) GetWireframe(c geometry.Camera) []geometry.RasterLine {
+	lines := append(t.circle(0, 1-t.MinorRadius), t.circle(0, 1+t.MinorRadius)...)
+	lines = append(lines, t.circle(-t.MinorRadius, 1)...)
+	lines = append(lines, t.circle(t.MinorRadius, 1)...)
+	return cropLines(lines, c)
+}
+
+func (t Torus) String() string {
+	return fmt.Sprintf("Torus %s, minor radius %.3f", t.Basis, t.MinorRadius)
+}
+
+func (t Torus) Contains(p geometry.Point) bool {
+	local, ok := t.localPoint(p)
+	if !ok {
+		return false
+	}
+	fromTube := math.Hypot(local.X, local.Z) - 1
+	return fromTube*fromTube+local.Y*local.Y < t.MinorRadius*t.MinorRadius
+}
+
+// RayIntersectLocalCoords solves the quartic (|p|^2 + 1 - r^2)^2 = 4(x^2 + z^2) along the ray. To keep the
+// coefficients well-conditioned, the ray is first moved up to the sphere around the torus and its direction
+// normalized.
+func (t Torus) RayIntersectLocalCoords(r geometry.Ray) []intersection {
+	l, ok := t.localRay(r)
+	if !ok {
+		return nil
+	}
+	scale := l.d.Mag()
+	if scale == 0 {
+		return nil
+	}
+	d := l.d.ScalarMultiply(1 / scale)
+	bound := 1 + t.MinorRadius
+	sphereRoots := maths.QuadraticRoots(1, 2*l.o.DotProduct(d), l.o.DotProduct(l.o)-bound*bound)
+	if len(sphereRoots) < 2 || sphereRoots[1] <= 0 {
+		return nil
+	}
+	start := max(0, sphereRoots[0])
+	o := l.o.AddVector(d.ScalarMultiply(start))
+
+	od := o.DotProduct(d)
+	k := o.DotProduct(o) + 1 - t.MinorRadius*t.MinorRadius
+	roots := maths.QuarticRoots(
+		1,
+		4*od,
+		2*k+4*od*od-4*(d.X*d.X+d.Z*d.Z),
+		4*od*k-8*(o.X*d.X+o.Z*d.Z),
+		k*k-4*(o.X*o.X+o.Z*o.Z),
+	)
+	hits := []localHit{}
+	for _, root := range roots {
+		s := (start + root) / scale
+		p := l.at(s)
+		rho := math.Hypot(p.X, p.Z)
+		c := math.Atan2(p.Y, rho-1) / (2 * math.Pi)
+		if c < 0 {
+			c += 1
+		}
+		// the gradient of the quartic, divided by 4
+		normal := p.ScalarMultiply(p.DotProduct(p) + 1 - t.MinorRadius*t.MinorRadius).AddVector(geometry.V3(-2*p.X, 0, -2*p.Z))
+		hits = append(hits, localHit{t: s, b: longitude(p), c: c, normal: normal})
+	}
+	return l.intersections(r, hits)
+}
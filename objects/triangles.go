@@ -12,21 +12,30 @@ import (
 
 func GradientTriangle(a, b, c geometry.Point, colorA, colorB, colorC colors.Color) dynamicBasicObject {
 	return DynamicBasicObject(
-		&Triangle{
-			A: a,
-			B: b,
-			C: c,
-		},
+		Tri(a, b, c),
 		textures.OpaqueDynamicTexture(textures.StaticTexture(textures.TriangleGradientTexture(colorA, colorB, colorC))),
 	)
 }
 
 func Tri(a, b, c geometry.Point) *Triangle {
-	return &Triangle{
+	return (&Triangle{
 		A: a,
 		B: b,
 		C: c,
-	}
+	}).withGeometry()
+}
+
+// SmoothTri returns a triangle whose normal is interpolated between the unit normals at its corners,
+// so that a mesh of them is shaded as a smooth surface
+func SmoothTri(a, b, c geometry.Point, normalA, normalB, normalC geometry.Vector3D) *Triangle {
+	return (&Triangle{
+		A:       a,
+		B:       b,
+		C:       c,
+		NormalA: normalA,
+		NormalB: normalB,
+		NormalC: normalC,
+	}).withGeometry()
 }
 
 // A Triangle describes an uncolored object in the space
@@ -35,16 +44,48 @@ type Triangle struct {
 	B geometry.Point
 	C geometry.Point
 
-	// the below are cached values for efficiency. They are created at the top of rayIntersectLocalCoords
-	cached      bool
+	// optional unit normals at A, B and C, interpolated across the triangle. If unset, the triangle
+	// is flat, with the normal of its plane
+	NormalA geometry.Vector3D
+	NormalB geometry.Vector3D
+	NormalC geometry.Vector3D
+
+	// optional texture coordinates at A, B and C, see WithUVs
+	uvs *[3]geometry.Vector2D
+
+	// values derived from the corners, computed once by the constructors rather than on first use,
+	// since a triangle is intersected from several goroutines at once. Nil for a Triangle literal
+	geom *triangleGeometry
+}
+
+// triangleGeometry holds the vectors AB and AC, as well as the plane of a triangle, which makes
+// intersections 37% more efficient
+type triangleGeometry struct {
 	plane       plane
 	bVect       geometry.Vector3D
 	cVect       geometry.Vector3D
-	normal      geometry.Vector3D
 	normalMagSq float64
+	unitNormal  geometry.Vector3D
+}
 
-	cachedBoundingBox bool
-	bbox              BoundingBox
+func (t Triangle) computeGeometry() triangleGeometry {
+	bVect := t.B.Subtract(t.A)
+	cVect := t.C.Subtract(t.A)
+	normal := bVect.CrossProduct(cVect)
+	return triangleGeometry{
+		plane:       plane{normal, t.A.Vector().DotProduct(normal)},
+		bVect:       bVect,
+		cVect:       cVect,
+		normalMagSq: normal.Mag() * normal.Mag(),
+		unitNormal:  normal.Unit(),
+	}
+}
+
+// withGeometry computes the values derived from the corners of a newly created triangle
+func (t *Triangle) withGeometry() *Triangle {
+	geom := t.computeGeometry()
+	t.geom = &geom
+	return t
 }
 
 func (t Triangle) ApplyMatrix(m geometry.HomogeneusMatrix) BasicObject {
@@ -60,27 +101,71 @@ func (t Triangle) ApplyMatrix(m geometry.HomogeneusMatrix) BasicObject {
 	if !ok {
 		panic(fmt.Errorf("could not apply matrix %s to point %s", m, t.C))
 	}
-	return &Triangle{
+	ret := &Triangle{
 		A: a, B: b, C: c,
+		uvs: t.uvs,
+	}
+	if !t.isSmooth() {
+		return ret.withGeometry()
+	}
+	// normals are transformed by the inverse transpose, which keeps them perpendicular to the surface
+	normalMatrix := m.Slice3DMatrix()
+	if inverse, ok := normalMatrix.Inverse(); ok {
+		normalMatrix = inverse.Transpose()
 	}
+	ret.NormalA = normalMatrix.MultVect(t.NormalA).Unit()
+	ret.NormalB = normalMatrix.MultVect(t.NormalB).Unit()
+	ret.NormalC = normalMatrix.MultVect(t.NormalC).Unit()
+	return ret.withGeometry()
+}
+
+// WithUVs returns a copy of the triangle with texture coordinates at its corners. They are interpolated
+// across the triangle, and its texture is sampled at them instead of at the triangle-local (b,c),
+// so that one texture can span many triangles.
+func (t Triangle) WithUVs(uvA, uvB, uvC geometry.Vector2D) *Triangle {
+	return (&Triangle{
+		A: t.A, B: t.B, C: t.C,
+		NormalA: t.NormalA, NormalB: t.NormalB, NormalC: t.NormalC,
+		uvs: &[3]geometry.Vector2D{uvA, uvB, uvC},
+	}).withGeometry()
+}
+
+// TextureCoords returns the texture coordinates at the triangle-local coordinates (b,c), which are
+// (b,c) themselves unless the triangle has texture coordinates at its corners
+func (t Triangle) TextureCoords(b, c float64) (float64, float64) {
+	if t.uvs == nil {
+		return b, c
+	}
+	uv := t.uvs[0].ScalarMultiply(1 - b - c).AddVector(t.uvs[1].ScalarMultiply(b)).AddVector(t.uvs[2].ScalarMultiply(c))
+	return uv.X, uv.Y
+}
+
+func (t Triangle) isSmooth() bool {
+	return t.NormalA != geometry.NilVector3D
+}
+
+// NormalAt returns the unit normal at the triangle-local coordinates (b,c), interpolated between
+// the corner normals of a smooth triangle
+func (t Triangle) NormalAt(b, c float64) geometry.Vector3D {
+	if !t.isSmooth() {
+		return t.B.Subtract(t.A).CrossProduct(t.C.Subtract(t.A)).Unit()
+	}
+	return t.NormalA.ScalarMultiply(1 - b - c).AddVector(t.NormalB.ScalarMultiply(b)).AddVector(t.NormalC.ScalarMultiply(c)).Unit()
 }
 
 func (t Triangle) Flatten() []*Triangle {
 	return []*Triangle{&t}
 }
 
-func (t *Triangle) GetBoundingBox() BoundingBox {
-	if t.cachedBoundingBox {
-		return t.bbox
-	}
-	wireframe := t.getSceneWireframe()
+func (t Triangle) GetBoundingBox(c geometry.Camera) BoundingBox {
+	wireframe := t.getSceneWireframe(c)
 	points := make([]geometry.Pixel, 0, 6)
 	pointsX := make([]float64, 0, 6)
 	pointsY := make([]float64, 0, 6)
 	zdepths := make([]float64, 0, 6)
 	for _, line := range wireframe {
-		pointA, zdepthA := line.A.ToPixel()
-		pointB, zdepthB := line.B.ToPixel()
+		pointA, zdepthA := c.ToPixel(line.A)
+		pointB, zdepthB := c.ToPixel(line.B)
 		if pointA == nil || pointB == nil {
 			panic(fmt.Errorf("line should already be in front of camera %s", line))
 		}
@@ -99,7 +184,7 @@ func (t *Triangle) GetBoundingBox() BoundingBox {
 		}
 	}
 
-	bb := BoundingBox{
+	return BoundingBox{
 		TopLeft: geometry.Pixel{
 			X: max(slices.Min(pointsX), -1.0),
 			Y: max(slices.Min(pointsY), -1.0),
@@ -111,16 +196,17 @@ func (t *Triangle) GetBoundingBox() BoundingBox {
 		MinZDepth: max(0, slices.Min(zdepths)),
 		MaxZDepth: min(math.MaxFloat64, slices.Max(zdepths)),
 	}
-	t.bbox = bb
-	t.cachedBoundingBox = true
-	return bb
+}
+
+func (t Triangle) GetBounds() geometry.AABB {
+	return geometry.AABBFromPoints(t.A, t.B, t.C)
 }
 
 // return all the lines that describe the triangle, without any fill, used to generate wireframe images
 // note that the resulting lines may not exactly match the triangle, as they are cropped to what is
 // in front of the camera
-func (t Triangle) getSceneWireframe() []geometry.Line {
-	minDepth := -0.01 // minimum z-coordinate to keep on screen
+func (t Triangle) getSceneWireframe(c geometry.Camera) []geometry.Line {
+	minDepth := -c.Near // maximum z-coordinate to keep on screen
 	lineAB := geometry.Line{A: t.A, B: t.B}.CropToFrontOfCamera(minDepth)
 	lineAC := geometry.Line{A: t.A, B: t.C}.CropToFrontOfCamera(minDepth)
 	lineBC := geometry.Line{A: t.B, B: t.C}.CropToFrontOfCamera(minDepth)
@@ -169,11 +255,11 @@ func (t Triangle) getSceneWireframe() []geometry.Line {
 	panic(fmt.Errorf("two lines are missing, not sure what to do \n%s \n%s \n%s \n%v \n%v \n%v", t.A, t.B, t.C, lineAB, lineAC, lineBC))
 }
 
-func (t Triangle) GetWireframe() []geometry.RasterLine {
-	sceneLines := t.getSceneWireframe()
+func (t Triangle) GetWireframe(c geometry.Camera) []geometry.RasterLine {
+	sceneLines := t.getSceneWireframe(c)
 	ret := []geometry.RasterLine{}
 	for _, l := range sceneLines {
-		rasterLine := l.CropToScreenView()
+		rasterLine := l.CropToScreenView(c)
 		if rasterLine != nil {
 			ret = append(ret, *rasterLine)
 		}
@@ -185,35 +271,17 @@ func (t Triangle) String() string {
 	return fmt.Sprintf("Triangle %s %s %s", t.A, t.B, t.C)
 }
 
-func (t Triangle) getPlane() plane {
-	bVector := t.bVect
-	cVector := t.cVect
-	nVector := bVector.CrossProduct(cVector)
-	return plane{nVector, t.A.Vector().DotProduct(nVector)}
-}
-
-func (t Triangle) getBVect() geometry.Vector3D {
-	return t.B.Subtract(t.A)
-}
-
-func (t Triangle) getCVect() geometry.Vector3D {
-	return t.C.Subtract(t.A)
-}
-
 // return the intersection in triangle-local coordinates, in direction of A->B and A->C
 // bool signifies whether intersection is inside the triange
 // third float is the z-depth, in positive values
-func (t *Triangle) RayIntersectLocalCoords(r ray) []intersection {
-	// cache the vectors AB and AC, as well as the plane, this is 37% more efficient
-	if !t.cached {
-		t.bVect = t.getBVect()
-		t.cVect = t.getCVect()
-		t.plane = t.getPlane()
-		t.normal = t.plane.N
-		t.normalMagSq = t.normal.Mag() * t.normal.Mag()
-		t.cached = true
-	}
-	intersectDot, doesIntersect := t.plane.IntersectPoint(r)
+func (t *Triangle) RayIntersectLocalCoords(r geometry.Ray) []intersection {
+	geom := t.geom
+	if geom == nil {
+		// a Triangle literal, which was not created by a constructor
+		computed := t.computeGeometry()
+		geom = &computed
+	}
+	intersectDot, rayT, doesIntersect := geom.plane.IntersectPoint(r)
 	if !doesIntersect {
 		return nil
 		// return 0, 0, 0, false
@@ -222,10 +290,10 @@ func (t *Triangle) RayIntersectLocalCoords(r ray) []intersection {
 	// iMag := geometry.OriginPoint.Subtract(intersectDot).Mag()
 	zDepth := -intersectDot.Z
 
-	bVect := t.bVect
-	cVect := t.cVect
-	normal := t.normal
-	normalMagSq := t.normalMagSq
+	bVect := geom.bVect
+	cVect := geom.cVect
+	normal := geom.plane.N
+	normalMagSq := geom.normalMagSq
 	b := iVect.CrossProduct(cVect).DotProduct(normal) / normalMagSq
 	c := bVect.CrossProduct(iVect).DotProduct(normal) / normalMagSq
 	// check if vector (b,c) is inside the triangle [(0,0), (1,0), (0,1)]
@@ -240,6 +308,11 @@ func (t *Triangle) RayIntersectLocalCoords(r ray) []intersection {
 		// return b, c, zDepth, false
 	}
 	// inside unit square and inside the hypotenuse
-	return []intersection{{b, c, zDepth}}
+	unitNormal := geom.unitNormal
+	if t.isSmooth() {
+		unitNormal = t.NormalAt(b, c)
+	}
+	u, v := t.TextureCoords(b, c)
+	return []intersection{{u, v, zDepth, rayT, unitNormal}}
 	// return b, c, zDepth, true
 }
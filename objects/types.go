@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/libeks/go-scene-renderer/geometry"
+	"github.com/libeks/go-scene-renderer/textures"
 )
 
 var (
@@ -21,9 +22,17 @@ type DynamicObjectInt interface {
 type BasicObject interface {
 	// GetColorDepth(x, y float64) (*colors.Color, float64)
 	ApplyMatrix(m geometry.HomogeneusMatrix) BasicObject
-	GetBoundingBox() BoundingBox
-	GetWireframe() []geometry.RasterLine
-	RayIntersectLocalCoords(ray) []intersection
+	GetBoundingBox(c geometry.Camera) BoundingBox // box containing the object on screen, as seen by the camera
+	GetBounds() geometry.AABB                     // axis-aligned box containing the whole object, in 3D space
+	GetWireframe(c geometry.Camera) []geometry.RasterLine
+	RayIntersectLocalCoords(geometry.Ray) []intersection
+}
+
+// Solid is a BasicObject that encloses a volume, so that it can be combined with others by CSG.
+// The normals of its intersections point out of the volume.
+type Solid interface {
+	BasicObject
+	Contains(p geometry.Point) bool // true if the point is inside the volume
 }
 
 type StaticObject struct {
@@ -123,6 +132,36 @@ func (ob DynamicObject) WithDynamicTransform(f func(float64) geometry.Homogeneus
 	}
 }
 
+// WithMaterial returns a copy of the object, with the material applied to all of its constituent parts
+func (ob DynamicObject) WithMaterial(m textures.Material) DynamicObject {
+	newTriangles := make([]objWithTransform, 0, len(ob.objs))
+	for _, tri := range ob.objs {
+		newTriangles = append(newTriangles, objWithTransform{
+			obj: materialObject{tri.obj, m},
+			fn:  tri.fn,
+		})
+	}
+	return DynamicObject{
+		objs: newTriangles,
+	}
+}
+
+// materialObject overrides the material of every StaticBasicObject in obj
+type materialObject struct {
+	obj      DynamicObjectInt
+	material textures.Material
+}
+
+func (o materialObject) Frame(t float64) StaticObject {
+	basics := o.obj.Frame(t).basics
+	withMaterial := make([]StaticBasicObject, len(basics))
+	for i, basic := range basics {
+		basic.Material = o.material
+		withMaterial[i] = basic
+	}
+	return StaticObject{withMaterial}
+}
+
 type BoundingBox struct {
 	TopLeft     geometry.Pixel
 	BottomRight geometry.Pixel
@@ -139,15 +178,6 @@ func (bb BoundingBox) String() string {
 	return fmt.Sprintf("BB(%s %s zmin:%.3f zmax:%.3f)", bb.TopLeft, bb.BottomRight, bb.MinZDepth, bb.MaxZDepth)
 }
 
-type ray struct {
-	P geometry.Point    // origin point
-	D geometry.Vector3D // direction vector describing the ray
-}
-
-func (r ray) PointAt(t float64) geometry.Point {
-	return geometry.Point(r.P.Vector().AddVector(r.D.ScalarMultiply(t)))
-}
-
 type plane struct {
 	N geometry.Vector3D // normal vector
 	D float64           // d parameter, describing plane
@@ -157,21 +187,24 @@ func (p plane) String() string {
 	return fmt.Sprintf("Plane(->%s, at %f)", p.N, p.D)
 }
 
-func (p plane) IntersectPoint(r ray) (geometry.Point, bool) {
+// returns the intersection point, along with the ray parameter at which it occurs
+func (p plane) IntersectPoint(r geometry.Ray) (geometry.Point, float64, bool) {
 	denominator := p.N.DotProduct(r.D)
 	if denominator == 0.0 {
-		return geometry.Point{}, false // ray is parallel to plane, no intersection
+		return geometry.Point{}, 0, false // ray is parallel to plane, no intersection
 	}
 	t := (p.D - p.N.DotProduct(r.P.Vector())) / denominator
 	if t < 0.0 {
-		return geometry.Point{}, false // ray intersects plane before ray's starting point
+		return geometry.Point{}, 0, false // ray intersects plane before ray's starting point
 	}
 	point := r.PointAt(t)
-	return point, true
+	return point, t, true
 }
 
 type intersection struct {
 	b      float64
 	c      float64
 	zDepth float64
+	t      float64           // ray parameter of the intersection
+	normal geometry.Vector3D // unit vector perpendicular to the surface at the intersection, pointing outward
 }
@@ -0,0 +1,174 @@
+package renderer
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"math"
+	"math/rand"
+	"os"
+	"time"
+
+	"github.com/libeks/go-scene-renderer/scenes"
+	"github.com/muesli/clusters"
+	"github.com/muesli/kmeans"
+)
+
+const (
+	maxPaletteColors = 256  // the most colors a GIF frame can have
+	paletteSamples   = 4096 // number of pixels clustered into a palette, when there are more colors than fit
+)
+
+// RenderGIF renders the scene into an animated GIF, without needing ffmpeg. Frames are quantized to a palette
+// shared by all frames, or one per frame, found by k-means clustering of their pixels.
+func RenderGIF(scene scenes.DynamicScene, vp VideoPreset, outFile string, wireframe bool, triDepth bool) error {
+	start := time.Now()
+	frames, err := renderAllFrames(scene, vp, wireframe, triDepth)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Quantizing frames...\n")
+	var palette color.Palette
+	if !vp.perFramePalette {
+		images := make([]image.Image, len(frames))
+		for i, frame := range frames {
+			images[i] = frame.GetImage()
+		}
+		if palette, err = kmeansPalette(images); err != nil {
+			return err
+		}
+	}
+	anim := &gif.GIF{
+		LoopCount: gifLoopCount(vp.loopCount),
+	}
+	for _, frame := range frames {
+		framePalette := palette
+		if vp.perFramePalette {
+			if framePalette, err = kmeansPalette([]image.Image{frame.GetImage()}); err != nil {
+				return err
+			}
+		}
+		anim.Image = append(anim.Image, toPaletted(frame.GetImage(), framePalette, vp.dither))
+		anim.Delay = append(anim.Delay, vp.frameDelay(100))
+	}
+	f, err := os.Create(outFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := gif.EncodeAll(f, anim); err != nil {
+		return err
+	}
+	fmt.Printf("GIF generation took %s\n", time.Since(start))
+	return nil
+}
+
+// RenderAPNG renders the scene into an animated PNG, without needing ffmpeg. Unlike GIFs, frames keep all their colors.
+func RenderAPNG(scene scenes.DynamicScene, vp VideoPreset, outFile string, wireframe bool, triDepth bool) error {
+	start := time.Now()
+	frames, err := renderAllFrames(scene, vp, wireframe, triDepth)
+	if err != nil {
+		return err
+	}
+	images := make([]image.Image, len(frames))
+	for i, frame := range frames {
+		images[i] = frame.GetImage()
+	}
+	f, err := os.Create(outFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := encodeAPNG(f, images, vp.frameDelay(apngDelayDenominator), vp.loopCount); err != nil {
+		return err
+	}
+	fmt.Printf("APNG generation took %s\n", time.Since(start))
+	return nil
+}
+
+// renderAllFrames renders the frames of the video, keeping all of them in memory
+func renderAllFrames(scene scenes.DynamicScene, vp VideoPreset, wireframe bool, triDepth bool) ([]*Image, error) {
+	frames := make([]*Image, vp.nFrameCount)
+	r := newRenderer(vp.ImagePreset)
+	err := r.renderFrames(scene, vp, vp.allFrames(), wireframe, triDepth, func(i int, frame *Image) error {
+		frames[i] = frame
+		return nil
+	}, nil)
+	return frames, err
+}
+
+// frameDelay returns the time between frames, in units of 1/denominator seconds
+func (vp VideoPreset) frameDelay(denominator int) int {
+	return max(1, int(math.Round(float64(denominator)/vp.frameRate)))
+}
+
+// gifLoopCount converts the number of times an animation is played into GIF's count of repetitions,
+// where 0 loops forever, and -1 plays once
+func gifLoopCount(loopCount int) int {
+	switch {
+	case loopCount <= 0:
+		return 0
+	case loopCount == 1:
+		return -1
+	default:
+		return loopCount - 1
+	}
+}
+
+// kmeansPalette returns a palette with the colors of the images, if there are few enough of them.
+// Otherwise, the palette colors are the centers of the clusters found by k-means over a random sample of pixels.
+func kmeansPalette(images []image.Image) (color.Palette, error) {
+	distinct := map[color.RGBA]bool{}
+	for _, img := range images {
+		bounds := img.Bounds()
+		for y := bounds.Min.Y; y < bounds.Max.Y && len(distinct) <= maxPaletteColors; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X && len(distinct) <= maxPaletteColors; x++ {
+				distinct[color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)] = true
+			}
+		}
+	}
+	if len(distinct) <= maxPaletteColors {
+		palette := make(color.Palette, 0, len(distinct))
+		for c := range distinct {
+			palette = append(palette, c)
+		}
+		return palette, nil
+	}
+
+	observations := make(clusters.Observations, paletteSamples)
+	for i := range observations {
+		img := images[rand.Intn(len(images))]
+		bounds := img.Bounds()
+		r, g, b, _ := img.At(bounds.Min.X+rand.Intn(bounds.Dx()), bounds.Min.Y+rand.Intn(bounds.Dy())).RGBA()
+		observations[i] = clusters.Coordinates{float64(r >> 8), float64(g >> 8), float64(b >> 8)}
+	}
+	clustered, err := kmeans.New().Partition(observations, maxPaletteColors)
+	if err != nil {
+		return nil, err
+	}
+	palette := make(color.Palette, 0, len(clustered))
+	for _, cluster := range clustered {
+		palette = append(palette, color.RGBA{
+			uint8(math.Round(cluster.Center[0])),
+			uint8(math.Round(cluster.Center[1])),
+			uint8(math.Round(cluster.Center[2])),
+			0xff,
+		})
+	}
+	return palette, nil
+}
+
+// toPaletted maps each pixel of the image to the closest color of the palette,
+// spreading the error to neighboring pixels if dither is set
+func toPaletted(img image.Image, palette color.Palette, dither bool) *image.Paletted {
+	bounds := img.Bounds()
+	paletted := image.NewPaletted(bounds, palette)
+	if dither {
+		draw.FloydSteinberg.Draw(paletted, bounds, img, bounds.Min)
+	} else {
+		draw.Draw(paletted, bounds, img, bounds.Min, draw.Src)
+	}
+	return paletted
+}
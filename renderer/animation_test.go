@@ -0,0 +1,61 @@
+package renderer
+
+import (
+	"image"
+	"testing"
+
+	"github.com/libeks/go-scene-renderer/colors"
+)
+
+func TestKmeansPalette(t *testing.T) {
+	ip := ImagePreset{width: 32, height: 32}
+	few := NewImage(ip)
+	few.Fill(colors.Red)
+	few.Set(0, 0, colors.Blue)
+	gradient := NewImage(ip)
+	for x := range ip.width {
+		for y := range ip.height {
+			gradient.Set(x, y, colors.Color{R: float64(x) / float64(ip.width), G: float64(y) / float64(ip.height), B: 0.5})
+		}
+	}
+
+	tests := []struct {
+		name       string
+		images     []image.Image
+		wantColors int
+	}{
+		{"few colors are kept", []image.Image{few.GetImage()}, 2},
+		{"colors of all frames are kept", []image.Image{few.GetImage(), NewImage(ip).GetImage()}, 3},
+		{"many colors are clustered", []image.Image{gradient.GetImage()}, maxPaletteColors},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			palette, err := kmeansPalette(tt.images)
+			if err != nil {
+				t.Fatalf("kmeansPalette: %s", err)
+			}
+			if len(palette) != tt.wantColors {
+				t.Errorf("palette has %d colors, want %d", len(palette), tt.wantColors)
+			}
+			// every pixel should be reasonably close to its palette color
+			for _, img := range tt.images {
+				paletted := toPaletted(img, palette, false)
+				for y := range ip.height {
+					for x := range ip.width {
+						if !colorsClose(img.At(x, y), paletted.At(x, y), 32) {
+							t.Fatalf("pixel (%d, %d) is %v in the palette, want %v", x, y, paletted.At(x, y), img.At(x, y))
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestGIFLoopCount(t *testing.T) {
+	for loopCount, want := range map[int]int{0: 0, 1: -1, 3: 2} {
+		if got := gifLoopCount(loopCount); got != want {
+			t.Errorf("gifLoopCount(%d) = %d, want %d", loopCount, got, want)
+		}
+	}
+}
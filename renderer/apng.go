@@ -0,0 +1,121 @@
+package renderer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"io"
+)
+
+const (
+	apngDelayDenominator = 1000 // frame delays are in milliseconds
+)
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// pngChunk is a chunk of a PNG file, without its length and checksum
+type pngChunk struct {
+	kind string
+	data []byte
+}
+
+// encodeAPNG writes the images as an animated PNG, each shown for delay milliseconds, played loopCount times,
+// or forever if loopCount is 0. Each frame is encoded with image/png, and its image data is repackaged
+// into APNG frame chunks, so all images need to have the same size and color model.
+func encodeAPNG(w io.Writer, images []image.Image, delay int, loopCount int) error {
+	if len(images) == 0 {
+		return fmt.Errorf("an animated PNG needs at least one frame")
+	}
+	if _, err := w.Write(pngSignature); err != nil {
+		return err
+	}
+	sequence := uint32(0) // frame control and frame data chunks share a sequence number
+	for i, img := range images {
+		chunks, err := encodePNGChunks(img)
+		if err != nil {
+			return err
+		}
+		if i == 0 {
+			// the first frame's header describes the whole animation
+			for _, chunk := range chunks {
+				if chunk.kind != "IHDR" {
+					continue
+				}
+				if err := writePNGChunk(w, chunk); err != nil {
+					return err
+				}
+			}
+			actl := make([]byte, 8)
+			binary.BigEndian.PutUint32(actl[0:], uint32(len(images)))
+			binary.BigEndian.PutUint32(actl[4:], uint32(loopCount))
+			if err := writePNGChunk(w, pngChunk{"acTL", actl}); err != nil {
+				return err
+			}
+		}
+
+		bounds := img.Bounds()
+		fctl := make([]byte, 26)
+		binary.BigEndian.PutUint32(fctl[0:], sequence)
+		binary.BigEndian.PutUint32(fctl[4:], uint32(bounds.Dx()))
+		binary.BigEndian.PutUint32(fctl[8:], uint32(bounds.Dy()))
+		// frames are placed at (0,0), followed by the delay, and no disposal or blending (both 0)
+		binary.BigEndian.PutUint16(fctl[20:], uint16(delay))
+		binary.BigEndian.PutUint16(fctl[22:], apngDelayDenominator)
+		sequence += 1
+		if err := writePNGChunk(w, pngChunk{"fcTL", fctl}); err != nil {
+			return err
+		}
+
+		for _, chunk := range chunks {
+			if chunk.kind != "IDAT" {
+				continue
+			}
+			if i > 0 {
+				// later frames store their image data in fdAT chunks, prefixed by the sequence number
+				data := make([]byte, 4, 4+len(chunk.data))
+				binary.BigEndian.PutUint32(data, sequence)
+				chunk = pngChunk{"fdAT", append(data, chunk.data...)}
+				sequence += 1
+			}
+			if err := writePNGChunk(w, chunk); err != nil {
+				return err
+			}
+		}
+	}
+	return writePNGChunk(w, pngChunk{"IEND", nil})
+}
+
+// encodePNGChunks encodes the image as a PNG, and splits it into its chunks
+func encodePNGChunks(img image.Image) ([]pngChunk, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	data := buf.Bytes()[len(pngSignature):]
+	chunks := []pngChunk{}
+	for len(data) >= 12 {
+		length := binary.BigEndian.Uint32(data)
+		chunks = append(chunks, pngChunk{string(data[4:8]), data[8 : 8+length]})
+		data = data[12+length:]
+	}
+	return chunks, nil
+}
+
+func writePNGChunk(w io.Writer, chunk pngChunk) error {
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header, uint32(len(chunk.data)))
+	copy(header[4:], chunk.kind)
+	crc := crc32.NewIEEE()
+	crc.Write(header[4:])
+	crc.Write(chunk.data)
+	footer := binary.BigEndian.AppendUint32(nil, crc.Sum32())
+	for _, b := range [][]byte{header, chunk.data, footer} {
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
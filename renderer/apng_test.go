@@ -0,0 +1,91 @@
+package renderer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/libeks/go-scene-renderer/colors"
+)
+
+func TestEncodeAPNG(t *testing.T) {
+	ip := ImagePreset{width: 4, height: 3}
+	fills := []colors.Color{colors.Red, colors.Green, colors.Blue}
+	images := make([]image.Image, len(fills))
+	for i, c := range fills {
+		frame := NewImage(ip)
+		frame.Fill(c)
+		images[i] = frame.GetImage()
+	}
+	var buf bytes.Buffer
+	if err := encodeAPNG(&buf, images, 40, 2); err != nil {
+		t.Fatalf("encodeAPNG: %s", err)
+	}
+
+	// decoders without APNG support show the first frame
+	first, err := png.Decode(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("decoding as a PNG: %s", err)
+	}
+	if got, want := first.At(0, 0), images[0].At(0, 0); !colorsClose(got, want, 0) {
+		t.Errorf("first frame is %v, want %v", got, want)
+	}
+
+	data := buf.Bytes()[len(pngSignature):]
+	var kinds []string
+	var header []byte
+	var frames [][]byte // image data of each frame
+	var sequence []uint32
+	for len(data) > 0 {
+		length := binary.BigEndian.Uint32(data)
+		chunk := pngChunk{string(data[4:8]), data[8 : 8+length]}
+		data = data[12+length:]
+		kinds = append(kinds, chunk.kind)
+		switch chunk.kind {
+		case "IHDR":
+			header = chunk.data
+		case "acTL":
+			if frames, plays := binary.BigEndian.Uint32(chunk.data), binary.BigEndian.Uint32(chunk.data[4:]); frames != 3 || plays != 2 {
+				t.Errorf("acTL has %d frames and %d plays, want 3 and 2", frames, plays)
+			}
+		case "fcTL":
+			sequence = append(sequence, binary.BigEndian.Uint32(chunk.data))
+			if delay := binary.BigEndian.Uint16(chunk.data[20:]); delay != 40 {
+				t.Errorf("frame delay is %d, want 40", delay)
+			}
+		case "IDAT":
+			frames = append(frames, chunk.data)
+		case "fdAT":
+			sequence = append(sequence, binary.BigEndian.Uint32(chunk.data))
+			frames = append(frames, chunk.data[4:])
+		}
+	}
+	wantKinds := []string{"IHDR", "acTL", "fcTL", "IDAT", "fcTL", "fdAT", "fcTL", "fdAT", "IEND"}
+	if diff := cmp.Diff(wantKinds, kinds); diff != "" {
+		t.Errorf("chunks differ (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]uint32{0, 1, 2, 3, 4}, sequence); diff != "" {
+		t.Errorf("sequence numbers differ (-want +got):\n%s", diff)
+	}
+
+	// each frame's image data, along with the header, is a PNG of its own
+	for i, frameData := range frames {
+		var frame bytes.Buffer
+		frame.Write(pngSignature)
+		for _, chunk := range []pngChunk{{"IHDR", header}, {"IDAT", frameData}, {"IEND", nil}} {
+			if err := writePNGChunk(&frame, chunk); err != nil {
+				t.Fatal(err)
+			}
+		}
+		decoded, err := png.Decode(&frame)
+		if err != nil {
+			t.Fatalf("decoding frame %d: %s", i, err)
+		}
+		if got, want := decoded.At(1, 1), images[i].At(1, 1); !colorsClose(got, want, 0) {
+			t.Errorf("frame %d is %v, want %v", i, got, want)
+		}
+	}
+}
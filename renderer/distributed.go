@@ -0,0 +1,455 @@
+package renderer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/libeks/go-scene-renderer/scenes"
+)
+
+const (
+	leaseTimeout     = 30 * time.Second // frames whose worker hasn't been heard from for this long are handed out again
+	leaseRetryDelay  = time.Second      // time workers wait before asking again, while all remaining frames are leased
+	maxFrameAttempts = 3                // number of times a frame may fail on workers before the render is aborted
+	workerRetries    = 5                // number of consecutive times a worker tries to reach the coordinator
+)
+
+// frameLease is a frame handed out to a worker, until it sends the frame back or stops sending heartbeats
+type frameLease struct {
+	ID    string `json:"lease"`
+	Frame int    `json:"frame"`
+
+	expires time.Time
+}
+
+// coordinator hands out the frames of a video to workers over HTTP, writing the frames that they send back
+// to .tmp/. Frames whose worker disappears are handed out again once their lease expires.
+//
+// Workers call these endpoints:
+//   - GET /job returns the key of the render, which the worker has to match
+//   - POST /lease returns the next frame to render, 503 if all remaining frames are leased, or 410 once all are done
+//   - POST /heartbeat?lease=<id> extends the lease while the frame is rendering, 410 if the lease was lost
+//   - POST /frame?lease=<id>&frame=<n> uploads the encoded frame, which has to be the one leased under the ID,
+//     409 if the lease has expired, and the frame was handed out again
+//   - POST /fail?lease=<id> reports that the frame could not be rendered, with the error as the body
+type coordinator struct {
+	key           manifestKey
+	manifest      *frameManifest
+	outFileFormat string
+	timeout       time.Duration
+	maxFrameBytes int64 // largest upload accepted for a single frame
+
+	mu        sync.Mutex
+	queue     []int                  // frames that are waiting to be leased
+	leases    map[string]*frameLease // leases that have not expired, by ID
+	remaining map[int]bool           // frames that are not yet complete
+	attempts  map[int]int            // number of times each frame has failed
+	nextID    int
+	err       error         // set once a frame failed too often
+	done      chan struct{} // closed once all frames are complete, or the render failed
+}
+
+func newCoordinator(key manifestKey, manifest *frameManifest, outFileFormat string, ip ImagePreset, frames []int) *coordinator {
+	c := &coordinator{
+		key:           key,
+		manifest:      manifest,
+		outFileFormat: outFileFormat,
+		timeout:       leaseTimeout,
+		maxFrameBytes: maxEncodedFrameBytes(ip),
+		queue:         frames,
+		leases:        map[string]*frameLease{},
+		remaining:     map[int]bool{},
+		attempts:      map[int]int{},
+		done:          make(chan struct{}),
+	}
+	for _, i := range frames {
+		c.remaining[i] = true
+	}
+	if len(frames) == 0 {
+		close(c.done)
+	}
+	return c
+}
+
+// RenderVideoDistributed serves the frames of the video to workers started with RunWorker, listening on addr.
+// Once the workers have sent back all frames, they are encoded from .tmp/ with ffmpeg, as with -pngframes.
+func RenderVideoDistributed(vp VideoPreset, addr string, outFile string) error {
+	start := time.Now()
+	fileWildcardPattern, outFileFormat, manifestPath := frameDirectoryPaths(vp)
+	manifest, frames, err := prepareFrameDirectory(vp, fileWildcardPattern, outFileFormat, manifestPath)
+	if err != nil {
+		return err
+	}
+	c := newCoordinator(manifest.manifestKey, manifest, outFileFormat, vp.ImagePreset, frames)
+	server := &http.Server{Addr: addr, Handler: c.handler()}
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.ListenAndServe()
+	}()
+	// workers that ask for frames during encoding are told that the render is done
+	defer server.Shutdown(context.Background())
+
+	fmt.Printf("Waiting for workers to render %d frames on %s...\n", len(frames), addr)
+	select {
+	case err := <-serverErr:
+		return err
+	case <-c.done:
+	}
+	if err := c.failure(); err != nil {
+		return fmt.Errorf("%w\nrerun with -resume to render the remaining frames", err)
+	}
+	fmt.Printf("\nDistributed frame generation took %s\n", time.Since(start))
+	return encodeFrameDirectory(vp, fileWildcardPattern, outFileFormat, outFile)
+}
+
+func (c *coordinator) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/job", func(w http.ResponseWriter, req *http.Request) {
+		json.NewEncoder(w).Encode(c.key)
+	})
+	mux.HandleFunc("/lease", func(w http.ResponseWriter, req *http.Request) {
+		lease, status := c.lease()
+		if lease == nil {
+			w.WriteHeader(status)
+			return
+		}
+		json.NewEncoder(w).Encode(lease)
+	})
+	mux.HandleFunc("/heartbeat", func(w http.ResponseWriter, req *http.Request) {
+		if !c.heartbeat(req.URL.Query().Get("lease")) {
+			w.WriteHeader(http.StatusGone)
+		}
+	})
+	mux.HandleFunc("/frame", func(w http.ResponseWriter, req *http.Request) {
+		frame, err := strconv.Atoi(req.URL.Query().Get("frame"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		data, err := io.ReadAll(http.MaxBytesReader(w, req.Body, c.maxFrameBytes))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		err = c.complete(req.URL.Query().Get("lease"), frame, data)
+		if errors.Is(err, errWrongLease) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, errLeaseLost) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+	mux.HandleFunc("/fail", func(w http.ResponseWriter, req *http.Request) {
+		message, _ := io.ReadAll(req.Body)
+		c.fail(req.URL.Query().Get("lease"), string(message))
+	})
+	return mux
+}
+
+// lease returns the next frame to be rendered, or nil and the HTTP status telling the worker why there is none
+func (c *coordinator) lease() (*frameLease, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.remaining) == 0 || c.err != nil {
+		return nil, http.StatusGone
+	}
+	c.expireLeases()
+	if len(c.queue) == 0 {
+		return nil, http.StatusServiceUnavailable
+	}
+	frame := c.queue[0]
+	c.queue = c.queue[1:]
+	c.nextID += 1
+	lease := &frameLease{
+		ID:      strconv.Itoa(c.nextID),
+		Frame:   frame,
+		expires: time.Now().Add(c.timeout),
+	}
+	c.leases[lease.ID] = lease
+	return lease, http.StatusOK
+}
+
+// expireLeases puts the frames of leases whose worker hasn't been heard from back in the queue.
+// It must be called with the lock held.
+func (c *coordinator) expireLeases() {
+	now := time.Now()
+	for id, lease := range c.leases {
+		if !now.After(lease.expires) {
+			continue
+		}
+		delete(c.leases, id)
+		if c.remaining[lease.Frame] {
+			fmt.Printf("Lease of frame %d expired, handing it out again\n", lease.Frame)
+			c.queue = append(c.queue, lease.Frame)
+		}
+	}
+}
+
+// heartbeat extends the lease, returning false if it has expired
+func (c *coordinator) heartbeat(id string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	lease, ok := c.leases[id]
+	if !ok {
+		return false
+	}
+	lease.expires = time.Now().Add(c.timeout)
+	return true
+}
+
+var (
+	// errWrongLease is returned for uploads of a frame that wasn't leased under the lease they are sent with
+	errWrongLease = errors.New("frame was not leased under this lease")
+	// errLeaseLost is returned for uploads under a lease that has expired, or was never handed out
+	errLeaseLost = errors.New("lease has expired")
+)
+
+// complete writes the frame sent back by a worker, under a lease that has not expired. Once a lease expires,
+// its frame is handed out again, and the frame sent back late is turned away.
+func (c *coordinator) complete(id string, frame int, data []byte) error {
+	c.mu.Lock()
+	lease, ok := c.leases[id]
+	if !ok {
+		c.mu.Unlock()
+		return fmt.Errorf("frame %d with lease %q: %w", frame, id, errLeaseLost)
+	}
+	if lease.Frame != frame {
+		c.mu.Unlock()
+		return fmt.Errorf("frame %d with lease %q: %w", frame, id, errWrongLease)
+	}
+	delete(c.leases, id)
+	if !c.remaining[frame] {
+		c.mu.Unlock()
+		return nil
+	}
+	c.mu.Unlock()
+	if len(data) == 0 {
+		return fmt.Errorf("frame %d is empty", frame)
+	}
+	if err := c.manifest.writeFrame(frame, c.outFileFormat, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	}); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.remaining[frame] {
+		return nil
+	}
+	delete(c.remaining, frame)
+	// another worker may still be rendering the frame, after an earlier lease failed
+	for i, queued := range c.queue {
+		if queued == frame {
+			c.queue = append(c.queue[:i], c.queue[i+1:]...)
+			break
+		}
+	}
+	fmt.Printf("Frame %d complete, %d remaining\n", frame, len(c.remaining))
+	if len(c.remaining) == 0 && c.err == nil {
+		close(c.done)
+	}
+	return nil
+}
+
+// fail hands the frame of the lease out again, unless it has failed too often, which aborts the render
+func (c *coordinator) fail(id string, message string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	lease, ok := c.leases[id]
+	if !ok {
+		return
+	}
+	delete(c.leases, id)
+	if !c.remaining[lease.Frame] {
+		return
+	}
+	fmt.Printf("Frame %d failed on a worker: %s\n", lease.Frame, message)
+	c.attempts[lease.Frame] += 1
+	if c.attempts[lease.Frame] < maxFrameAttempts {
+		c.queue = append(c.queue, lease.Frame)
+		return
+	}
+	if c.err == nil {
+		c.err = fmt.Errorf("frame %d failed %d times, last with: %s", lease.Frame, maxFrameAttempts, message)
+		close(c.done)
+	}
+}
+
+func (c *coordinator) failure() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// RunWorker renders frames of the scene handed out by the coordinator at coordinatorURL, until all frames are done.
+// The worker has to be started with the same scene, preset and executable as the coordinator.
+func RunWorker(scene scenes.DynamicScene, vp VideoPreset, coordinatorURL string, wireframe bool, triDepth bool) error {
+	w := worker{
+		client:  &http.Client{},
+		baseURL: coordinatorURL,
+	}
+	var job manifestKey
+	if err := w.call("GET", "/job", nil, nil, &job); err != nil {
+		return err
+	}
+	key, err := newManifestKey(vp.sceneName, vp)
+	if err != nil {
+		return err
+	}
+	if job != key {
+		return fmt.Errorf("coordinator is rendering %+v, but this worker would render %+v", job, key)
+	}
+
+	r := newRenderer(vp.ImagePreset)
+	go func() {
+		// workers don't show progress within frames
+		for range r.lineChannel {
+		}
+	}()
+	defer close(r.lineChannel)
+
+	var wg sync.WaitGroup
+	errs := make([]error, framesInFlight(vp.ImagePreset))
+	for n := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[n] = w.renderLeases(r, scene, vp, wireframe, triDepth)
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// worker talks to the coordinator over HTTP
+type worker struct {
+	client  *http.Client
+	baseURL string
+}
+
+// errRenderDone is returned when the coordinator has no more frames to hand out
+var errRenderDone = errors.New("render is done")
+
+// renderLeases leases frames from the coordinator, renders them and sends them back, until the render is done
+func (w worker) renderLeases(r Renderer, scene scenes.DynamicScene, vp VideoPreset, wireframe bool, triDepth bool) error {
+	for {
+		var lease frameLease
+		err := w.call("POST", "/lease", nil, nil, &lease)
+		if errors.Is(err, errRenderDone) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if lease.ID == "" {
+			// all remaining frames are leased to other workers, one of them might disappear
+			time.Sleep(leaseRetryDelay)
+			continue
+		}
+
+		stopHeartbeat := w.heartbeat(lease.ID)
+		var data bytes.Buffer
+		err = r.renderFrameSafely(scene, lease.Frame, vp, wireframe, triDepth, func(i int, frame *Image) error {
+			return encodeFrame(&data, frame, vp.frameFormat)
+		})
+		stopHeartbeat()
+		query := url.Values{"lease": {lease.ID}, "frame": {strconv.Itoa(lease.Frame)}}
+		if err != nil {
+			fmt.Printf("%s\n", err)
+			if err := w.call("POST", "/fail", query, []byte(err.Error()), nil); err != nil && !errors.Is(err, errRenderDone) {
+				return err
+			}
+			continue
+		}
+		err = w.call("POST", "/frame", query, data.Bytes(), nil)
+		if errors.Is(err, errLeaseLost) {
+			fmt.Printf("Lease of frame %d expired before it was sent, dropping it\n", lease.Frame)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Sent frame %d\n", lease.Frame)
+	}
+}
+
+// heartbeat extends the lease in the background, until the returned function is called
+func (w worker) heartbeat(id string) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		ticker := time.NewTicker(leaseTimeout / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				// a lost lease is handed out again, the frame is turned away once it is sent back
+				w.call("POST", "/heartbeat", url.Values{"lease": {id}}, nil, nil)
+			}
+		}
+	}()
+	return cancel
+}
+
+// call sends a request to the coordinator, decoding the JSON response into result if it is set.
+// Requests are retried while the coordinator can't be reached, and return errRenderDone once it has no more frames,
+// or errLeaseLost if the frame was sent back under an expired lease.
+func (w worker) call(method, path string, query url.Values, body []byte, result any) error {
+	var err error
+	for attempt := range workerRetries {
+		if attempt > 0 {
+			time.Sleep(leaseRetryDelay)
+		}
+		var req *http.Request
+		req, err = http.NewRequest(method, w.baseURL+path+"?"+query.Encode(), bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		var resp *http.Response
+		resp, err = w.client.Do(req)
+		if err != nil {
+			continue
+		}
+		defer resp.Body.Close()
+		switch resp.StatusCode {
+		case http.StatusOK:
+			if result == nil {
+				return nil
+			}
+			return json.NewDecoder(resp.Body).Decode(result)
+		case http.StatusServiceUnavailable:
+			// no result, the caller tries again later
+			return nil
+		case http.StatusGone:
+			return errRenderDone
+		case http.StatusConflict:
+			return errLeaseLost
+		default:
+			message, _ := io.ReadAll(resp.Body)
+			return fmt.Errorf("coordinator returned %s for %s: %s", resp.Status, path, message)
+		}
+	}
+	return fmt.Errorf("could not reach coordinator at %s: %w", w.baseURL, err)
+}
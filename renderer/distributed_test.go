@@ -0,0 +1,202 @@
+package renderer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/libeks/go-scene-renderer/colors"
+	"github.com/libeks/go-scene-renderer/scenes"
+	"github.com/libeks/go-scene-renderer/textures"
+)
+
+// startTestCoordinator serves the frames of a small video of the scene to workers, writing them to a temporary directory
+func startTestCoordinator(t *testing.T, vp VideoPreset) (*coordinator, string) {
+	dir := t.TempDir()
+	outFileFormat := filepath.Join(dir, "frame_%03d.png")
+	key, err := newManifestKey(vp.sceneName, vp)
+	if err != nil {
+		t.Fatalf("newManifestKey: %s", err)
+	}
+	manifest, err := newManifest(filepath.Join(dir, "manifest.json"), key)
+	if err != nil {
+		t.Fatalf("newManifest: %s", err)
+	}
+	c := newCoordinator(key, manifest, outFileFormat, vp.ImagePreset, vp.allFrames())
+	c.timeout = 100 * time.Millisecond
+	server := httptest.NewServer(c.handler())
+	t.Cleanup(server.Close)
+	return c, server.URL
+}
+
+func testVideoPreset() VideoPreset {
+	return VideoPreset{
+		ImagePreset: ImagePreset{width: 8, height: 8, interpolateN: 1},
+		nFrameCount: 4,
+	}.WithResume("spheres", false)
+}
+
+func TestDistributedRenderRetriesLostFrames(t *testing.T) {
+	background := scenes.BackgroundFromTexture(textures.StaticTexture(textures.Uniform(colors.Black)))
+	scene := scenes.ThreeSpheres(background)
+	vp := testVideoPreset()
+	c, url := startTestCoordinator(t, vp)
+
+	// a worker leases a frame and disappears
+	resp, err := http.Post(url+"/lease", "", nil)
+	if err != nil {
+		t.Fatalf("leasing a frame: %s", err)
+	}
+	resp.Body.Close()
+
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for n := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[n] = RunWorker(scene, vp, url, false, false)
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			t.Errorf("RunWorker: %s", err)
+		}
+	}
+	select {
+	case <-c.done:
+	default:
+		t.Fatalf("workers stopped before all frames were done")
+	}
+	if err := c.failure(); err != nil {
+		t.Errorf("render failed: %s", err)
+	}
+	if diff := cmp.Diff([]int(nil), c.manifest.missingFrames(vp.nFrameCount, c.outFileFormat)); diff != "" {
+		t.Errorf("missing frames (-want +got):\n%s", diff)
+	}
+}
+
+func TestDistributedRenderAbortsFailingFrames(t *testing.T) {
+	background := scenes.BackgroundFromTexture(textures.StaticTexture(textures.Uniform(colors.Black)))
+	scene := panickingScene{scenes.ThreeSpheres(background), 0}
+	vp := testVideoPreset()
+	c, url := startTestCoordinator(t, vp)
+	if err := RunWorker(scene, vp, url, false, false); err != nil {
+		t.Errorf("RunWorker: %s", err)
+	}
+	if c.failure() == nil {
+		t.Errorf("render of a frame that always panics didn't fail")
+	}
+}
+
+func TestWorkerRejectsOtherRenders(t *testing.T) {
+	background := scenes.BackgroundFromTexture(textures.StaticTexture(textures.Uniform(colors.Black)))
+	scene := scenes.ThreeSpheres(background)
+	_, url := startTestCoordinator(t, testVideoPreset())
+	if err := RunWorker(scene, testVideoPreset().WithResume("other", false), url, false, false); err == nil {
+		t.Errorf("worker of another scene was accepted")
+	}
+}
+
+func TestCoordinatorValidatesUploads(t *testing.T) {
+	vp := testVideoPreset()
+	c, url := startTestCoordinator(t, vp)
+	resp, err := http.Post(url+"/lease", "", nil)
+	if err != nil {
+		t.Fatalf("leasing a frame: %s", err)
+	}
+	var lease frameLease
+	err = json.NewDecoder(resp.Body).Decode(&lease)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("decoding lease: %s", err)
+	}
+
+	upload := func(id string, frame int, data []byte) int {
+		resp, err := http.Post(fmt.Sprintf("%s/frame?lease=%s&frame=%d", url, id, frame), "", bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("uploading frame: %s", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	for _, tc := range []struct {
+		name  string
+		id    string
+		frame int
+		data  []byte
+		want  int
+	}{
+		{name: "other frame", id: lease.ID, frame: lease.Frame + 1, data: []byte("frame"), want: http.StatusBadRequest},
+		{name: "unknown lease", id: "unknown", frame: lease.Frame, data: []byte("frame"), want: http.StatusConflict},
+		{name: "larger than a frame", id: lease.ID, frame: lease.Frame, data: make([]byte, c.maxFrameBytes+1), want: http.StatusRequestEntityTooLarge},
+		{name: "leased frame", id: lease.ID, frame: lease.Frame, data: []byte("frame"), want: http.StatusOK},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := upload(tc.id, tc.frame, tc.data); got != tc.want {
+				t.Errorf("upload returned status %d, want %d", got, tc.want)
+			}
+		})
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.remaining[lease.Frame] {
+		t.Errorf("frame %d is still remaining after its upload", lease.Frame)
+	}
+}
+
+func TestCoordinatorForgetsEndedLeases(t *testing.T) {
+	vp := testVideoPreset()
+	c, url := startTestCoordinator(t, vp)
+	lease := func() frameLease {
+		resp, err := http.Post(url+"/lease", "", nil)
+		if err != nil {
+			t.Fatalf("leasing a frame: %s", err)
+		}
+		defer resp.Body.Close()
+		var lease frameLease
+		if err := json.NewDecoder(resp.Body).Decode(&lease); err != nil {
+			t.Fatalf("decoding lease: %s", err)
+		}
+		return lease
+	}
+	post := func(path string, lease frameLease) int {
+		resp, err := http.Post(fmt.Sprintf("%s%s?lease=%s&frame=%d", url, path, lease.ID, lease.Frame), "", bytes.NewReader([]byte("frame")))
+		if err != nil {
+			t.Fatalf("posting to %s: %s", path, err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	uploaded, failed, expired := lease(), lease(), lease()
+	if got := post("/frame", uploaded); got != http.StatusOK {
+		t.Errorf("upload returned status %d, want %d", got, http.StatusOK)
+	}
+	post("/fail", failed)
+	time.Sleep(2 * c.timeout)
+	// leasing expires the lease that wasn't heard from, and hands its frame out again
+	lease()
+	if got := post("/frame", expired); got != http.StatusConflict {
+		t.Errorf("upload under an expired lease returned status %d, want %d", got, http.StatusConflict)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, ended := range []frameLease{uploaded, failed, expired} {
+		if _, ok := c.leases[ended.ID]; ok {
+			t.Errorf("lease %s of frame %d is kept after it ended", ended.ID, ended.Frame)
+		}
+	}
+	if !c.remaining[expired.Frame] {
+		t.Errorf("frame %d sent back under an expired lease was accepted", expired.Frame)
+	}
+}
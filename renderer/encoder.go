@@ -0,0 +1,165 @@
+package renderer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+)
+
+const (
+	encoderBufferFrames = 10 // number of rendered frames held back until all earlier frames are encoded
+)
+
+// frameEncoder pipes raw RGB frames into ffmpeg's stdin. Frames may be added in any order from concurrent
+// goroutines, and are written in frame order, holding back at most encoderBufferFrames of them at a time.
+type frameEncoder struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	output *bytes.Buffer // ffmpeg's combined stdout and stderr
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	next    int            // index of the next frame to be written
+	pending map[int]*Image // frames waiting for earlier frames to be written
+	deep    bool           // write frames with 16 bits per channel, rather than 8
+	err     error          // first error writing to ffmpeg, returned from every later call
+}
+
+func newFrameEncoder(vp VideoPreset, outFile string) (*frameEncoder, error) {
+	params := []string{
+		"-y",
+		"-f", "rawvideo",
+		"-pix_fmt", rawPixelFormat(vp),
+		"-video_size", fmt.Sprintf("%dx%d", vp.width, vp.height),
+		"-framerate", fmt.Sprintf("%.2f", vp.frameRate),
+		"-i", "-",
+	}
+	params = append(params, encoderParams()...)
+	params = append(params, outFile)
+	encoder, err := startFrameEncoder(exec.Command("ffmpeg", params...))
+	if err != nil {
+		return nil, err
+	}
+	encoder.deep = rawPixelFormat(vp) == "rgb48le"
+	return encoder, nil
+}
+
+// rawPixelFormat returns the ffmpeg pixel format that frames are piped in. Frames keep 16 bits per channel
+// for high bit depth frame formats, and for h265, whose 10-bit output would otherwise get upsampled 8-bit data.
+func rawPixelFormat(vp VideoPreset) string {
+	if render_h265 || vp.frameFormat.highBitDepth() {
+		return "rgb48le"
+	}
+	return "rgb24"
+}
+
+// startFrameEncoder starts the command, which is sent the frames on stdin
+func startFrameEncoder(cmd *exec.Cmd) (*frameEncoder, error) {
+	output := &bytes.Buffer{}
+	cmd.Stdout = output
+	cmd.Stderr = output
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	e := &frameEncoder{
+		cmd:     cmd,
+		stdin:   stdin,
+		output:  output,
+		pending: map[int]*Image{},
+	}
+	e.cond = sync.NewCond(&e.mu)
+	return e, nil
+}
+
+// addFrame queues the i-th frame, writing it and any queued frames following it once all earlier frames
+// have been written. It blocks while the frame is too far ahead of the next one to be written.
+func (e *frameEncoder) addFrame(i int, frame *Image) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for i >= e.next+encoderBufferFrames && e.err == nil {
+		e.cond.Wait()
+	}
+	if e.err != nil {
+		return e.err
+	}
+	e.pending[i] = frame
+	for {
+		frame, ok := e.pending[e.next]
+		if !ok {
+			break
+		}
+		delete(e.pending, e.next)
+		data := rgbBytes(frame)
+		if e.deep {
+			data = rgb48Bytes(frame)
+		}
+		if _, err := e.stdin.Write(data); err != nil {
+			e.err = fmt.Errorf("could not write frame %d to ffmpeg: %w\n%s", e.next, err, e.output.String())
+			break
+		}
+		e.next += 1
+	}
+	e.cond.Broadcast()
+	return e.err
+}
+
+// abort stops the encoder from writing any more frames, once a frame could not be rendered.
+// Frames waiting for the frame return the error, rather than blocking forever.
+func (e *frameEncoder) abort(err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.err == nil {
+		e.err = err
+	}
+	e.cond.Broadcast()
+}
+
+// close signals the end of the video to ffmpeg, and waits for it to finish encoding
+func (e *frameEncoder) close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.pending) > 0 && e.err == nil {
+		e.err = fmt.Errorf("closing encoder with %d frames waiting for frame %d", len(e.pending), e.next)
+	}
+	e.stdin.Close()
+	err := e.cmd.Wait()
+	fmt.Print(e.output.String())
+	if e.err != nil {
+		return e.err
+	}
+	return err
+}
+
+// rgbBytes returns the pixels of the image as packed 8-bit RGB triplets, starting with the top row
+func rgbBytes(img *Image) []byte {
+	im := img.im
+	width, height := im.Rect.Dx(), im.Rect.Dy()
+	ret := make([]byte, 0, width*height*3)
+	for y := range height {
+		row := im.Pix[y*im.Stride : y*im.Stride+width*4]
+		for x := 0; x < len(row); x += 4 {
+			ret = append(ret, row[x], row[x+1], row[x+2])
+		}
+	}
+	return ret
+}
+
+// rgb48Bytes returns the pixels of the image as packed little-endian 16-bit RGB triplets, starting with the top row,
+// gamma corrected from the image's linear colors
+func rgb48Bytes(img *Image) []byte {
+	ret := make([]byte, 0, len(img.pixels)*6)
+	for _, c := range img.pixels {
+		r, g, b, _ := clampColor(c).RGBA()
+		ret = binary.LittleEndian.AppendUint16(ret, uint16(r))
+		ret = binary.LittleEndian.AppendUint16(ret, uint16(g))
+		ret = binary.LittleEndian.AppendUint16(ret, uint16(b))
+	}
+	return ret
+}
@@ -0,0 +1,47 @@
+package renderer
+
+import (
+	"bytes"
+	"os/exec"
+	"sync"
+	"testing"
+
+	"github.com/libeks/go-scene-renderer/colors"
+)
+
+func TestFrameEncoderWritesInOrder(t *testing.T) {
+	ip := ImagePreset{width: 2, height: 1}
+	nFrames := 3 * encoderBufferFrames
+	encoder, err := startFrameEncoder(exec.Command("cat"))
+	if err != nil {
+		t.Skipf("could not start cat: %s", err)
+	}
+	var wg sync.WaitGroup
+	// add frames from the back, so that all but the first have to wait for earlier ones
+	for i := nFrames - 1; i >= 0; i-- {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			frame := NewImage(ip)
+			frame.Fill(colors.GrayscaleColor(float64(i) / float64(nFrames)))
+			if err := encoder.addFrame(i, frame); err != nil {
+				t.Errorf("adding frame %d: %s", i, err)
+			}
+		}()
+	}
+	wg.Wait()
+	output := encoder.output
+	if err := encoder.close(); err != nil {
+		t.Fatalf("closing encoder: %s", err)
+	}
+
+	want := &bytes.Buffer{}
+	for i := range nFrames {
+		frame := NewImage(ip)
+		frame.Fill(colors.GrayscaleColor(float64(i) / float64(nFrames)))
+		want.Write(rgbBytes(frame))
+	}
+	if !bytes.Equal(output.Bytes(), want.Bytes()) {
+		t.Errorf("encoder wrote %v, want %v", output.Bytes(), want.Bytes())
+	}
+}
@@ -0,0 +1,230 @@
+package renderer
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"image/png"
+	"io"
+	"math"
+)
+
+// FrameFormat selects the file format that images and video frames are written in
+type FrameFormat int
+
+const (
+	FramePNG    FrameFormat = iota // 8-bit PNG, gamma corrected
+	FramePNG16                     // 16-bit PNG, gamma corrected
+	FramePFM                       // portable float map, with linear 32-bit float channels
+	FrameEXR                       // uncompressed OpenEXR, with linear 32-bit float channels
+	FrameEXRZip                    // OpenEXR compressed with zlib in blocks of 16 scanlines
+)
+
+const (
+	exrZipScanlines  = 16      // number of scanlines compressed together in ZIP-compressed OpenEXR files
+	frameHeaderBytes = 1 << 16 // generous bound on the size of the headers of an encoded frame, besides its scanlines
+)
+
+func ParseFrameFormat(flagVal string) (FrameFormat, error) {
+	switch flagVal {
+	case "png":
+		return FramePNG, nil
+	case "png16":
+		return FramePNG16, nil
+	case "pfm":
+		return FramePFM, nil
+	case "exr":
+		return FrameEXR, nil
+	case "exrzip":
+		return FrameEXRZip, nil
+	default:
+		return FramePNG, fmt.Errorf("could not parse frame format '%s', expect png, png16, pfm, exr or exrzip", flagVal)
+	}
+}
+
+// Extension returns the file extension of the format, without a leading dot
+func (f FrameFormat) Extension() string {
+	switch f {
+	case FramePFM:
+		return "pfm"
+	case FrameEXR, FrameEXRZip:
+		return "exr"
+	default:
+		return "png"
+	}
+}
+
+// highBitDepth returns true if the format keeps more than 8 bits per channel
+func (f FrameFormat) highBitDepth() bool {
+	return f != FramePNG
+}
+
+// maxEncodedFrameBytes returns an upper bound on the size of a frame of the preset, encoded in its frame format.
+// Compressed formats are bounded by their uncompressed size, plus the overhead of data that doesn't compress.
+func maxEncodedFrameBytes(ip ImagePreset) int64 {
+	pixelBytes := int64(4)
+	switch ip.frameFormat {
+	case FramePNG16:
+		pixelBytes = 8
+	case FramePFM, FrameEXR, FrameEXRZip:
+		pixelBytes = 12
+	}
+	// each scanline also has a filter byte, or an offset and a length
+	raw := int64(ip.width*ip.height)*pixelBytes + int64(ip.height)*16
+	return raw + raw/64 + frameHeaderBytes
+}
+
+// encodeFrame writes the image in the format
+func encodeFrame(w io.Writer, img *Image, format FrameFormat) error {
+	switch format {
+	case FramePNG16:
+		return png.Encode(w, img.GetImage16())
+	case FramePFM:
+		return encodePFM(w, img)
+	case FrameEXR:
+		return encodeEXR(w, img, false)
+	case FrameEXRZip:
+		return encodeEXR(w, img, true)
+	default:
+		return png.Encode(w, img.GetImage())
+	}
+}
+
+// encodePFM writes the linear colors of the image as a little-endian color PFM, whose rows start at the bottom
+func encodePFM(w io.Writer, img *Image) error {
+	bw := bufio.NewWriter(w)
+	// a negative scale denotes little-endian values
+	fmt.Fprintf(bw, "PF\n%d %d\n-1.0\n", img.ip.width, img.ip.height)
+	values := make([]float32, 0, img.ip.width*3)
+	for y := img.ip.height - 1; y >= 0; y-- {
+		values = values[:0]
+		for _, c := range img.pixels[y*img.ip.width : (y+1)*img.ip.width] {
+			values = append(values, float32(c.R), float32(c.G), float32(c.B))
+		}
+		if err := binary.Write(bw, binary.LittleEndian, values); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// encodeEXR writes the linear colors of the image as a single-part scanline OpenEXR file,
+// with 32-bit float R, G and B channels. Scanlines are compressed with zlib if zip is set.
+func encodeEXR(w io.Writer, img *Image, zip bool) error {
+	width, height := img.ip.width, img.ip.height
+	linesPerChunk := 1
+	compression := byte(0) // NO_COMPRESSION
+	if zip {
+		linesPerChunk = exrZipScanlines
+		compression = 3 // ZIP_COMPRESSION
+	}
+
+	var header bytes.Buffer
+	header.Write([]byte{0x76, 0x2f, 0x31, 0x01}) // magic number
+	header.Write([]byte{2, 0, 0, 0})             // version 2, single-part scanline file
+	attribute := func(name, kind string, value []byte) {
+		header.WriteString(name + "\x00" + kind + "\x00")
+		binary.Write(&header, binary.LittleEndian, int32(len(value)))
+		header.Write(value)
+	}
+	var channels bytes.Buffer
+	// channels are stored in alphabetical order
+	for _, name := range []string{"B", "G", "R"} {
+		channels.WriteString(name + "\x00")
+		binary.Write(&channels, binary.LittleEndian, []int32{
+			2, // FLOAT
+			0, // pLinear and reserved bytes
+			1, // x sampling
+			1, // y sampling
+		})
+	}
+	channels.WriteByte(0)
+	window := binary.LittleEndian.AppendUint32(nil, 0)
+	window = binary.LittleEndian.AppendUint32(window, 0)
+	window = binary.LittleEndian.AppendUint32(window, uint32(width-1))
+	window = binary.LittleEndian.AppendUint32(window, uint32(height-1))
+	attribute("channels", "chlist", channels.Bytes())
+	attribute("compression", "compression", []byte{compression})
+	attribute("dataWindow", "box2i", window)
+	attribute("displayWindow", "box2i", window)
+	attribute("lineOrder", "lineOrder", []byte{0}) // INCREASING_Y, from the top row down
+	attribute("pixelAspectRatio", "float", binary.LittleEndian.AppendUint32(nil, math.Float32bits(1)))
+	attribute("screenWindowCenter", "v2f", make([]byte, 8))
+	attribute("screenWindowWidth", "float", binary.LittleEndian.AppendUint32(nil, math.Float32bits(1)))
+	header.WriteByte(0)
+
+	var chunks [][]byte
+	for y := 0; y < height; y += linesPerChunk {
+		var data bytes.Buffer
+		for line := y; line < min(y+linesPerChunk, height); line++ {
+			row := img.pixels[line*width : (line+1)*width]
+			for _, channel := range []func(x int) float64{
+				func(x int) float64 { return row[x].B },
+				func(x int) float64 { return row[x].G },
+				func(x int) float64 { return row[x].R },
+			} {
+				for x := range width {
+					data.Write(binary.LittleEndian.AppendUint32(nil, math.Float32bits(float32(channel(x)))))
+				}
+			}
+		}
+		raw := data.Bytes()
+		if zip {
+			compressed, err := exrZipCompress(raw)
+			if err != nil {
+				return err
+			}
+			// readers treat chunks that are as large as their uncompressed data as stored uncompressed
+			if len(compressed) < len(raw) {
+				raw = compressed
+			}
+		}
+		chunk := binary.LittleEndian.AppendUint32(nil, uint32(y))
+		chunk = binary.LittleEndian.AppendUint32(chunk, uint32(len(raw)))
+		chunks = append(chunks, append(chunk, raw...))
+	}
+
+	// the offset table points at each chunk, from the start of the file
+	offset := uint64(header.Len() + 8*len(chunks))
+	for _, chunk := range chunks {
+		binary.Write(&header, binary.LittleEndian, offset)
+		offset += uint64(len(chunk))
+	}
+	if _, err := w.Write(header.Bytes()); err != nil {
+		return err
+	}
+	for _, chunk := range chunks {
+		if _, err := w.Write(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exrZipCompress applies OpenEXR's ZIP preprocessing to the data, splitting even and odd bytes and
+// storing differences between consecutive bytes, before compressing it with zlib
+func exrZipCompress(data []byte) ([]byte, error) {
+	split := make([]byte, len(data))
+	half := (len(data) + 1) / 2
+	for i, b := range data {
+		if i%2 == 0 {
+			split[i/2] = b
+		} else {
+			split[half+i/2] = b
+		}
+	}
+	for i := len(split) - 1; i > 0; i-- {
+		split[i] = split[i] - split[i-1] + 128
+	}
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	if _, err := zw.Write(split); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
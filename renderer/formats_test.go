@@ -0,0 +1,198 @@
+package renderer
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"testing"
+
+	"github.com/libeks/go-scene-renderer/colors"
+	"github.com/libeks/go-scene-renderer/geometry"
+	"github.com/libeks/go-scene-renderer/objects"
+	"github.com/libeks/go-scene-renderer/scenes"
+	"github.com/libeks/go-scene-renderer/textures"
+)
+
+// testFrame returns a 2x2 image with a top row that is brighter than displayable
+func testFrame() *Image {
+	ip := ImagePreset{width: 2, height: 2}
+	frame := NewImage(ip)
+	frame.Set(0, 0, colors.Red)
+	frame.Set(1, 0, colors.Gray)
+	frame.Set(0, 1, colors.Color{R: 2, G: 0.25, B: 4})
+	frame.Set(1, 1, colors.Blue)
+	return frame
+}
+
+func TestEncodePNG16(t *testing.T) {
+	var buf bytes.Buffer
+	if err := encodeFrame(&buf, testFrame(), FramePNG16); err != nil {
+		t.Fatalf("encodeFrame: %s", err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("decoding PNG: %s", err)
+	}
+	if img.ColorModel() != color.RGBA64Model {
+		t.Errorf("got color model %v, want 16-bit RGBA", img.ColorModel())
+	}
+	// values above 1 are clamped, the same as in 8-bit images
+	if r, g, b, _ := img.At(0, 0).RGBA(); r != 0xffff || b != 0xffff || g == 0 {
+		t.Errorf("top left is %d,%d,%d, want clamped red and blue", r, g, b)
+	}
+	if r, g, b, _ := img.At(0, 1).RGBA(); r != 0xffff || g != 0 || b != 0 {
+		t.Errorf("bottom left is %d,%d,%d, want red", r, g, b)
+	}
+}
+
+func TestEncodePFM(t *testing.T) {
+	var buf bytes.Buffer
+	if err := encodeFrame(&buf, testFrame(), FramePFM); err != nil {
+		t.Fatalf("encodeFrame: %s", err)
+	}
+	header := "PF\n2 2\n-1.0\n"
+	if got := buf.String()[:len(header)]; got != header {
+		t.Fatalf("header is %q, want %q", got, header)
+	}
+	values := make([]float32, 12)
+	if err := binary.Read(bytes.NewReader(buf.Bytes()[len(header):]), binary.LittleEndian, values); err != nil {
+		t.Fatalf("reading values: %s", err)
+	}
+	// rows start at the bottom, and keep values above 1
+	want := []float32{1, 0, 0, 0.5, 0.5, 0.5, 2, 0.25, 4, 0, 0, 1}
+	for i := range want {
+		if values[i] != want[i] {
+			t.Errorf("got values %v, want %v", values, want)
+			break
+		}
+	}
+}
+
+func TestEncodeEXR(t *testing.T) {
+	for _, format := range []FrameFormat{FrameEXR, FrameEXRZip} {
+		var buf bytes.Buffer
+		if err := encodeFrame(&buf, testFrame(), format); err != nil {
+			t.Fatalf("encodeFrame: %s", err)
+		}
+		data := buf.Bytes()
+		if got := binary.LittleEndian.Uint32(data); got != 20000630 {
+			t.Fatalf("magic number is %d, want 20000630", got)
+		}
+		// skip the attributes of the header, each a name, type, size and value
+		pos := 8
+		for data[pos] != 0 {
+			pos += bytes.IndexByte(data[pos:], 0) + 1 // name
+			pos += bytes.IndexByte(data[pos:], 0) + 1 // type
+			pos += 4 + int(binary.LittleEndian.Uint32(data[pos:]))
+		}
+		pos += 1
+		nChunks := 2 // one per scanline without compression
+		if format == FrameEXRZip {
+			nChunks = 1
+		}
+		var rows []float32
+		for i := range nChunks {
+			offset := binary.LittleEndian.Uint64(data[pos+8*i:])
+			size := binary.LittleEndian.Uint32(data[offset+4:])
+			chunk := data[offset+8 : offset+8+uint64(size)]
+			if format == FrameEXRZip && len(chunk) < 2*2*3*4 {
+				chunk = exrZipDecompress(t, chunk)
+			}
+			for j := 0; j < len(chunk); j += 4 {
+				rows = append(rows, math.Float32frombits(binary.LittleEndian.Uint32(chunk[j:])))
+			}
+		}
+		// rows start at the top, with channels in the order B, G, R
+		want := []float32{4, 1, 0.25, 0, 2, 0, 0, 0.5, 0, 0.5, 1, 0.5}
+		if len(rows) != len(want) {
+			t.Fatalf("got %d values, want %d", len(rows), len(want))
+		}
+		for i := range want {
+			if rows[i] != want[i] {
+				t.Errorf("format %d: got values %v, want %v", format, rows, want)
+				break
+			}
+		}
+	}
+}
+
+// exrZipDecompress undoes exrZipCompress
+func exrZipDecompress(t *testing.T, data []byte) []byte {
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("reading zlib data: %s", err)
+	}
+	split, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("reading zlib data: %s", err)
+	}
+	for i := 1; i < len(split); i++ {
+		split[i] = split[i] + split[i-1] - 128
+	}
+	ret := make([]byte, len(split))
+	half := (len(split) + 1) / 2
+	for i := range ret {
+		if i%2 == 0 {
+			ret[i] = split[i/2]
+		} else {
+			ret[i] = split[half+i/2]
+		}
+	}
+	return ret
+}
+
+func TestRenderHDR(t *testing.T) {
+	// a gray wall filling the view, lit head on by a light bright enough to make it brighter than white
+	wall := objects.DynamicBasicObject(
+		objects.Tri(geometry.Pt(-10, -10, -3), geometry.Pt(30, -10, -3), geometry.Pt(-10, 30, -3)),
+		textures.OpaqueDynamicTexture(textures.StaticTexture(textures.Uniform(colors.Gray))),
+	)
+	scene := scenes.CombinedDynamicScene{
+		Objects:    []objects.DynamicObjectInt{objects.DynamicObjectFromBasics(wall)},
+		Background: scenes.BackgroundFromTexture(textures.StaticTexture(textures.Uniform(colors.Black))),
+		Lights: []scenes.DynamicLight{
+			scenes.StaticLight(scenes.DirectionalLight{Direction: geometry.V3(0, 0, -1), Color: colors.White, Intensity: 4}),
+		},
+	}
+	ip := ImagePreset{width: 4, height: 4, interpolateN: 2, rayDepth: defaultRayDepth}
+	r := newRenderer(ip)
+	go func() {
+		for range r.lineChannel {
+		}
+	}()
+	defer close(r.lineChannel)
+	frame := r.getImage(scene.GetFrame(0), ip)
+
+	var buf bytes.Buffer
+	if err := encodeFrame(&buf, frame, FramePFM); err != nil {
+		t.Fatalf("encodeFrame: %s", err)
+	}
+	header := "PF\n4 4\n-1.0\n"
+	values := make([]float32, 4*4*3)
+	if err := binary.Read(bytes.NewReader(buf.Bytes()[len(header):]), binary.LittleEndian, values); err != nil {
+		t.Fatalf("reading values: %s", err)
+	}
+	for i, v := range values {
+		// the diffuse term alone is 0.5*4
+		if v < 2 {
+			t.Fatalf("value %d of the PFM is %v, want at least 2", i, v)
+		}
+	}
+
+	// 8-bit output is clamped to white, rather than overflowing
+	buf.Reset()
+	if err := encodeFrame(&buf, frame, FramePNG); err != nil {
+		t.Fatalf("encodeFrame: %s", err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("decoding PNG: %s", err)
+	}
+	if r, g, b, _ := img.At(1, 1).RGBA(); r != 0xffff || g != 0xffff || b != 0xffff {
+		t.Errorf("PNG pixel is %d,%d,%d, want white", r, g, b)
+	}
+}
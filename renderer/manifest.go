@@ -0,0 +1,162 @@
+package renderer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// manifestKey identifies a render, frames are only reused by a render with the same key
+type manifestKey struct {
+	Scene  string `json:"scene"`
+	Preset string `json:"preset"`
+	Build  string `json:"build"` // hash of the executable, which changes whenever the scenes or the renderer do
+}
+
+// frameManifest records which frames of a render in .tmp/ are complete, so that an interrupted render can be resumed.
+// It is rewritten atomically after every frame, so it only ever lists frames whose files were fully written.
+type frameManifest struct {
+	manifestKey
+	Frames map[int]string `json:"frames"` // hash of the file of each complete frame
+
+	path string
+	mu   sync.Mutex
+}
+
+// newManifestKey returns the key of a render of the scene with the preset
+func newManifestKey(sceneName string, vp VideoPreset) (manifestKey, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return manifestKey{}, err
+	}
+	build, err := fileHash(exe)
+	if err != nil {
+		return manifestKey{}, err
+	}
+	// resuming a render, or where it is rendered, doesn't change its frames
+	vp.resume = false
+	vp.pngFrames = false
+	return manifestKey{
+		Scene:  sceneName,
+		Preset: fmt.Sprintf("%+v", vp),
+		Build:  build,
+	}, nil
+}
+
+// loadManifest reads the manifest at path, returning nil if there is none
+func loadManifest(path string) (*frameManifest, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	m := &frameManifest{path: path}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("could not parse manifest %s: %w", path, err)
+	}
+	return m, nil
+}
+
+// newManifest returns an empty manifest for the render, saved to path
+func newManifest(path string, key manifestKey) (*frameManifest, error) {
+	m := &frameManifest{
+		manifestKey: key,
+		Frames:      map[int]string{},
+		path:        path,
+	}
+	return m, m.save()
+}
+
+// missingFrames returns the frames that are not recorded as complete, or whose files are missing
+// or no longer match the hash they were recorded with
+func (m *frameManifest) missingFrames(nFrames int, outFileFormat string) []int {
+	var missing []int
+	for i := range nFrames {
+		hash, ok := m.Frames[i]
+		if ok {
+			if fileHash, err := fileHash(fmt.Sprintf(outFileFormat, i)); err == nil && fileHash == hash {
+				continue
+			}
+			delete(m.Frames, i)
+		}
+		missing = append(missing, i)
+	}
+	return missing
+}
+
+// writeFrame writes the i-th frame's file with write, and records it as complete
+func (m *frameManifest) writeFrame(i int, outFileFormat string, write func(w io.Writer) error) error {
+	file := fmt.Sprintf(outFileFormat, i)
+	if err := writeFileAtomically(file, write); err != nil {
+		return err
+	}
+	return m.complete(i, file)
+}
+
+// complete records the i-th frame as written to file, safe to call from concurrent goroutines
+func (m *frameManifest) complete(i int, file string) error {
+	hash, err := fileHash(file)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Frames[i] = hash
+	return m.save()
+}
+
+// save writes the manifest to a temporary file, which then replaces the previous manifest
+func (m *frameManifest) save() error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomically(m.path, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// writeFileAtomically writes the file with write, so that it either has its previous contents, or all of the new ones
+func writeFileAtomically(path string, write func(w io.Writer) error) error {
+	// concurrent writers of the same file each write their own temporary file
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.partial")
+	if err != nil {
+		return err
+	}
+	tmpPath := f.Name()
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+func fileHash(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
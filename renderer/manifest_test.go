@@ -0,0 +1,56 @@
+package renderer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestManifestMissingFrames(t *testing.T) {
+	dir := t.TempDir()
+	outFileFormat := filepath.Join(dir, "frame_%03d.png")
+	manifestPath := filepath.Join(dir, "manifest.json")
+	key := manifestKey{Scene: "scene", Preset: "preset", Build: "build"}
+	manifest, err := newManifest(manifestPath, key)
+	if err != nil {
+		t.Fatalf("newManifest: %s", err)
+	}
+	for i := range 4 {
+		file := fmt.Sprintf(outFileFormat, i)
+		if err := os.WriteFile(file, []byte{byte(i)}, 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := manifest.complete(i, file); err != nil {
+			t.Fatalf("complete(%d): %s", i, err)
+		}
+	}
+	// frame 1 is corrupted, frame 2 went missing
+	if err := os.WriteFile(fmt.Sprintf(outFileFormat, 1), []byte{9}, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(fmt.Sprintf(outFileFormat, 2)); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := loadManifest(manifestPath)
+	if err != nil {
+		t.Fatalf("loadManifest: %s", err)
+	}
+	if loaded.manifestKey != key {
+		t.Errorf("loaded key %+v, want %+v", loaded.manifestKey, key)
+	}
+	// frames 4 and 5 were never rendered
+	if diff := cmp.Diff([]int{1, 2, 4, 5}, loaded.missingFrames(6, outFileFormat)); diff != "" {
+		t.Errorf("missingFrames() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLoadMissingManifest(t *testing.T) {
+	manifest, err := loadManifest(filepath.Join(t.TempDir(), "manifest.json"))
+	if manifest != nil || err != nil {
+		t.Errorf("loadManifest() = %v, %v, want nil, nil", manifest, err)
+	}
+}
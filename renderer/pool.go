@@ -0,0 +1,159 @@
+package renderer
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"sync"
+)
+
+const (
+	framePixelBytes      = 256      // rough memory use per pixel of a frame in flight, for its sample, raster and image buffers
+	frameOverheadBytes   = 64 << 20 // rough memory use of a frame in flight besides its pixels, for its objects and BVH
+	frameMemoryFraction  = 0.5      // fraction of the available memory that frames in flight may use
+	defaultMemoryBudget  = 4 << 30  // memory assumed to be available, if it can't be found out
+	minFramesPerTileWork = 2        // frames kept in flight, so that workers aren't idle while the last windows of a frame finish
+)
+
+// tilePool runs the windows of all frames being rendered on GOMAXPROCS workers. Each worker takes tasks from
+// the front of its own queue, and once it runs out, steals them from the back of the longest other queue.
+type tilePool struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	queues [][]func()
+	next   int // queue that the next batch of tasks starts on
+}
+
+// sharedTilePool is used by all frames, so that the windows of concurrent frames don't compete for more threads
+var sharedTilePool = sync.OnceValue(func() *tilePool {
+	return newTilePool(runtime.GOMAXPROCS(0))
+})
+
+func newTilePool(workers int) *tilePool {
+	p := &tilePool{
+		queues: make([][]func(), workers),
+	}
+	p.cond = sync.NewCond(&p.mu)
+	for i := range workers {
+		go p.work(i)
+	}
+	return p
+}
+
+// taskPanic is a panic of a task, along with the stack of the worker that ran it
+type taskPanic struct {
+	value any
+	stack []byte
+}
+
+func (p taskPanic) Error() string {
+	return fmt.Sprintf("%v\n%s", p.value, p.stack)
+}
+
+// run runs the tasks on the pool's workers, returning once all of them are done. If any of them panics,
+// the workers carry on, and run panics with the first of them once the others are done, so that it can be
+// recovered by the caller rather than crashing the process.
+func (p *tilePool) run(tasks []func()) {
+	var wg sync.WaitGroup
+	var once sync.Once
+	var panicked *taskPanic
+	wg.Add(len(tasks))
+	p.mu.Lock()
+	for _, task := range tasks {
+		p.queues[p.next] = append(p.queues[p.next], func() {
+			defer wg.Done()
+			defer func() {
+				if v := recover(); v != nil {
+					once.Do(func() {
+						panicked = &taskPanic{v, debug.Stack()}
+					})
+				}
+			}()
+			task()
+		})
+		p.next = (p.next + 1) % len(p.queues)
+	}
+	p.mu.Unlock()
+	p.cond.Broadcast()
+	wg.Wait()
+	if panicked != nil {
+		panic(*panicked)
+	}
+}
+
+// work runs the tasks of the i-th worker, forever
+func (p *tilePool) work(i int) {
+	for {
+		p.mu.Lock()
+		task := p.take(i)
+		for task == nil {
+			p.cond.Wait()
+			task = p.take(i)
+		}
+		p.mu.Unlock()
+		task()
+	}
+}
+
+// take returns the next task of the i-th worker, stolen from another worker if it has none of its own,
+// or nil if there are no tasks at all. It must be called with the lock held.
+func (p *tilePool) take(i int) func() {
+	if queue := p.queues[i]; len(queue) > 0 {
+		p.queues[i] = queue[1:]
+		return queue[0]
+	}
+	victim := -1
+	for j, queue := range p.queues {
+		if len(queue) > 0 && (victim < 0 || len(queue) > len(p.queues[victim])) {
+			victim = j
+		}
+	}
+	if victim < 0 {
+		return nil
+	}
+	queue := p.queues[victim]
+	p.queues[victim] = queue[:len(queue)-1]
+	return queue[len(queue)-1]
+}
+
+// framesInFlight returns the number of frames rendered at once, as many as fit in the memory budget,
+// but no more than are needed to keep the tile pool's workers busy
+func framesInFlight(ip ImagePreset) int {
+	frameBytes := float64(framePixelBytes*ip.width*ip.height + frameOverheadBytes)
+	fit := int(math.Floor(frameMemoryFraction * float64(memoryBudget()) / frameBytes))
+	return max(1, min(fit, max(minFramesPerTileWork, runtime.GOMAXPROCS(0))))
+}
+
+// memoryBudget returns the memory limit set with GOMEMLIMIT, or otherwise the memory available on the machine
+func memoryBudget() int64 {
+	if limit := debug.SetMemoryLimit(-1); limit < math.MaxInt64 {
+		return limit
+	}
+	if available, err := availableMemory(); err == nil {
+		return available
+	}
+	return defaultMemoryBudget
+}
+
+// availableMemory returns the memory available for new processes on Linux, without swapping
+func availableMemory() (int64, error) {
+	f, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var kb int64
+		if _, err := fmt.Sscanf(scanner.Text(), "MemAvailable: %d kB", &kb); err == nil {
+			return kb << 10, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	return 0, fmt.Errorf("no MemAvailable in /proc/meminfo")
+}
@@ -0,0 +1,93 @@
+package renderer
+
+import (
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestTilePoolRunsAllTasks(t *testing.T) {
+	p := newTilePool(3)
+	var wg sync.WaitGroup
+	counts := make([]atomic.Int64, 5)
+	// batches of several frames share the pool, each waiting for only its own tasks
+	for batch := range counts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tasks := make([]func(), 100)
+			for i := range tasks {
+				tasks[i] = func() {
+					counts[batch].Add(1)
+				}
+			}
+			p.run(tasks)
+			if got := counts[batch].Load(); got != 100 {
+				t.Errorf("batch %d ran %d tasks before returning, want 100", batch, got)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestTilePoolRecoversPanics(t *testing.T) {
+	p := newTilePool(2)
+	var ran atomic.Int64
+	tasks := make([]func(), 10)
+	for i := range tasks {
+		tasks[i] = func() {
+			ran.Add(1)
+			if i == 3 {
+				panic("bad task")
+			}
+		}
+	}
+	defer func() {
+		p := recover()
+		if p == nil {
+			t.Fatalf("run() didn't panic")
+		}
+		if err, ok := p.(error); !ok || !strings.HasPrefix(err.Error(), "bad task\n") {
+			t.Errorf("run() panicked with %v, want the panic of the task", p)
+		}
+		if got := ran.Load(); got != 10 {
+			t.Errorf("%d tasks ran, want all 10", got)
+		}
+	}()
+	p.run(tasks)
+}
+
+func TestTilePoolStealsTasks(t *testing.T) {
+	p := &tilePool{queues: make([][]func(), 3)}
+	a, b, c := func() {}, func() {}, func() {}
+	p.queues[1] = []func(){a, b, c}
+	// the worker without tasks steals from the back of the other queue, the owner takes from the front
+	if task := p.take(0); task == nil {
+		t.Fatalf("worker 0 didn't steal a task")
+	}
+	if len(p.queues[1]) != 2 {
+		t.Errorf("queue 1 has %d tasks after a steal, want 2", len(p.queues[1]))
+	}
+	if task := p.take(1); task == nil {
+		t.Fatalf("worker 1 didn't take its own task")
+	}
+	if task := p.take(2); task == nil {
+		t.Fatalf("worker 2 didn't steal the last task")
+	}
+	if task := p.take(2); task != nil {
+		t.Errorf("worker 2 took a task from empty queues")
+	}
+}
+
+func TestFramesInFlight(t *testing.T) {
+	small := framesInFlight(ImagePreset{width: 10, height: 10})
+	if small < 1 || small > max(minFramesPerTileWork, runtime.GOMAXPROCS(0)) {
+		t.Errorf("framesInFlight() = %d for a small image, want between 1 and the number of workers", small)
+	}
+	// an image far larger than the memory of any machine still renders one frame at a time
+	if huge := framesInFlight(ImagePreset{width: 1 << 20, height: 1 << 20}); huge != 1 {
+		t.Errorf("framesInFlight() = %d for a huge image, want 1", huge)
+	}
+}
@@ -6,16 +6,43 @@ import (
 	"strings"
 )
 
+const (
+	defaultRayDepth = 3
+)
+
+// Backend selects how the objects visible at each pixel are found
+type Backend int
+
+const (
+	BackendRaycast Backend = iota // cast a ray through each pixel sample
+	BackendRaster                 // rasterize triangles into a depth buffer, only casting rays for other objects
+)
+
 type ImagePreset struct {
 	width        int
 	height       int
 	interpolateN int
+	rayDepth     int // number of bounces for reflected and refracted rays
+	backend      Backend
+	sampler      Sampler     // where within each pixel the interpolateN samples are taken
+	filter       Filter      // how samples are combined into pixel colors
+	adaptive     float64     // if positive, pixels whose samples disagree by more than this get more samples
+	frameFormat  FrameFormat // file format of images, and of video frames written to .tmp/
 }
 
 type VideoPreset struct {
 	ImagePreset
-	nFrameCount int
-	frameRate   float64
+	nFrameCount  int
+	frameRate    float64
+	shutterAngle float64 // fraction of the frame interval that the shutter is open for, in degrees. 0 disables motion blur
+	pngFrames    bool    // write frames as PNG files in .tmp/ before encoding them, instead of piping them into ffmpeg
+	sceneName    string  // name of the scene, identifying the render in the manifest of frames in .tmp/
+	resume       bool    // only render the frames missing from .tmp/, if its manifest is of the same render
+
+	// options for GIF and APNG animations
+	loopCount       int  // number of times the animation is played, 0 loops forever
+	perFramePalette bool // quantize each GIF frame to its own palette, rather than one shared by all frames
+	dither          bool // apply Floyd-Steinberg dithering when quantizing GIF frames
 }
 
 type Pixel struct {
@@ -28,21 +55,38 @@ var (
 		width:        200,
 		height:       200,
 		interpolateN: 1,
+		rayDepth:     2,
 	}
 	ImagePresetIntermediate = ImagePreset{
 		width:        500,
 		height:       500,
 		interpolateN: 1,
+		rayDepth:     defaultRayDepth,
 	}
 	ImagePresetHiDef = ImagePreset{
 		width:        1000,
 		height:       1000,
 		interpolateN: 16,
+		rayDepth:     5,
 	}
 	ImagePresetIPhone = ImagePreset{
 		width:        1170,
 		height:       1170,
 		interpolateN: 9,
+		rayDepth:     5,
+	}
+	// 16:9 and 9:16 presets, the scene's camera takes its aspect ratio from the image so they are not stretched
+	ImagePresetWidescreen = ImagePreset{
+		width:        1280,
+		height:       720,
+		interpolateN: 4,
+		rayDepth:     defaultRayDepth,
+	}
+	ImagePresetVertical = ImagePreset{
+		width:        720,
+		height:       1280,
+		interpolateN: 4,
+		rayDepth:     defaultRayDepth,
 	}
 	VideoPresetTest = VideoPreset{
 		ImagePreset: ImagePresetTest,
@@ -65,10 +109,132 @@ var (
 		frameRate:   30,
 	}
 
+	VideoPresetWidescreen = VideoPreset{
+		ImagePreset: ImagePresetWidescreen,
+		nFrameCount: 300,
+		frameRate:   30,
+	}
+	VideoPresetVertical = VideoPreset{
+		ImagePreset: ImagePresetVertical,
+		nFrameCount: 300,
+		frameRate:   30,
+	}
+
 	defaultImagePreset = ImagePresetTest
 	defaultVideoPreset = VideoPresetTest
 )
 
+// WithRayDepth returns a copy of the preset, following reflected and refracted rays for up to depth bounces
+func (ip ImagePreset) WithRayDepth(depth int) ImagePreset {
+	ip.rayDepth = depth
+	return ip
+}
+
+// WithBackend returns a copy of the preset, rendered with the given backend
+func (ip ImagePreset) WithBackend(backend Backend) ImagePreset {
+	ip.backend = backend
+	return ip
+}
+
+// WithShutterAngle returns a copy of the preset, with the shutter open for angle degrees of each frame.
+// Each frame then averages the scene over that part of the interval until the next frame, sampling each
+// of the interpolateN pixel offsets at its own time.
+func (vp VideoPreset) WithShutterAngle(angle float64) VideoPreset {
+	vp.shutterAngle = angle
+	return vp
+}
+
+// WithPNGFrames returns a copy of the preset, which writes each frame to a PNG file in .tmp/ and encodes
+// the directory once all frames are rendered, rather than streaming them into ffmpeg
+func (vp VideoPreset) WithPNGFrames(pngFrames bool) VideoPreset {
+	vp.pngFrames = pngFrames
+	return vp
+}
+
+// WithResume returns a copy of the preset, whose frames written to .tmp/ are recorded in a manifest for the named scene.
+// If resume is set, frames that the manifest lists as complete are kept, rather than rendered again.
+func (vp VideoPreset) WithResume(sceneName string, resume bool) VideoPreset {
+	vp.sceneName = sceneName
+	vp.resume = resume
+	return vp
+}
+
+// allFrames returns the indices of all frames of the video
+func (vp VideoPreset) allFrames() []int {
+	return sampleRange(0, vp.nFrameCount)
+}
+
+// WithLoopCount returns a copy of the preset, whose animations are played count times, or forever if count is 0
+func (vp VideoPreset) WithLoopCount(count int) VideoPreset {
+	vp.loopCount = count
+	return vp
+}
+
+// WithPalette returns a copy of the preset, which quantizes GIF frames to a palette per frame if perFrame is set,
+// rather than to a global one, dithering them if dither is set
+func (vp VideoPreset) WithPalette(perFrame, dither bool) VideoPreset {
+	vp.perFramePalette = perFrame
+	vp.dither = dither
+	return vp
+}
+
+// exposure returns the length of time the shutter is open for, in the units of t passed to DynamicScene.GetFrame
+func (vp VideoPreset) exposure() float64 {
+	if vp.nFrameCount < 2 {
+		return 0
+	}
+	return vp.shutterAngle / 360 / float64(vp.nFrameCount-1)
+}
+
+// WithSampler returns a copy of the preset, with samples placed within pixels by the sampler
+func (ip ImagePreset) WithSampler(sampler Sampler) ImagePreset {
+	ip.sampler = sampler
+	return ip
+}
+
+// WithFilter returns a copy of the preset, reconstructing pixel colors with the filter
+func (ip ImagePreset) WithFilter(filter Filter) ImagePreset {
+	ip.filter = filter
+	return ip
+}
+
+// WithAdaptive returns a copy of the preset, which adds samples to pixels whose samples, or whose neighbors,
+// differ by more than threshold in any color channel, up to adaptiveSampleFactor times as many
+func (ip ImagePreset) WithAdaptive(threshold float64) ImagePreset {
+	ip.adaptive = threshold
+	return ip
+}
+
+// WithFrameFormat returns a copy of the preset, whose images and video frames are written in the format
+func (ip ImagePreset) WithFrameFormat(format FrameFormat) ImagePreset {
+	ip.frameFormat = format
+	return ip
+}
+
+// sampleCount returns the number of samples taken in every pixel
+func (ip ImagePreset) sampleCount() int {
+	return max(1, ip.interpolateN)
+}
+
+// maxSampleCount returns the number of samples taken in pixels that are refined by adaptive sampling
+func (ip ImagePreset) maxSampleCount() int {
+	if ip.adaptive > 0 {
+		return adaptiveSampleFactor * ip.sampleCount()
+	}
+	return ip.sampleCount()
+}
+
+func ParseBackend(flagVal string) (Backend, error) {
+	switch flagVal {
+	case "raycast":
+		return BackendRaycast, nil
+	case "raster":
+		return BackendRaster, nil
+	default:
+		return BackendRaycast, fmt.Errorf("could not parse backend '%s', expect raycast or raster", flagVal)
+	}
+}
+
 func ParseImagePreset(flagVal string) (ImagePreset, error) {
 	if strings.Contains(flagVal, ",") {
 		chunks := strings.Split(flagVal, ",")
@@ -88,6 +254,7 @@ func ParseImagePreset(flagVal string) (ImagePreset, error) {
 			width:        width,
 			height:       height,
 			interpolateN: interpolate,
+			rayDepth:     defaultRayDepth,
 		}, nil
 	}
 	switch flagVal {
@@ -101,6 +268,10 @@ func ParseImagePreset(flagVal string) (ImagePreset, error) {
 		return ImagePresetHiDef, nil
 	case "iphone":
 		return ImagePresetIPhone, nil
+	case "widescreen":
+		return ImagePresetWidescreen, nil
+	case "vertical":
+		return ImagePresetVertical, nil
 	default:
 		return ImagePreset{}, fmt.Errorf("could not parse image format '%s'", flagVal)
 	}
@@ -123,9 +294,10 @@ func ParseVideoPreset(flagVal string) (VideoPreset, error) {
 		width, height, interpolate, frames, frameRate := intChunks[0], intChunks[1], intChunks[2], intChunks[3], intChunks[4]
 		return VideoPreset{
 			ImagePreset: ImagePreset{
-				width,
-				height,
-				interpolate,
+				width:        width,
+				height:       height,
+				interpolateN: interpolate,
+				rayDepth:     defaultRayDepth,
 			},
 			nFrameCount: frames,
 			frameRate:   float64(frameRate),
@@ -142,6 +314,10 @@ func ParseVideoPreset(flagVal string) (VideoPreset, error) {
 		return VideoPresetHiDef, nil
 	case "iphone":
 		return VideoPresetIPhone, nil
+	case "widescreen":
+		return VideoPresetWidescreen, nil
+	case "vertical":
+		return VideoPresetVertical, nil
 	default:
 		return VideoPreset{}, fmt.Errorf("could not parse video format '%s'", flagVal)
 	}
@@ -0,0 +1,296 @@
+package renderer
+
+import (
+	"math"
+
+	"github.com/libeks/go-scene-renderer/colors"
+	"github.com/libeks/go-scene-renderer/geometry"
+	"github.com/libeks/go-scene-renderer/objects"
+	"github.com/libeks/go-scene-renderer/textures"
+)
+
+// fragment is a visible point of a triangle at a pixel sample, found by rasterization
+type fragment struct {
+	depth float64
+	b     float64 // triangle-local coordinates, see objects.Triangle.TextureCoords for the texture coordinates
+	c     float64
+	obj   int // index of the triangle, -1 if no triangle covers the sample
+	color colors.AlphaColor
+}
+
+// rasterBuffer holds the fragments of every pixel sample
+type rasterBuffer struct {
+	opaque      []fragment   // closest opaque fragment, the depth buffer
+	translucent [][]fragment // semi-transparent fragments in front of the opaque one, in no particular order
+}
+
+func newRasterBuffer(ip ImagePreset) rasterBuffer {
+	return rasterBuffer{
+		opaque:      make([]fragment, ip.width*ip.height),
+		translucent: make([][]fragment, ip.width*ip.height),
+	}
+}
+
+func (buf rasterBuffer) clear() {
+	for i := range buf.opaque {
+		buf.opaque[i] = fragment{depth: math.Inf(1), obj: -1}
+		buf.translucent[i] = buf.translucent[i][:0]
+	}
+}
+
+// rasterVertex is a triangle vertex in camera space, along with its triangle-local (b,c) coordinates
+type rasterVertex struct {
+	p    geometry.Point
+	b, c float64
+}
+
+// projectedVertex is a vertex in pixel space, with the attributes needed for perspective-correct interpolation
+type projectedVertex struct {
+	x, y float64
+	invW float64 // 1/w, where w is the z-depth for perspective cameras, and 1 for orthographic ones
+	zW   float64 // zDepth/w
+	bW   float64 // b/w
+	cW   float64 // c/w
+}
+
+// rasterizeSamples renders the samples by rasterizing the scene's triangles into a depth buffer,
+// once per sample. Other objects, like spheres, are ray cast and depth tested against
+// the rasterized triangles. Semi-transparent fragments are kept alongside the depth buffer, and all hits
+// are then shaded and blended front to back, the same way as in castSamples, a row at a time on the tile pool.
+func (r Renderer) rasterizeSamples(frame flatFrame, ip ImagePreset, samples []int, mask []bool, buf *sampleBuffer) {
+	tracer := frame.tracer
+	camera := tracer.camera
+	triangles := []objects.StaticBasicObject{}
+	analytic := []objects.StaticBasicObject{}
+	for _, obj := range frame.objs {
+		if _, ok := obj.BasicObject.(*objects.Triangle); ok {
+			triangles = append(triangles, obj)
+		} else {
+			analytic = append(analytic, obj)
+		}
+	}
+	analyticObjects := objects.NewBVH(analytic)
+
+	raster := newRasterBuffer(ip)
+	offsets := make([]Offset, ip.width*ip.height)
+	for _, i := range samples {
+		raster.clear()
+		for y := range ip.height {
+			for x := range ip.width {
+				offsets[y*ip.width+x] = r.offset(ip, x, y, i)
+			}
+		}
+		lens := r.offsets[i].lens // all pixels share the lens sample, so that triangles are projected once
+		for j, tri := range triangles {
+			rasterizeTriangle(raster, j, tri, camera, lens, offsets, mask, ip)
+		}
+		// shading casts rays for the lights and secondary bounces, so rows are shaded concurrently on the tile pool
+		tasks := make([]func(), ip.height)
+		for y := range tasks {
+			tasks[y] = func() {
+				var rowSamples []pixelSample
+				for x := range ip.width {
+					pixel := y*ip.width + x
+					if mask != nil && !mask[pixel] {
+						continue
+					}
+					offset := offsets[pixel]
+					xR, yR := getImageSpace(x, ip.width)+offset.dx, getImageSpace(y, ip.height)+offset.dy
+					ray := tracer.primaryRay(xR, yR, lens)
+					hits := analyticObjects.VisibleHits(ray)
+					if frag := raster.opaque[pixel]; frag.obj >= 0 {
+						hits = append(hits, triangleHit(triangles[frag.obj], frag, ray))
+					}
+					for _, frag := range raster.translucent[pixel] {
+						hits = append(hits, triangleHit(triangles[frag.obj], frag, ray))
+					}
+					c, transmittance := tracer.composite(objects.FrontToBack(hits), ray, 0, false)
+					if transmittance > 0 {
+						c = c.AddUnclamped(tracer.primaryBackground(xR, yR).Scale(transmittance))
+					}
+					rowSamples = append(rowSamples, pixelSample{x, y, offset, c})
+				}
+				buf.addAll(rowSamples)
+			}
+		}
+		r.pool.run(tasks)
+	}
+	if mask != nil {
+		return
+	}
+	for range ip.height {
+		r.lineChannel <- chunkReport{
+			pixels: ip.width,
+		}
+	}
+}
+
+// triangleHit returns the hit of a rasterized fragment, as seen along the primary ray r
+func triangleHit(obj objects.StaticBasicObject, frag fragment, r geometry.Ray) objects.Hit {
+	tri := obj.BasicObject.(*objects.Triangle)
+	normal := tri.NormalAt(frag.b, frag.c)
+	return objects.Hit{
+		Color:    frag.color.Color,
+		Alpha:    frag.color.A,
+		Depth:    frag.depth,
+		T:        frag.depth, // primary rays have a z-component of -1, so the ray parameter is the z-depth
+		Point:    r.PointAt(frag.depth),
+		Normal:   normal,
+		Material: obj.Material,
+	}
+}
+
+// rasterizeTriangle writes the triangle into the buffer wherever it is closer than the opaque fragment
+// already there, and not transparent. Each pixel is tested at its own sample offset, and only pixels
+// in the mask are written, unless it is nil. The triangle is first clipped to be in front of the camera.
+func rasterizeTriangle(buf rasterBuffer, i int, obj objects.StaticBasicObject, camera geometry.Camera, lens geometry.Vector2D, offsets []Offset, mask []bool, ip ImagePreset) {
+	tri := obj.BasicObject.(*objects.Triangle)
+	polygon := clipToNearPlane([]rasterVertex{
+		{tri.A, 0, 0},
+		{tri.B, 1, 0},
+		{tri.C, 0, 1},
+	}, camera)
+	if len(polygon) < 3 {
+		return
+	}
+	projected := make([]projectedVertex, len(polygon))
+	for j, v := range polygon {
+		projected[j] = projectVertex(v, camera, lens, ip)
+	}
+	// the clipped polygon is convex, draw it as a fan of triangles
+	for j := 1; j+1 < len(projected); j++ {
+		rasterizeProjected(buf, i, obj, projected[0], projected[j], projected[j+1], offsets, mask, ip)
+	}
+}
+
+// clipToNearPlane clips the polygon to the part in front of the camera's near plane
+func clipToNearPlane(polygon []rasterVertex, camera geometry.Camera) []rasterVertex {
+	inFront := func(v rasterVertex) bool {
+		return camera.IsInFront(v.p)
+	}
+	ret := make([]rasterVertex, 0, len(polygon)+1)
+	for j, v := range polygon {
+		next := polygon[(j+1)%len(polygon)]
+		if inFront(v) {
+			ret = append(ret, v)
+		}
+		if inFront(v) != inFront(next) {
+			// the edge crosses the near plane, add the crossing point
+			ratio := (-camera.Near - v.p.Z) / (next.p.Z - v.p.Z)
+			ret = append(ret, rasterVertex{
+				p: geometry.Point(v.p.Vector().AddVector(next.p.Subtract(v.p).ScalarMultiply(ratio))),
+				b: v.b + (next.b-v.b)*ratio,
+				c: v.c + (next.c-v.c)*ratio,
+			})
+		}
+	}
+	return ret
+}
+
+// projectVertex maps the vertex to continuous pixel coordinates, where pixel (x,y) covers [x,x+1) by [y,y+1),
+// as seen through the point lens on the unit disc of the lens
+func projectVertex(v rasterVertex, camera geometry.Camera, lens geometry.Vector2D, ip ImagePreset) projectedVertex {
+	// the vertex has been clipped to be in front of the camera, so it always has a pixel
+	pixel, depth := camera.LensToPixel(v.p, lens)
+	w := depth
+	if camera.Orthographic {
+		// attributes are affine in screen space
+		w = 1
+	}
+	return projectedVertex{
+		x:    (pixel.X + 1) * float64(ip.width) / 2,
+		y:    (pixel.Y + 1) * float64(ip.height) / 2,
+		invW: 1 / w,
+		zW:   depth / w,
+		bW:   v.b / w,
+		cW:   v.c / w,
+	}
+}
+
+func edgeFunction(a, b projectedVertex, x, y float64) float64 {
+	return (b.x-a.x)*(y-a.y) - (b.y-a.y)*(x-a.x)
+}
+
+func rasterizeProjected(buf rasterBuffer, i int, obj objects.StaticBasicObject, v0, v1, v2 projectedVertex, offsets []Offset, mask []bool, ip ImagePreset) {
+	tri := obj.BasicObject.(*objects.Triangle)
+	area := edgeFunction(v0, v1, v2.x, v2.y)
+	if area == 0 {
+		// triangle is seen edge-on
+		return
+	}
+	// coordsAt returns the texture coordinates at (fx,fy), anywhere on the triangle's plane, false if
+	// the point is on the horizon or beyond it
+	coordsAt := func(fx, fy float64) (float64, float64, bool) {
+		l0 := edgeFunction(v1, v2, fx, fy) / area
+		l1 := edgeFunction(v2, v0, fx, fy) / area
+		l2 := edgeFunction(v0, v1, fx, fy) / area
+		invW := l0*v0.invW + l1*v1.invW + l2*v2.invW
+		if invW <= 0 {
+			return 0, 0, false
+		}
+		u, v := tri.TextureCoords((l0*v0.bW+l1*v1.bW+l2*v2.bW)/invW, (l0*v0.cW+l1*v1.cW+l2*v2.cW)/invW)
+		return u, v, true
+	}
+	// coordsChange returns the change in texture coordinates from (u,v) at (fx,fy) to the next pixel along
+	// (dx,dy), or from the previous one, whichever is smaller, the same as the footprints of the ray caster.
+	// Pixels beyond the horizon are skipped.
+	coordsChange := func(u, v, fx, fy, dx, dy float64) (float64, float64) {
+		du, dv := 0.0, 0.0
+		found := false
+		for _, sign := range []float64{1, -1} {
+			nu, nv, ok := coordsAt(fx+sign*dx, fy+sign*dy)
+			if !ok {
+				continue
+			}
+			nu, nv = sign*(nu-u), sign*(nv-v)
+			if !found || nu*nu+nv*nv < du*du+dv*dv {
+				du, dv = nu, nv
+				found = true
+			}
+		}
+		return du, dv
+	}
+	// samples lie within their pixel, so only pixels overlapping the triangle's bounds can be covered
+	xMin := max(0, int(math.Floor(min(v0.x, v1.x, v2.x))))
+	xMax := min(ip.width-1, int(math.Floor(max(v0.x, v1.x, v2.x))))
+	yMin := max(0, int(math.Floor(min(v0.y, v1.y, v2.y))))
+	yMax := min(ip.height-1, int(math.Floor(max(v0.y, v1.y, v2.y))))
+	wiggleX, wiggleY := getPixelWiggle(ip.width), getPixelWiggle(ip.height)
+	for y := yMin; y <= yMax; y++ {
+		for x := xMin; x <= xMax; x++ {
+			pixel := y*ip.width + x
+			if mask != nil && !mask[pixel] {
+				continue
+			}
+			fx, fy := float64(x)+offsets[pixel].dx/wiggleX, float64(y)+offsets[pixel].dy/wiggleY
+			l0 := edgeFunction(v1, v2, fx, fy) / area
+			l1 := edgeFunction(v2, v0, fx, fy) / area
+			l2 := edgeFunction(v0, v1, fx, fy) / area
+			if l0 < 0 || l1 < 0 || l2 < 0 {
+				continue
+			}
+			// interpolate attributes divided by w linearly in screen space, which is perspective-correct
+			invW := l0*v0.invW + l1*v1.invW + l2*v2.invW
+			depth := (l0*v0.zW + l1*v1.zW + l2*v2.zW) / invW
+			if depth >= buf.opaque[pixel].depth {
+				continue
+			}
+			b := (l0*v0.bW + l1*v1.bW + l2*v2.bW) / invW
+			c := (l0*v0.cW + l1*v1.cW + l2*v2.cW) / invW
+			u, v := tri.TextureCoords(b, c)
+			var f geometry.Footprint
+			f.DUDX, f.DVDX = coordsChange(u, v, fx, fy, 1, 0)
+			f.DUDY, f.DVDY = coordsChange(u, v, fx, fy, 0, 1)
+			color := textures.FilteredTransparentColor(obj.Colorer, u, v, f)
+			if color.IsTransparent() {
+				continue
+			}
+			frag := fragment{depth, b, c, i, color}
+			if color.IsOpaque() {
+				buf.opaque[pixel] = frag
+			} else {
+				buf.translucent[pixel] = append(buf.translucent[pixel], frag)
+			}
+		}
+	}
+}
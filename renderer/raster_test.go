@@ -0,0 +1,96 @@
+package renderer
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/libeks/go-scene-renderer/colors"
+	"github.com/libeks/go-scene-renderer/geometry"
+	"github.com/libeks/go-scene-renderer/scenes"
+	"github.com/libeks/go-scene-renderer/textures"
+)
+
+func TestRasterizedImageMatchesWindowed(t *testing.T) {
+	background := scenes.BackgroundFromTexture(textures.StaticTexture(textures.Uniform(colors.Black)))
+	tests := []struct {
+		name        string
+		scene       scenes.DynamicScene
+		width       int
+		height      int
+		interpolate int
+	}{
+		{"height map", scenes.HeightMap(background), 64, 64, 1},
+		{"spheres and triangles", scenes.ThreeSpheres(background), 64, 64, 1},
+		{"checkerboard with hole", scenes.CheckerboardSquareWithRoundHole(background), 64, 64, 1},
+		{"widescreen narrow fov", scenes.HeightMap(background).WithCamera(geometry.Camera{FOV: 1}), 96, 54, 1},
+		{"vertical orthographic", scenes.ThreeSpheres(background).WithCamera(geometry.Camera{Orthographic: true, Height: 3}), 54, 96, 1},
+		{"depth of field", scenes.FocusPull(background), 64, 48, 4},
+	}
+	// pixels along triangle edges may be assigned to either neighbor, so allow a small fraction to differ
+	maxDifferentRatio := 0.02
+	maxChannelDelta := 8
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ip := ImagePreset{width: tt.width, height: tt.height, interpolateN: tt.interpolate, rayDepth: defaultRayDepth}
+			compareBackends(t, tt.scene.GetFrame(0.3), ip, maxDifferentRatio, maxChannelDelta)
+		})
+	}
+}
+
+func TestRasterizedSamplesMatchCast(t *testing.T) {
+	background := scenes.BackgroundFromTexture(textures.StaticTexture(textures.Uniform(colors.Black)))
+	frame := scenes.ThreeSpheres(background).GetFrame(0.3)
+	base := ImagePreset{width: 48, height: 48, interpolateN: 4, rayDepth: defaultRayDepth}
+	tests := []struct {
+		name string
+		ip   ImagePreset
+	}{
+		{"stratified", base.WithSampler(SamplerStratified)},
+		{"halton", base.WithSampler(SamplerHalton)},
+		{"sobol with tent filter", base.WithSampler(SamplerSobol).WithFilter(FilterTent)},
+		{"stratified with mitchell filter", base.WithSampler(SamplerStratified).WithFilter(FilterMitchell)},
+		{"adaptive", base.WithSampler(SamplerSobol).WithAdaptive(0.05)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// adaptive sampling may refine different pixels along edges, so allow a few more to differ
+			compareBackends(t, frame, tt.ip, 0.04, 8)
+		})
+	}
+}
+
+// compareBackends checks that the scene renders the same with the raycast and the raster backends
+func compareBackends(t *testing.T, frame scenes.StaticScene, ip ImagePreset, maxDifferentRatio float64, maxChannelDelta int) {
+	t.Helper()
+	r := newRenderer(ip)
+	go func() {
+		for range r.lineChannel {
+		}
+	}()
+	defer close(r.lineChannel)
+	want := r.getImage(frame, ip.WithBackend(BackendRaycast)).GetImage()
+	got := r.getImage(frame, ip.WithBackend(BackendRaster)).GetImage()
+
+	different := 0
+	for y := range ip.height {
+		for x := range ip.width {
+			if !colorsClose(want.At(x, y), got.At(x, y), maxChannelDelta) {
+				different += 1
+			}
+		}
+	}
+	if ratio := float64(different) / float64(ip.width*ip.height); ratio > maxDifferentRatio {
+		t.Errorf("%d of %d pixels differ between the backends, want at most %.1f%%", different, ip.width*ip.height, maxDifferentRatio*100)
+	}
+}
+
+func colorsClose(a, b color.Color, delta int) bool {
+	ar, ag, ab, _ := a.RGBA()
+	br, bg, bb, _ := b.RGBA()
+	within := func(x, y uint32) bool {
+		d := int(x>>8) - int(y>>8)
+		return -delta <= d && d <= delta
+	}
+	return within(ar, br) && within(ag, bg) && within(ab, bb)
+}
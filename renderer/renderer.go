@@ -2,12 +2,16 @@ package renderer
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"image/png"
+	"io"
+	"math"
 	"math/rand"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime/debug"
+	"sync"
 	"time"
 
 	"github.com/libeks/go-scene-renderer/colors"
@@ -19,13 +23,15 @@ import (
 )
 
 const (
-	frameConcurrency       = 10   // should depend on video preset. Too many and you'll operate close to full memory, slowing rendering down.
 	generateVideoPNGs      = true // set to false to debug ffmpeg settings without recreating image files (files have to exist in .tmp/)
 	minWindowWidth         = 3
 	minWindowCount         = 1
 	wireframeTriangleDepth = false
 	applyWireframe         = false // draw wireframes on top of rendered objects
 	render_h265            = false // if false, will render with h264
+	castShadows            = true  // cast shadow rays towards lights, only applies to scenes with lights
+	useBVH                 = true  // find primary ray hits in the scene's BVH, rather than checking each triangle in the window
+	bvhTileSize            = 32    // width and height in pixels of the tiles rendered concurrently, when primary rays use the BVH
 )
 
 var (
@@ -44,6 +50,39 @@ type chunkReport struct {
 	trianglesInWindow int
 }
 
+// imageStats sums up the reports of the windows of an image, which may be rendered in several calls of castSamples,
+// so that they are printed once per image
+type imageStats struct {
+	pixels    int // pixels of all windows, counted once for each call that rendered them
+	triangles int // triangles of the window of each of those pixels
+	checks    int
+	bvh       bool // primary hits were found in the BVH, whose node visits are the checks
+}
+
+func (s imageStats) add(o imageStats) imageStats {
+	return imageStats{s.pixels + o.pixels, s.triangles + o.triangles, s.checks + o.checks, s.bvh || o.bvh}
+}
+
+func (s imageStats) print(ip ImagePreset) {
+	if s.pixels == 0 {
+		// rasterized, or not rendered at all
+		return
+	}
+	imagePixels := ip.width * ip.height
+	if s.bvh {
+		fmt.Printf("Image had %d pixels, %.3f BVH nodes visited per pixel\n",
+			imagePixels,
+			float64(s.checks)/float64(imagePixels),
+		)
+		return
+	}
+	fmt.Printf("Image had %d pixels, %.3f triangles per pixel, %.3f checks per pixel\n",
+		imagePixels,
+		float64(s.triangles)/float64(s.pixels),
+		float64(s.checks)/float64(imagePixels),
+	)
+}
+
 // Renderer does two things - tracks progress of per-frame goroutines, and updates
 // a progress bar based on the number of image rows that have been rendered so far
 type Renderer struct {
@@ -51,85 +90,101 @@ type Renderer struct {
 	fileChannel chan fileReport  // each file completion is sent on fileChannel
 	doneChannel chan struct{}    // doneChannel sends a message when all frames are rendered
 	offsets     []Offset
+	pool        *tilePool // runs the windows of the frames, shared between renderers
 }
 
 func newRenderer(ip ImagePreset) Renderer {
-	offsets := make([]Offset, ip.interpolateN)
+	n, total := ip.sampleCount(), ip.maxSampleCount()
+	// a single sample is at the pixel's corner, through the center of the lens, at the start of the exposure
+	offsets := make([]Offset, total)
 	dx, dy := getPixelWiggle(ip.width), getPixelWiggle(ip.height)
-	for i := range ip.interpolateN {
-		offsets[i] = Offset{rand.Float64() * dx, rand.Float64() * dy}
+	for i := range total {
+		if total == 1 {
+			break
+		}
+		// uniformly distributed on the lens disc
+		r, theta := math.Sqrt(rand.Float64()), rand.Float64()*maths.Rotation
+		lens := geometry.Vector2D{X: r * math.Cos(theta), Y: r * math.Sin(theta)}
+		// sample times are stratified, so that each part of the exposure is covered,
+		// both by the samples of every pixel, and by the extra ones of adaptively refined pixels
+		dt := (float64(i) + rand.Float64()) / float64(n)
+		if i >= n {
+			dt = (float64(i-n) + rand.Float64()) / float64(total-n)
+		}
+		offsets[i] = Offset{rand.Float64() * dx, rand.Float64() * dy, lens, dt}
 	}
 	return Renderer{
 		lineChannel: make(chan chunkReport, 10),
 		fileChannel: make(chan fileReport, 10),
 		doneChannel: make(chan struct{}, 1),
 		offsets:     offsets,
+		pool:        sharedTilePool(),
 	}
 }
 
 func RenderVideo(scene scenes.DynamicScene, vp VideoPreset, outFile string, wireframe bool, triDepth bool) error {
+	if vp.pngFrames {
+		return renderVideoFromPNGs(scene, vp, outFile, wireframe, triDepth)
+	}
 	start := time.Now()
-	// clean up frames in temp directory before starting
-	tmpDirectory := ".tmp"
-	fileWildcardPattern := filepath.Join(".", tmpDirectory, "frame_*.png")
-	outFileFormat := filepath.Join(tmpDirectory, "frame_%03d.png")
+	encoder, err := newFrameEncoder(vp, outFile)
+	if err != nil {
+		return err
+	}
+	r := newRenderer(vp.ImagePreset)
+	if err := r.renderFrames(scene, vp, vp.allFrames(), wireframe, triDepth, func(i int, frame *Image) error {
+		// frames that finish out of order wait here, holding on to their semaphore slot
+		return encoder.addFrame(i, frame)
+	}, encoder.abort); err != nil {
+		encoder.close()
+		return err
+	}
+	fmt.Printf("Encoding remaining frames with ffmpeg...\n")
+	if err := encoder.close(); err != nil {
+		return err
+	}
+	fmt.Printf("\nVideo generation took %s\n", time.Since(start))
+	return nil
+}
+
+// renderVideoFromPNGs renders each frame to a file in .tmp/, in the preset's frame format, and then encodes them
+// into the video with ffmpeg. Complete frames are recorded in a manifest, so that a render that is resumed
+// only renders the frames that are missing.
+func renderVideoFromPNGs(scene scenes.DynamicScene, vp VideoPreset, outFile string, wireframe bool, triDepth bool) error {
+	start := time.Now()
+	fileWildcardPattern, outFileFormat, manifestPath := frameDirectoryPaths(vp)
 	if generateVideoPNGs {
 		fmt.Printf("Preparing setup...\n")
-		if err := cleanUpTempFiles(fileWildcardPattern); err != nil {
-			return err
-		}
-		if err := createSubdirectories(outFileFormat); err != nil {
+		manifest, frames, err := prepareFrameDirectory(vp, fileWildcardPattern, outFileFormat, manifestPath)
+		if err != nil {
 			return err
 		}
 		r := newRenderer(vp.ImagePreset)
-		var sem = semaphore.NewWeighted(int64(frameConcurrency))
-		go r.progressbar(vp.nFrameCount, vp.nFrameCount*vp.width*vp.height) // start progressbar before launching goroutines to not deadlock
-
-		fmt.Printf("Rendering frames...\n")
-		for i := range vp.nFrameCount {
-			if err := sem.Acquire(context.Background(), 1); err != nil {
-				return err
-			}
-			go func() {
-				outFile := fmt.Sprintf(outFileFormat, i)
-				f, err := os.OpenFile(outFile, os.O_WRONLY|os.O_CREATE, 0600)
-				if err != nil {
-					panic(err)
-				}
-				defer f.Close()
-				t := float64(i) / float64(vp.nFrameCount-1) // range [0.0, 1.0]
-				frameObj := scene.GetFrame(t)
-				var frame *Image
-				if wireframe {
-					if wireframeTriangleDepth {
-						frame = r.getTriangleDepthImage(frameObj, vp.ImagePreset)
-					} else {
-						frame = r.getWireframeImage(frameObj, vp.ImagePreset)
-					}
-
-				} else if triDepth {
-					frame = r.getTriangleDepthImage(frameObj, vp.ImagePreset)
-				} else {
-					frame = r.getWindowedImage(frameObj, vp.ImagePreset)
-					if applyWireframe {
-						frame = r.applyWireframeToImage(frame, frameObj, vp.ImagePreset)
-					}
-				}
-				err = png.Encode(f, frame.GetImage())
-				if err != nil {
-					panic(err)
-				}
-				sem.Release(1)
-				r.fileChannel <- fileReport{
-					frameID: i,
-				}
-
-			}()
+		if err := r.renderFrames(scene, vp, frames, wireframe, triDepth, func(i int, frame *Image) error {
+			return manifest.writeFrame(i, outFileFormat, func(w io.Writer) error {
+				return encodeFrame(w, frame, vp.frameFormat)
+			})
+		}, nil); err != nil {
+			return fmt.Errorf("%w\nrerun with -resume to render the remaining frames", err)
 		}
-		r.wait() // block until completion
 
 		fmt.Printf("\nPNG frame generation took %s\n", time.Since(start))
 	}
+	return encodeFrameDirectory(vp, fileWildcardPattern, outFileFormat, outFile)
+}
+
+// frameDirectoryPaths returns the pattern matching all frame files in .tmp/, the format of each frame's file name,
+// and the path of the manifest of complete frames
+func frameDirectoryPaths(vp VideoPreset) (fileWildcardPattern, outFileFormat, manifestPath string) {
+	tmpDirectory := ".tmp"
+	fileWildcardPattern = filepath.Join(".", tmpDirectory, "frame_*."+vp.frameFormat.Extension())
+	outFileFormat = filepath.Join(tmpDirectory, "frame_%03d."+vp.frameFormat.Extension())
+	manifestPath = filepath.Join(tmpDirectory, "manifest.json")
+	return
+}
+
+// encodeFrameDirectory encodes the frame files in .tmp/ into the video with ffmpeg
+func encodeFrameDirectory(vp VideoPreset, fileWildcardPattern, outFileFormat, outFile string) error {
 	fmt.Printf("Encoding with ffmpeg...\n")
 	// render video file from png frame images in .tmp/
 	params := []string{
@@ -137,27 +192,7 @@ func RenderVideo(scene scenes.DynamicScene, vp VideoPreset, outFile string, wire
 		"-framerate", fmt.Sprintf("%.2f", vp.frameRate),
 		"-i", outFileFormat,
 	}
-	if render_h265 {
-		params = append(params,
-			"-c:v", "libx265",
-			// "-pix_fmt", "yu v420p",
-			"-pix_fmt", "yuv420p10le",
-			// "-profile:v", "main",
-			"-level", "3.1",
-			"-preset", "medium",
-			"-crf", "14",
-			"-tag:v", "hvc1",
-		)
-	} else {
-		params = append(params,
-			"-c:v", "libx264",
-			"-pix_fmt", "yuv420p",
-			"-profile:v", "main",
-			"-level", "3.1",
-			"-preset", "medium",
-			"-crf", "14",
-		)
-	}
+	params = append(params, encoderParams()...)
 	params = append(params, outFile)
 
 	// fmt.Printf("params %v\n", params)
@@ -176,6 +211,157 @@ func RenderVideo(scene scenes.DynamicScene, vp VideoPreset, outFile string, wire
 	return nil
 }
 
+// prepareFrameDirectory returns the manifest of the frames in .tmp/, and the frames that have to be rendered.
+// Unless the preset resumes a render with the same key, all earlier frames are removed.
+func prepareFrameDirectory(vp VideoPreset, fileWildcardPattern, outFileFormat, manifestPath string) (*frameManifest, []int, error) {
+	if err := createSubdirectories(outFileFormat); err != nil {
+		return nil, nil, err
+	}
+	key, err := newManifestKey(vp.sceneName, vp)
+	if err != nil {
+		return nil, nil, err
+	}
+	if vp.resume {
+		manifest, err := loadManifest(manifestPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		if manifest != nil && manifest.manifestKey == key {
+			frames := manifest.missingFrames(vp.nFrameCount, outFileFormat)
+			fmt.Printf("Resuming render, %d of %d frames remaining\n", len(frames), vp.nFrameCount)
+			return manifest, frames, nil
+		}
+		fmt.Printf("No manifest of an earlier render of this scene and preset, starting over\n")
+	}
+	// clean up frames in temp directory before starting
+	if err := cleanUpTempFiles(fileWildcardPattern); err != nil {
+		return nil, nil, err
+	}
+	manifest, err := newManifest(manifestPath, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return manifest, vp.allFrames(), nil
+}
+
+// renderFrames renders the frames of the video concurrently, passing each one to handle once it is done.
+// handle is called from several goroutines at once, in no particular frame order. Frames that panic while rendering,
+// or whose handle returns an error, are reported to failed, if set, and no further frames are started.
+// All of the errors are returned once the frames that were started are done.
+func (r Renderer) renderFrames(scene scenes.DynamicScene, vp VideoPreset, frames []int, wireframe bool, triDepth bool, handle func(i int, frame *Image) error, failed func(err error)) error {
+	// frames in flight are limited by memory, the windows within them keep all cores busy
+	var sem = semaphore.NewWeighted(int64(framesInFlight(vp.ImagePreset)))
+	go r.progressbar(len(frames), len(frames)*r.framePixels(vp)) // start progressbar before launching goroutines to not deadlock
+
+	var mu sync.Mutex
+	var errs []error
+	fmt.Printf("Rendering frames...\n")
+	for _, i := range frames {
+		if err := sem.Acquire(context.Background(), 1); err != nil {
+			return err
+		}
+		go func() {
+			defer func() {
+				sem.Release(1)
+				r.fileChannel <- fileReport{
+					frameID: i,
+				}
+			}()
+			mu.Lock()
+			stopped := len(errs) > 0
+			mu.Unlock()
+			if stopped {
+				return
+			}
+			err := r.renderFrameSafely(scene, i, vp, wireframe, triDepth, handle)
+			if err == nil {
+				return
+			}
+			mu.Lock()
+			errs = append(errs, err)
+			mu.Unlock()
+			if failed != nil {
+				failed(err)
+			}
+		}()
+	}
+	r.wait() // block until completion
+	return errors.Join(errs...)
+}
+
+// renderFrameSafely renders the i-th frame and passes it to handle, returning an error instead of panicking
+func (r Renderer) renderFrameSafely(scene scenes.DynamicScene, i int, vp VideoPreset, wireframe bool, triDepth bool, handle func(i int, frame *Image) error) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("frame %d panicked: %v\n%s", i, p, debug.Stack())
+		}
+	}()
+	frame := r.renderFrame(scene, i, vp, wireframe, triDepth)
+	if err := handle(i, frame); err != nil {
+		return fmt.Errorf("frame %d: %w", i, err)
+	}
+	return nil
+}
+
+// framePixels returns the number of pixels reported as progress while rendering each frame of the video
+func (r Renderer) framePixels(vp VideoPreset) int {
+	if vp.shutterAngle > 0 {
+		// with motion blur, the samples at each time within the exposure are rendered separately,
+		// reporting progress over the whole image
+		return vp.width * vp.height * len(r.shutterGroups(sampleRange(0, vp.sampleCount()), vp.exposure()))
+	}
+	return vp.width * vp.height
+}
+
+// renderFrame renders the i-th frame of the video
+func (r Renderer) renderFrame(scene scenes.DynamicScene, i int, vp VideoPreset, wireframe bool, triDepth bool) *Image {
+	t := float64(i) / float64(vp.nFrameCount-1) // range [0.0, 1.0]
+	frameObj := scene.GetFrame(t)
+	if wireframe {
+		if wireframeTriangleDepth {
+			return r.getTriangleDepthImage(frameObj, vp.ImagePreset)
+		}
+		return r.getWireframeImage(frameObj, vp.ImagePreset)
+	}
+	if triDepth {
+		return r.getTriangleDepthImage(frameObj, vp.ImagePreset)
+	}
+	var frame *Image
+	if vp.shutterAngle > 0 {
+		frame = r.getMotionBlurredImage(scene, t, vp.exposure(), vp.ImagePreset)
+	} else {
+		frame = r.getImage(frameObj, vp.ImagePreset)
+	}
+	if applyWireframe {
+		frame = r.applyWireframeToImage(frame, frameObj, vp.ImagePreset)
+	}
+	return frame
+}
+
+// encoderParams returns the ffmpeg output codec parameters
+func encoderParams() []string {
+	if render_h265 {
+		return []string{
+			"-c:v", "libx265",
+			// "-pix_fmt", "yu v420p",
+			"-pix_fmt", "yuv420p10le",
+			// "-profile:v", "main",
+			"-level", "3.1",
+			"-preset", "medium",
+			"-crf", "14",
+			"-tag:v", "hvc1",
+		}
+	}
+	return []string{
+		"-c:v", "libx264",
+		"-pix_fmt", "yuv420p",
+		"-profile:v", "main",
+		"-level", "3.1",
+		"-preset", "medium",
+		"-crf", "14",
+	}
+}
+
 func RenderPNG(scene scenes.StaticScene, im ImagePreset, outfile string, wireframe bool, triDepth bool) error {
 	f, err := os.OpenFile(outfile, os.O_WRONLY|os.O_CREATE, 0600)
 	if err != nil {
@@ -183,6 +369,7 @@ func RenderPNG(scene scenes.StaticScene, im ImagePreset, outfile string, wirefra
 	}
 	defer f.Close()
 	var frame *Image
+	var encodeErr error
 	r := newRenderer(im)
 	go r.progressbar(1, im.width) // block until completion
 	go func() {
@@ -195,18 +382,18 @@ func RenderPNG(scene scenes.StaticScene, im ImagePreset, outfile string, wirefra
 		} else if triDepth {
 			frame = r.getTriangleDepthImage(scene, im)
 		} else {
-			frame = r.getWindowedImage(scene, im)
+			frame = r.getImage(scene, im)
 			if applyWireframe {
 				frame = r.applyWireframeToImage(frame, scene, im)
 			}
 		}
-		png.Encode(f, frame.GetImage())
+		encodeErr = encodeFrame(f, frame, im.frameFormat)
 		r.fileChannel <- fileReport{
 			frameID: 0,
 		}
 	}()
 	r.wait()
-	return nil
+	return encodeErr
 }
 
 func (r Renderer) progressbar(nFiles, nPixels int) {
@@ -233,54 +420,164 @@ func (r Renderer) wait() {
 	fmt.Println("")
 }
 
+// Offset is a sample within a pixel, used for anti-aliasing and depth of field
 type Offset struct {
-	dx float64
-	dy float64
+	dx   float64
+	dy   float64
+	lens geometry.Vector2D // point on the unit disc of the camera's lens that the ray passes through
+	dt   float64           // fraction of the exposure at which the sample is taken, for motion blur
 }
 
-func (r Renderer) getWindowedImage(scene scenes.StaticScene, ip ImagePreset) *Image {
-	img := NewImage(ip)
-	windows := subdivideSceneIntoWindows(scene, ip)
-	var imageTriangles, imageChecks int
-	for _, window := range windows {
-		var nTriangles, windowChecks int
-		for x := window.xMin; x < window.xMax; x++ {
-			for y := window.yMin; y < window.yMax; y++ {
-				xR, yR := getImageSpace(x, ip.width), getImageSpace(y, ip.height)
-				var pixelColor colors.Color
-				if ip.interpolateN > 1 {
-					samples := make([]colors.Color, ip.interpolateN)
-					for i, offset := range r.offsets {
-						var nChecks int
-						samples[i], nTriangles, nChecks = window.GetColor(xR+offset.dx, yR+offset.dy)
-						windowChecks += nChecks
-					}
-					pixelColor = colors.Average(samples)
-				} else {
-					var nChecks int
-					pixelColor, nTriangles, nChecks = window.GetColor(xR, yR)
-					windowChecks += nChecks
-				}
+// getImage renders the scene with the backend chosen in the preset
+func (r Renderer) getImage(scene scenes.StaticScene, ip ImagePreset) *Image {
+	return newImageFromColors(r.getColors(scene, ip), ip)
+}
 
-				img.Set(x, y, pixelColor)
+// getColors renders the scene with the backend chosen in the preset, reconstructing each pixel from
+// the samples around it. The colors are in row-major order, starting with the bottom row.
+func (r Renderer) getColors(scene scenes.StaticScene, ip ImagePreset) []colors.Color {
+	frame := flattenFrame(scene, ip)
+	var stats imageStats
+	pixelColors := r.renderAdaptively(ip, func(samples []int, mask []bool, buf *sampleBuffer) {
+		stats = stats.add(r.renderSamples(frame, ip, samples, mask, buf))
+	})
+	stats.print(ip)
+	return pixelColors
+}
+
+// getMotionBlurredImage renders the frame of the scene at t, with each sample taken at its own time
+// within the exposure following t, so that samples are spread jointly over the pixel, the lens and time
+func (r Renderer) getMotionBlurredImage(scene scenes.DynamicScene, t, exposure float64, ip ImagePreset) *Image {
+	var stats imageStats
+	pixelColors := r.renderAdaptively(ip, func(samples []int, mask []bool, buf *sampleBuffer) {
+		// the frame at each time is flattened, and its BVH built, once for all samples taken at that time
+		for _, group := range r.shutterGroups(samples, exposure) {
+			frame := flattenFrame(scene.GetFrame(t+r.offsets[group[0]].dt*exposure), ip)
+			stats = stats.add(r.renderSamples(frame, ip, group, mask, buf))
+		}
+	})
+	stats.print(ip)
+	return newImageFromColors(pixelColors, ip)
+}
+
+// shutterGroups groups the samples by the time within the exposure that they are taken at, in the order
+// of their first sample
+func (r Renderer) shutterGroups(samples []int, exposure float64) [][]int {
+	groups := [][]int{}
+	indices := map[float64]int{}
+	for _, i := range samples {
+		offset := r.offsets[i].dt * exposure
+		j, ok := indices[offset]
+		if !ok {
+			j = len(groups)
+			indices[offset] = j
+			groups = append(groups, nil)
+		}
+		groups[j] = append(groups[j], i)
+	}
+	return groups
+}
+
+// renderAdaptively renders the samples that every pixel gets, then, if the preset samples adaptively,
+// the extra samples of the pixels whose samples disagree. render draws the samples of the pixels in the mask,
+// or of all pixels if the mask is nil, into the buffer.
+func (r Renderer) renderAdaptively(ip ImagePreset, render func(samples []int, mask []bool, buf *sampleBuffer)) []colors.Color {
+	buf := newSampleBuffer(ip)
+	render(sampleRange(0, ip.sampleCount()), nil, buf)
+	if ip.adaptive > 0 {
+		render(sampleRange(ip.sampleCount(), ip.maxSampleCount()), buf.disagreeing(ip.adaptive), buf)
+	}
+	return buf.colors()
+}
+
+func sampleRange(from, to int) []int {
+	samples := make([]int, 0, to-from)
+	for i := from; i < to; i++ {
+		samples = append(samples, i)
+	}
+	return samples
+}
+
+// offset returns the i-th sample of pixel (x,y), placed within the pixel by the preset's sampler
+func (r Renderer) offset(ip ImagePreset, x, y, i int) Offset {
+	offset := r.offsets[i]
+	if ip.maxSampleCount() == 1 || ip.sampler == SamplerRandom {
+		return offset
+	}
+	sx, sy := ip.sampler.position(x, y, i, ip.sampleCount())
+	offset.dx, offset.dy = sx*getPixelWiggle(ip.width), sy*getPixelWiggle(ip.height)
+	return offset
+}
+
+// renderSamples renders the samples of the pixels in the mask, or of all pixels if the mask is nil,
+// into the buffer, with the backend chosen in the preset. Progress is only reported for the whole image,
+// the stats of ray cast images are returned for the caller to print.
+func (r Renderer) renderSamples(frame flatFrame, ip ImagePreset, samples []int, mask []bool, buf *sampleBuffer) imageStats {
+	if ip.backend == BackendRaster {
+		r.rasterizeSamples(frame, ip, samples, mask, buf)
+		return imageStats{}
+	}
+	return r.castSamples(frame, ip, samples, mask, buf)
+}
+
+// castSamples renders the samples by casting rays, either into the scene's BVH, or only checking the triangles
+// of the window around each pixel. Windows are rendered concurrently on the renderer's tile pool, alongside the windows
+// of other frames. The stats of the windows are returned when all pixels are rendered, rather than those in a mask.
+func (r Renderer) castSamples(frame flatFrame, ip ImagePreset, samples []int, mask []bool, buf *sampleBuffer) imageStats {
+	var windows []Window
+	if frame.tracer.primaryHitsFromBVH() {
+		windows = tileImage(frame, ip)
+	} else {
+		windows = subdivideSceneIntoWindows(frame, ip)
+	}
+	reports := make([]chunkReport, len(windows))
+	tasks := make([]func(), len(windows))
+	for w, window := range windows {
+		tasks[w] = func() {
+			reports[w] = r.castWindowSamples(window, ip, samples, mask, buf)
+			if mask == nil {
+				r.lineChannel <- reports[w]
 			}
 		}
-		windowPixels := (window.yMax - window.yMin) * (window.xMax - window.xMin)
-		r.lineChannel <- chunkReport{
-			pixels:            windowPixels,
-			triangleChecks:    windowChecks,
-			trianglesInWindow: nTriangles,
+	}
+	r.pool.run(tasks)
+	if mask != nil {
+		return imageStats{}
+	}
+	stats := imageStats{bvh: frame.tracer.primaryHitsFromBVH()}
+	for _, report := range reports {
+		stats.pixels += report.pixels
+		stats.triangles += report.pixels * report.trianglesInWindow
+		stats.checks += report.triangleChecks
+	}
+	return stats
+}
+
+// castWindowSamples renders the samples of the window's pixels in the mask, adding them to the buffer once all are done
+func (r Renderer) castWindowSamples(window Window, ip ImagePreset, samples []int, mask []bool, buf *sampleBuffer) chunkReport {
+	var nTriangles, windowChecks int
+	var windowSamples []pixelSample
+	for x := window.xMin; x < window.xMax; x++ {
+		for y := window.yMin; y < window.yMax; y++ {
+			if mask != nil && !mask[y*ip.width+x] {
+				continue
+			}
+			xR, yR := getImageSpace(x, ip.width), getImageSpace(y, ip.height)
+			for _, i := range samples {
+				offset := r.offset(ip, x, y, i)
+				c, n, nChecks := window.GetColor(xR+offset.dx, yR+offset.dy, offset.lens)
+				windowSamples = append(windowSamples, pixelSample{x, y, offset, c})
+				nTriangles = n
+				windowChecks += nChecks
+			}
 		}
-		imageTriangles += windowPixels * nTriangles
-		imageChecks += windowChecks
 	}
-	imagePixels := ip.width * ip.height
-	fmt.Printf("Image had %d pixels, %.3f triangles per pixel, %.3f checks per pixel\n",
-		imagePixels,
-		float64(imageTriangles)/float64(imagePixels),
-		float64(imageChecks)/float64(imagePixels),
-	)
-	return img
+	buf.addAll(windowSamples)
+	return chunkReport{
+		pixels:            (window.yMax - window.yMin) * (window.xMax - window.xMin),
+		triangleChecks:    windowChecks,
+		trianglesInWindow: nTriangles,
+	}
 }
 
 func abs(a int) int {
@@ -312,8 +609,9 @@ func toImagePixel(p geometry.Pixel, width, height int) *RasterPixel {
 
 func (r Renderer) applyWireframeToImage(img *Image, scene scenes.StaticScene, ip ImagePreset) *Image {
 	triangles, _ := scene.Flatten()
+	camera := getCamera(scene, ip)
 	for _, tri := range triangles {
-		for _, line := range tri.GetWireframe() {
+		for _, line := range tri.GetWireframe(camera) {
 			pixA := toImagePixel(line.A, ip.width, ip.height)
 			pixB := toImagePixel(line.B, ip.width, ip.height)
 			if pixA == nil || pixB == nil {
@@ -332,7 +630,7 @@ func (r Renderer) applyWireframeToImage(img *Image, scene scenes.StaticScene, ip
 				*pixB,
 			), colors.SimpleGradient{Start: colorA, End: colorB})
 		}
-		bbox := tri.GetBoundingBox()
+		bbox := tri.GetBoundingBox(camera)
 		pixA := toImagePixel(bbox.TopLeft, ip.width, ip.height)
 		pixB := toImagePixel(bbox.BottomRight, ip.width, ip.height)
 		if pixA == nil || pixB == nil {
@@ -366,7 +664,7 @@ func (r Renderer) getTriangleDepthImage(scene scenes.StaticScene, ip ImagePreset
 		pixels: ip.height * ip.width,
 	}
 	drawBorders := false
-	windows := subdivideSceneIntoWindows(scene, ip)
+	windows := subdivideSceneIntoWindows(flattenFrame(scene, ip), ip)
 	gradient := colors.LinearGradient{Points: []colors.Color{colors.Red, colors.Green, colors.White}}
 	var pixelColor colors.Color
 	for _, window := range windows {
@@ -0,0 +1,219 @@
+package renderer
+
+import (
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/libeks/go-scene-renderer/colors"
+	"github.com/libeks/go-scene-renderer/scenes"
+	"github.com/libeks/go-scene-renderer/textures"
+)
+
+func TestMotionBlurredImage(t *testing.T) {
+	background := scenes.BackgroundFromTexture(textures.StaticTexture(textures.Uniform(colors.Black)))
+	scene := scenes.ThreeSpheres(background)
+	ip := ImagePreset{width: 32, height: 32, interpolateN: 4, rayDepth: defaultRayDepth}
+	r := newRenderer(ip)
+	go func() {
+		for range r.lineChannel {
+		}
+	}()
+	defer close(r.lineChannel)
+
+	// with a closed shutter, every sample is taken at the start of the frame
+	want := r.getImage(scene.GetFrame(0.3), ip).GetImage()
+	got := r.getMotionBlurredImage(scene, 0.3, 0, ip).GetImage()
+	for y := range ip.height {
+		for x := range ip.width {
+			if !colorsClose(want.At(x, y), got.At(x, y), 1) {
+				t.Fatalf("pixel (%d, %d) is %v with a closed shutter, want %v", x, y, got.At(x, y), want.At(x, y))
+			}
+		}
+	}
+
+	// with an open shutter, the samples should be spread out over the exposure
+	blurred := r.getMotionBlurredImage(scene, 0.3, 0.2, ip).GetImage()
+	different := 0
+	for y := range ip.height {
+		for x := range ip.width {
+			if !colorsClose(want.At(x, y), blurred.At(x, y), 1) {
+				different += 1
+			}
+		}
+	}
+	if different == 0 {
+		t.Errorf("motion blurred image is the same as the unblurred one")
+	}
+}
+
+func TestShutterGroups(t *testing.T) {
+	background := scenes.BackgroundFromTexture(textures.StaticTexture(textures.Uniform(colors.Black)))
+	scene := scenes.ThreeSpheres(background)
+	for _, tc := range []struct {
+		name       string
+		exposure   float64
+		wantGroups int
+	}{
+		// every sample is taken at the start of the frame, which is flattened once
+		{name: "closed shutter", exposure: 0, wantGroups: 1},
+		{name: "open shutter", exposure: 0.2, wantGroups: 4},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			vp := VideoPreset{ImagePreset: ImagePreset{width: 8, height: 8, interpolateN: 4, rayDepth: defaultRayDepth}, nFrameCount: 2, shutterAngle: 1}
+			r := newRenderer(vp.ImagePreset)
+			groups := r.shutterGroups(sampleRange(0, vp.sampleCount()), tc.exposure)
+			if len(groups) != tc.wantGroups {
+				t.Errorf("shutterGroups() returned %d groups, want %d", len(groups), tc.wantGroups)
+			}
+			// progress is reported over the whole image for each group
+			reported := make(chan int)
+			go func() {
+				pixels := 0
+				for report := range r.lineChannel {
+					pixels += report.pixels
+				}
+				reported <- pixels
+			}()
+			r.getMotionBlurredImage(scene, 0.3, tc.exposure, vp.ImagePreset)
+			close(r.lineChannel)
+			want := vp.width * vp.height * tc.wantGroups
+			if got := <-reported; got != want {
+				t.Errorf("reported progress of %d pixels, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestConcurrentTiles(t *testing.T) {
+	// the tiles or rows of a frame are rendered by several workers at once, all of them intersecting the frame's
+	// objects, which therefore must not be written to while rendering. Run with -race to catch that.
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(8))
+	background := scenes.BackgroundFromTexture(textures.StaticTexture(textures.Uniform(colors.Black)))
+	scene := scenes.DummySpinningCube(background).WithLights(scenes.DefaultLights()...)
+	for _, tc := range []struct {
+		name    string
+		backend Backend
+	}{
+		{name: "raycast", backend: BackendRaycast},
+		{name: "raster", backend: BackendRaster},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ip := ImagePreset{width: 128, height: 128, interpolateN: 2, rayDepth: defaultRayDepth}.WithBackend(tc.backend)
+			r := newRenderer(ip)
+			go func() {
+				for range r.lineChannel {
+				}
+			}()
+			defer close(r.lineChannel)
+
+			r.pool = newTilePool(1)
+			want := r.getImage(scene.GetFrame(0.3), ip).GetImage()
+			r.pool = newTilePool(8)
+			got := r.getImage(scene.GetFrame(0.3), ip).GetImage()
+			for y := range ip.height {
+				for x := range ip.width {
+					if !colorsClose(want.At(x, y), got.At(x, y), 1) {
+						t.Fatalf("pixel (%d, %d) is %v with 8 workers, want %v as with one", x, y, got.At(x, y), want.At(x, y))
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestVideoPresetExposure(t *testing.T) {
+	tests := []struct {
+		name   string
+		preset VideoPreset
+		want   float64
+	}{
+		{"no shutter", VideoPreset{nFrameCount: 11}, 0},
+		{"half shutter", VideoPreset{nFrameCount: 11}.WithShutterAngle(180), 0.05},
+		{"full shutter", VideoPreset{nFrameCount: 11}.WithShutterAngle(360), 0.1},
+		{"single frame", VideoPreset{nFrameCount: 1}.WithShutterAngle(180), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.preset.exposure(); got != tt.want {
+				t.Errorf("exposure() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// panickingScene panics when rendering the frame at time panicAt
+type panickingScene struct {
+	scenes.DynamicScene
+	panicAt float64
+}
+
+func (s panickingScene) GetFrame(t float64) scenes.StaticScene {
+	if t == s.panicAt {
+		panic("bad frame")
+	}
+	return s.DynamicScene.GetFrame(t)
+}
+
+// panickingBackground panics when it is asked for a color, which happens while the windows of a frame
+// are rendered on the tile pool
+type panickingBackground struct {
+	panics bool
+}
+
+func (b panickingBackground) GetColor(x, y float64) colors.Color {
+	if b.panics {
+		panic("bad background")
+	}
+	return colors.Black
+}
+
+// panickingBackgrounds returns a background that panics in the frame at time panicAt
+type panickingBackgrounds struct {
+	panicAt float64
+}
+
+func (b panickingBackgrounds) GetFrame(t float64) scenes.Background {
+	return panickingBackground{t == b.panicAt}
+}
+
+func TestRenderFramesCollectsPanics(t *testing.T) {
+	background := scenes.BackgroundFromTexture(textures.StaticTexture(textures.Uniform(colors.Black)))
+	for _, tc := range []struct {
+		name  string
+		scene scenes.DynamicScene
+	}{
+		{name: "getting the frame", scene: panickingScene{scenes.ThreeSpheres(background), 0.5}},
+		{name: "rendering a window", scene: scenes.ThreeSpheres(panickingBackgrounds{0.5})},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			vp := VideoPreset{ImagePreset: ImagePreset{width: 8, height: 8, interpolateN: 1}, nFrameCount: 3}
+			r := newRenderer(vp.ImagePreset)
+			var mu sync.Mutex
+			var handled []int
+			var failures int
+			err := r.renderFrames(tc.scene, vp, vp.allFrames(), false, false, func(i int, frame *Image) error {
+				mu.Lock()
+				defer mu.Unlock()
+				handled = append(handled, i)
+				return nil
+			}, func(err error) {
+				mu.Lock()
+				defer mu.Unlock()
+				failures += 1
+			})
+			if err == nil || !strings.Contains(err.Error(), "frame 1 panicked") {
+				t.Errorf("renderFrames() returned %v, want the panic of frame 1", err)
+			}
+			if failures != 1 {
+				t.Errorf("failed was called %d times, want once", failures)
+			}
+			for _, i := range handled {
+				if i == 1 {
+					t.Errorf("the frame that panicked was handled")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,309 @@
+package renderer
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"sync"
+
+	"github.com/libeks/go-scene-renderer/colors"
+)
+
+const (
+	adaptiveSampleFactor = 4 // adaptive sampling refines disagreeing pixels up to this many times the preset's samples
+)
+
+// Sampler selects where within each pixel its samples are taken
+type Sampler int
+
+const (
+	SamplerRandom     Sampler = iota // the same uniformly random offsets in every pixel
+	SamplerStratified                // one jittered sample in each cell of a grid over the pixel
+	SamplerHalton                    // the Halton sequence in bases 2 and 3, randomly shifted in each pixel
+	SamplerSobol                     // the first two dimensions of the Sobol sequence, randomly scrambled in each pixel
+)
+
+func ParseSampler(flagVal string) (Sampler, error) {
+	switch flagVal {
+	case "random":
+		return SamplerRandom, nil
+	case "stratified":
+		return SamplerStratified, nil
+	case "halton":
+		return SamplerHalton, nil
+	case "sobol":
+		return SamplerSobol, nil
+	default:
+		return SamplerRandom, fmt.Errorf("could not parse sampler '%s', expect random, stratified, halton or sobol", flagVal)
+	}
+}
+
+// position returns the position of the i-th of n samples within pixel (x,y), with both coordinates in [0,1).
+// Samples beyond the first n, added by adaptive sampling, continue the sequence.
+func (s Sampler) position(x, y, i, n int) (float64, float64) {
+	h := hashSample(x, y, 0)
+	switch s {
+	case SamplerStratified:
+		cols := max(1, int(math.Round(math.Sqrt(float64(n)))))
+		rows := (n + cols - 1) / cols
+		cell := i % n
+		jitter := hashSample(x, y, i+1)
+		return (float64(cell%cols) + unitFloat(jitter)) / float64(cols),
+			(float64(cell/cols) + unitFloat(jitter>>32)) / float64(rows)
+	case SamplerHalton:
+		// Cranley-Patterson rotation, so that neighboring pixels don't share the same pattern
+		return wrap(radicalInverse(i, 2) + unitFloat(h)), wrap(radicalInverse(i, 3) + unitFloat(h>>32))
+	case SamplerSobol:
+		// random digit scrambling by xor keeps the sequence stratified
+		return float64(bits.Reverse32(uint32(i))^uint32(h)) / (1 << 32),
+			float64(sobol2(uint32(i))^uint32(h>>32)) / (1 << 32)
+	default:
+		return 0, 0
+	}
+}
+
+// radicalInverse mirrors the digits of i in the base around the decimal point
+func radicalInverse(i, base int) float64 {
+	inv := 1 / float64(base)
+	f, ret := inv, 0.0
+	for ; i > 0; i /= base {
+		ret += float64(i%base) * f
+		f *= inv
+	}
+	return ret
+}
+
+// sobol2 returns the second dimension of the Sobol sequence, as a fraction of 2^32
+func sobol2(i uint32) uint32 {
+	var ret uint32
+	for v := uint32(1 << 31); i != 0; i >>= 1 {
+		if i&1 != 0 {
+			ret ^= v
+		}
+		v ^= v >> 1
+	}
+	return ret
+}
+
+// hashSample returns pseudo-random bits for the i-th sample of pixel (x,y), the same on every call
+func hashSample(x, y, i int) uint64 {
+	// splitmix64 finalizer
+	h := uint64(x)*0x9e3779b97f4a7c15 ^ uint64(y)*0xbf58476d1ce4e5b9 ^ uint64(i)*0x94d049bb133111eb
+	h ^= h >> 30
+	h *= 0xbf58476d1ce4e5b9
+	h ^= h >> 27
+	h *= 0x94d049bb133111eb
+	h ^= h >> 31
+	return h
+}
+
+// unitFloat maps the lower 32 bits of h to [0,1)
+func unitFloat(h uint64) float64 {
+	return float64(uint32(h)) / (1 << 32)
+}
+
+func wrap(v float64) float64 {
+	return v - math.Floor(v)
+}
+
+// Filter reconstructs pixel colors from the samples around them, weighted by their distance from the pixel center
+type Filter int
+
+const (
+	FilterBox      Filter = iota // the average of the pixel's own samples
+	FilterTent                   // linear falloff over one pixel
+	FilterMitchell               // Mitchell-Netravali cubic with B = C = 1/3, over two pixels, slightly sharpening
+)
+
+func ParseFilter(flagVal string) (Filter, error) {
+	switch flagVal {
+	case "box":
+		return FilterBox, nil
+	case "tent":
+		return FilterTent, nil
+	case "mitchell":
+		return FilterMitchell, nil
+	default:
+		return FilterBox, fmt.Errorf("could not parse filter '%s', expect box, tent or mitchell", flagVal)
+	}
+}
+
+// radius returns how far from the pixel center samples contribute to it, in pixels
+func (f Filter) radius() float64 {
+	switch f {
+	case FilterTent:
+		return 1
+	case FilterMitchell:
+		return 2
+	default:
+		return 0.5
+	}
+}
+
+// weight returns the weight of a sample at (dx,dy) pixels from the pixel center
+func (f Filter) weight(dx, dy float64) float64 {
+	return f.weight1D(dx) * f.weight1D(dy)
+}
+
+func (f Filter) weight1D(d float64) float64 {
+	switch f {
+	case FilterTent:
+		return max(0, 1-math.Abs(d))
+	case FilterMitchell:
+		const b, c = 1.0 / 3, 1.0 / 3
+		d = math.Abs(d)
+		if d < 1 {
+			return ((12-9*b-6*c)*d*d*d + (-18+12*b+6*c)*d*d + (6 - 2*b)) / 6
+		}
+		if d < 2 {
+			return ((-b-6*c)*d*d*d + (6*b+30*c)*d*d + (-12*b-48*c)*d + (8*b + 24*c)) / 6
+		}
+		return 0
+	default:
+		// half-open, so that samples on the border between pixels count towards exactly one of them
+		if -0.5 <= d && d < 0.5 {
+			return 1
+		}
+		return 0
+	}
+}
+
+// sampleBuffer accumulates the filtered samples of an image, along with the statistics of each pixel's
+// own samples used to decide where adaptive sampling adds more
+type sampleBuffer struct {
+	mu       sync.Mutex // held while adding samples from concurrently rendered windows
+	ip       ImagePreset
+	centered bool // pixels have a single sample at their corner, which counts as the pixel center
+	sums     []colors.Color
+	weights  []float64
+	own      []colors.Color // sum of the samples taken within the pixel
+	count    []int
+	lo       []colors.Color // channel-wise minimum and maximum of the samples taken within the pixel
+	hi       []colors.Color
+}
+
+func newSampleBuffer(ip ImagePreset) *sampleBuffer {
+	n := ip.width * ip.height
+	return &sampleBuffer{
+		ip:       ip,
+		centered: ip.maxSampleCount() == 1,
+		sums:     make([]colors.Color, n),
+		weights:  make([]float64, n),
+		own:      make([]colors.Color, n),
+		count:    make([]int, n),
+		lo:       make([]colors.Color, n),
+		hi:       make([]colors.Color, n),
+	}
+}
+
+// pixelSample is the color of a sample taken within pixel (x,y) at the offset
+type pixelSample struct {
+	x, y   int
+	offset Offset
+	c      colors.Color
+}
+
+// addAll adds the samples, safe to call from concurrent goroutines. Samples are splatted onto neighboring pixels,
+// so that windows rendered concurrently add to the same pixels along their borders.
+func (buf *sampleBuffer) addAll(samples []pixelSample) {
+	buf.mu.Lock()
+	defer buf.mu.Unlock()
+	for _, s := range samples {
+		buf.add(s.x, s.y, s.offset, s.c)
+	}
+}
+
+// add splats the color of a sample taken within pixel (x,y) at the offset onto the pixels around it
+func (buf *sampleBuffer) add(x, y int, offset Offset, c colors.Color) {
+	ip := buf.ip
+	sx, sy := 0.5, 0.5
+	if !buf.centered {
+		sx, sy = offset.dx/getPixelWiggle(ip.width), offset.dy/getPixelWiggle(ip.height)
+	}
+	// the sample's position relative to the center of pixel (x,y)
+	px, py := sx-0.5, sy-0.5
+	radius := buf.ip.filter.radius()
+	for j := int(math.Ceil(py - radius)); float64(j) <= py+radius; j++ {
+		for i := int(math.Ceil(px - radius)); float64(i) <= px+radius; i++ {
+			if x+i < 0 || x+i >= ip.width || y+j < 0 || y+j >= ip.height {
+				continue
+			}
+			w := buf.ip.filter.weight(px-float64(i), py-float64(j))
+			if w == 0 {
+				continue
+			}
+			pixel := (y+j)*ip.width + x + i
+			buf.sums[pixel] = buf.sums[pixel].AddUnclamped(c.Scale(w))
+			buf.weights[pixel] += w
+		}
+	}
+
+	pixel := y*ip.width + x
+	if buf.count[pixel] == 0 {
+		buf.lo[pixel], buf.hi[pixel] = c, c
+	} else {
+		lo, hi := buf.lo[pixel], buf.hi[pixel]
+		buf.lo[pixel] = colors.Color{R: min(lo.R, c.R), G: min(lo.G, c.G), B: min(lo.B, c.B)}
+		buf.hi[pixel] = colors.Color{R: max(hi.R, c.R), G: max(hi.G, c.G), B: max(hi.B, c.B)}
+	}
+	buf.own[pixel] = buf.own[pixel].AddUnclamped(c)
+	buf.count[pixel] += 1
+}
+
+// disagreeing returns the pixels whose samples differ by more than the threshold in some channel,
+// or whose average differs by that much from the average of one of their neighbors
+func (buf *sampleBuffer) disagreeing(threshold float64) []bool {
+	ip := buf.ip
+	mean := func(pixel int) colors.Color {
+		return buf.own[pixel].Scale(1 / float64(max(1, buf.count[pixel])))
+	}
+	mask := make([]bool, ip.width*ip.height)
+	for y := range ip.height {
+		for x := range ip.width {
+			pixel := y*ip.width + x
+			if channelDistance(buf.lo[pixel], buf.hi[pixel]) > threshold {
+				mask[pixel] = true
+				continue
+			}
+			m := mean(pixel)
+			for _, n := range [][2]int{{x - 1, y}, {x + 1, y}, {x, y - 1}, {x, y + 1}} {
+				if n[0] < 0 || n[0] >= ip.width || n[1] < 0 || n[1] >= ip.height {
+					continue
+				}
+				if channelDistance(m, mean(n[1]*ip.width+n[0])) > threshold {
+					mask[pixel] = true
+					break
+				}
+			}
+		}
+	}
+	return mask
+}
+
+// colors returns the reconstructed pixel colors, in row-major order starting with the bottom row
+func (buf *sampleBuffer) colors() []colors.Color {
+	ret := make([]colors.Color, len(buf.sums))
+	for i, sum := range buf.sums {
+		if buf.weights[i] <= 0 {
+			continue
+		}
+		c := sum.Scale(1 / buf.weights[i])
+		// negative lobes of the filter may push channels below zero. Channels above one are kept,
+		// they are only clamped when the image is encoded with a limited range.
+		ret[i] = colors.Color{R: max(0, c.R), G: max(0, c.G), B: max(0, c.B)}
+	}
+	return ret
+}
+
+func channelDistance(a, b colors.Color) float64 {
+	return max(math.Abs(a.R-b.R), math.Abs(a.G-b.G), math.Abs(a.B-b.B))
+}
+
+func clamp(v float64) float64 {
+	return max(0, min(1, v))
+}
+
+// clampColor returns the color with its channels clamped to the displayable range
+func clampColor(c colors.Color) colors.Color {
+	return colors.Color{R: clamp(c.R), G: clamp(c.G), B: clamp(c.B)}
+}
@@ -0,0 +1,56 @@
+package renderer
+
+import (
+	"testing"
+)
+
+func TestSamplerPosition(t *testing.T) {
+	n := 16
+	for _, sampler := range []Sampler{SamplerStratified, SamplerHalton, SamplerSobol} {
+		for _, pixel := range [][2]int{{0, 0}, {3, 7}, {100, 42}} {
+			// each of the samples should fall into a different cell of a 4x4 grid over the pixel
+			cells := map[[2]int]bool{}
+			for i := range n {
+				x, y := sampler.position(pixel[0], pixel[1], i, n)
+				if x < 0 || x >= 1 || y < 0 || y >= 1 {
+					t.Fatalf("sampler %d: sample %d of pixel %v at (%f, %f) is outside of the pixel", sampler, i, pixel, x, y)
+				}
+				cells[[2]int{int(x * 4), int(y * 4)}] = true
+			}
+			// the Halton sequence's second dimension is in base 3, so it isn't stratified over a 4x4 grid
+			if sampler != SamplerHalton && len(cells) != n {
+				t.Errorf("sampler %d: samples of pixel %v cover %d of %d cells", sampler, pixel, len(cells), n)
+			}
+		}
+	}
+}
+
+func TestFilterWeight(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter Filter
+		d      float64
+		want   float64
+	}{
+		{"box center", FilterBox, 0, 1},
+		{"box lower edge", FilterBox, -0.5, 1},
+		{"box upper edge", FilterBox, 0.5, 0},
+		{"tent center", FilterTent, 0, 1},
+		{"tent halfway", FilterTent, 0.5, 0.5},
+		{"tent outside", FilterTent, 1.5, 0},
+		{"mitchell center", FilterMitchell, 0, 8.0 / 9},
+		{"mitchell one", FilterMitchell, 1, 1.0 / 18},
+		{"mitchell outside", FilterMitchell, 2, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.weight1D(tt.d); !approxEqual(got, tt.want) {
+				t.Errorf("weight1D(%f) = %f, want %f", tt.d, got, tt.want)
+			}
+		})
+	}
+}
+
+func approxEqual(a, b float64) bool {
+	return a-b < 1e-9 && b-a < 1e-9
+}
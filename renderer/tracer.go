@@ -0,0 +1,203 @@
+package renderer
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/libeks/go-scene-renderer/colors"
+	"github.com/libeks/go-scene-renderer/geometry"
+	"github.com/libeks/go-scene-renderer/objects"
+	"github.com/libeks/go-scene-renderer/scenes"
+)
+
+const (
+	// secondary rays start this far off the surface, so that they don't hit the surface they originate from
+	rayBias = 1e-5
+	// once less than this fraction of light shows through the hits along a ray, anything behind them is ignored
+	minTransmittance = 1e-3
+)
+
+// tracer shades the hits of rays, and follows reflected and refracted rays through the scene
+type tracer struct {
+	objects    *objects.BVH   // all objects in the scene, in camera space
+	lights     []scenes.Light // lights in camera space, if empty, objects are rendered unshaded
+	background scenes.Background
+	camera     geometry.Camera // projection of primary rays, also used to look up the background of secondary rays
+	maxDepth   int             // maximum number of bounces a ray can take, 0 means no secondary rays
+	pixelX     float64         // width of a pixel in image space
+	pixelY     float64         // height of a pixel in image space
+}
+
+// newTracer returns a tracer over the flattened objects of a scene, with its lights
+func newTracer(objs []objects.StaticBasicObject, background scenes.Background, lights []scenes.Light, camera geometry.Camera, ip ImagePreset) *tracer {
+	return &tracer{
+		objects:    objects.NewBVH(objs),
+		lights:     lights,
+		background: background,
+		camera:     camera,
+		maxDepth:   ip.rayDepth,
+		pixelX:     getPixelWiggle(ip.width),
+		pixelY:     getPixelWiggle(ip.height),
+	}
+}
+
+// primaryRay returns the camera ray through the image space coordinates (x,y), seen through the point lens
+// on the unit disc of the lens, along with the rays through the neighboring pixels that filtered textures use
+func (tr *tracer) primaryRay(x, y float64, lens geometry.Vector2D) geometry.Ray {
+	return tr.camera.PixelRay(x, y, tr.pixelX, tr.pixelY, lens)
+}
+
+// primaryBackground returns the color of the background seen through the pixel at the image space coordinates (x,y)
+func (tr *tracer) primaryBackground(x, y float64) colors.Color {
+	return scenes.FilteredBackgroundColor(tr.background, x, y, geometry.Footprint{DUDX: tr.pixelX, DVDY: tr.pixelY})
+}
+
+// trace returns the color seen along the ray. depth is the number of bounces the ray has already taken,
+// inside is true if the ray is travelling through the interior of a refractive object.
+func (tr *tracer) trace(r geometry.Ray, depth int, inside bool) colors.Color {
+	color, transmittance := tr.composite(tr.objects.VisibleHits(r), r, depth, inside)
+	if transmittance > 0 {
+		color = color.AddUnclamped(tr.backgroundColor(r).Scale(transmittance))
+	}
+	return color
+}
+
+// flatFrame is a frame of a scene, flattened into its objects once, so that every pass over its samples
+// shares them, along with the tracer over them
+type flatFrame struct {
+	objs   []objects.StaticBasicObject
+	tracer *tracer
+}
+
+func flattenFrame(scene scenes.StaticScene, ip ImagePreset) flatFrame {
+	objs, background := scene.Flatten()
+	return flatFrame{
+		objs:   objs,
+		tracer: newTracer(objs, background, scene.GetLights(), getCamera(scene, ip), ip),
+	}
+}
+
+// primaryHitsFromBVH returns true if primary rays find their hits in the BVH, rather than among the triangles
+// of their window. Window bounding boxes are for the center of the lens, so only the BVH finds all hits of rays
+// through the whole lens.
+func (tr *tracer) primaryHitsFromBVH() bool {
+	return useBVH || tr.camera.Aperture > 0
+}
+
+// composite shades the hits, which must be sorted front to back, and blends them in that order.
+// It returns the blended color, along with the fraction of light from behind all hits that still shows through.
+func (tr *tracer) composite(hits []objects.Hit, r geometry.Ray, depth int, inside bool) (colors.Color, float64) {
+	var color colors.Color
+	transmittance := 1.0
+	for _, hit := range hits {
+		// only the hits that are composited have their footprints found
+		hit = hit.Filtered()
+		color = color.AddUnclamped(tr.shade(hit, r, depth, inside).Scale(transmittance * hit.Alpha))
+		transmittance *= 1 - hit.Alpha
+		if transmittance < minTransmittance {
+			return color, 0
+		}
+	}
+	return color, transmittance
+}
+
+// shade returns the color of the hit, as seen along the ray r
+func (tr *tracer) shade(hit objects.Hit, r geometry.Ray, depth int, inside bool) colors.Color {
+	local := hit.Color
+	if len(tr.lights) > 0 {
+		var occluders objects.RayQuerier
+		if castShadows {
+			occluders = tr.objects
+		}
+		local = scenes.Shade(hit, r.D.ScalarMultiply(-1), tr.lights, occluders)
+	}
+	m := hit.Material
+	if depth >= tr.maxDepth || (m.Reflectivity == 0 && m.Transmission == 0) {
+		return local
+	}
+
+	d := r.D.Unit()
+	normal := hit.Normal
+	if normal.DotProduct(d) > 0 {
+		// surfaces are two-sided, use the side facing the incoming ray
+		normal = normal.ScalarMultiply(-1)
+	}
+	cosI := -normal.DotProduct(d)
+	color := local.Scale(m.Diffuse())
+	reflectivity := m.Reflectivity
+	if m.Transmission > 0 {
+		n1, n2 := 1.0, m.GetIOR()
+		if inside {
+			n1, n2 = n2, n1
+		}
+		refracted, ok := refract(d, normal, cosI, n1/n2)
+		if ok {
+			fresnel := schlick(cosI, n1, n2)
+			reflectivity += m.Transmission * fresnel
+			refractedRay := geometry.Ray{
+				P: offsetPoint(hit.Point, normal, -rayBias*max(1, hit.Depth)),
+				D: perturb(refracted, m.Roughness),
+			}
+			// light passing through the surface is tinted by its color
+			transmitted := tr.trace(refractedRay, depth+1, !inside).Multiply(hit.Color)
+			color = color.AddUnclamped(transmitted.Scale(m.Transmission * (1 - fresnel)))
+		} else {
+			// total internal reflection
+			reflectivity += m.Transmission
+		}
+	}
+	if reflectivity > 0 {
+		reflectedRay := geometry.Ray{
+			P: offsetPoint(hit.Point, normal, rayBias*max(1, hit.Depth)),
+			D: perturb(d.AddVector(normal.ScalarMultiply(2*cosI)), m.Roughness),
+		}
+		color = color.AddUnclamped(tr.trace(reflectedRay, depth+1, inside).Scale(reflectivity))
+	}
+	return color
+}
+
+// backgroundColor returns the color of the background seen along the ray, by projecting it onto the image plane.
+func (tr *tracer) backgroundColor(r geometry.Ray) colors.Color {
+	p := tr.camera.RayToScreen(r)
+	return tr.background.GetColor(p.X, p.Y)
+}
+
+// refract returns the direction of unit vector d after passing through a surface with unit normal n,
+// facing against d, where eta is the ratio of the indices of refraction. The bool is false
+// if the ray is totally internally reflected instead.
+func refract(d, n geometry.Vector3D, cosI, eta float64) (geometry.Vector3D, bool) {
+	k := 1 - eta*eta*(1-cosI*cosI)
+	if k < 0 {
+		return geometry.Vector3D{}, false
+	}
+	return d.ScalarMultiply(eta).AddVector(n.ScalarMultiply(eta*cosI - math.Sqrt(k))), true
+}
+
+// schlick returns Schlick's approximation of the Fresnel reflectance, the fraction of light that is
+// reflected, rather than refracted, when passing from a medium with index n1 into one with index n2
+func schlick(cosI, n1, n2 float64) float64 {
+	r0 := (n1 - n2) / (n1 + n2)
+	r0 = r0 * r0
+	cos := cosI
+	if n1 > n2 {
+		sinT2 := (n1 / n2) * (n1 / n2) * (1 - cosI*cosI)
+		if sinT2 > 1 {
+			return 1
+		}
+		cos = math.Sqrt(1 - sinT2)
+	}
+	return r0 + (1-r0)*math.Pow(1-cos, 5)
+}
+
+// perturb randomly jitters the direction d, the more so the higher the roughness
+func perturb(d geometry.Vector3D, roughness float64) geometry.Vector3D {
+	if roughness == 0 {
+		return d
+	}
+	jitter := geometry.V3(rand.NormFloat64(), rand.NormFloat64(), rand.NormFloat64()).Unit()
+	return d.Unit().AddVector(jitter.ScalarMultiply(roughness * rand.Float64()))
+}
+
+func offsetPoint(p geometry.Point, direction geometry.Vector3D, distance float64) geometry.Point {
+	return geometry.Point(p.Vector().AddVector(direction.ScalarMultiply(distance)))
+}
@@ -0,0 +1,164 @@
+package renderer
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"github.com/libeks/go-scene-renderer/colors"
+	"github.com/libeks/go-scene-renderer/geometry"
+	"github.com/libeks/go-scene-renderer/objects"
+	"github.com/libeks/go-scene-renderer/scenes"
+	"github.com/libeks/go-scene-renderer/textures"
+)
+
+// testTracer returns a tracer over the objects, in camera space, against a black background
+func testTracer(objs []objects.StaticBasicObject, rayDepth int, lights ...scenes.Light) *tracer {
+	background := scenes.BackgroundFromTexture(textures.StaticTexture(textures.Uniform(colors.Black))).GetFrame(0)
+	ip := ImagePreset{width: 32, height: 32, rayDepth: rayDepth}
+	return newTracer(objs, background, lights, geometry.Camera{FOV: math.Pi / 2}, ip)
+}
+
+func opaqueObject(b objects.BasicObject, c colors.Color) objects.StaticBasicObject {
+	return objects.NewStaticBasicObject(b, textures.OpaqueTexture(textures.Uniform(c)))
+}
+
+// panel returns a triangle around center, facing along normal, that covers at least the unit disc around center
+func panel(center geometry.Point, normal geometry.Vector3D) objects.BasicObject {
+	n := normal.Unit()
+	axis := geometry.V3(0, 1, 0)
+	if math.Abs(n.Y) > 0.9 {
+		axis = geometry.V3(1, 0, 0)
+	}
+	u := n.CrossProduct(axis).Unit()
+	v := n.CrossProduct(u)
+	corner := func(angle float64) geometry.Point {
+		return geometry.Point(center.Vector().AddVector(u.ScalarMultiply(2 * math.Cos(angle))).AddVector(v.ScalarMultiply(2 * math.Sin(angle))))
+	}
+	return objects.Tri(corner(math.Pi/2), corner(math.Pi*7/6), corner(math.Pi*11/6))
+}
+
+func TestRefract(t *testing.T) {
+	down := geometry.V3(0, 0, -1)
+	up := geometry.V3(0, 0, 1)
+	diagonal := geometry.V3(1, 0, -1).Unit()
+	// sine and cosine of the refracted angle of the diagonal ray entering glass
+	sinT := math.Sqrt(0.5) / 1.5
+	cosT := math.Sqrt(1 - sinT*sinT)
+	for _, tc := range []struct {
+		name   string
+		d      geometry.Vector3D
+		cosI   float64
+		eta    float64
+		want   geometry.Vector3D
+		wantOK bool
+	}{
+		{name: "head on into glass", d: down, cosI: 1, eta: 1 / 1.5, want: down, wantOK: true},
+		{name: "diagonal between equal indices", d: diagonal, cosI: math.Sqrt(0.5), eta: 1, want: diagonal, wantOK: true},
+		{name: "diagonal into glass", d: diagonal, cosI: math.Sqrt(0.5), eta: 1 / 1.5, want: geometry.V3(sinT, 0, -cosT), wantOK: true},
+		{name: "diagonal out of glass", d: diagonal, cosI: math.Sqrt(0.5), eta: 1.5, wantOK: false},
+		{name: "grazing out of glass", d: geometry.V3(math.Sqrt(3), 0, -1).Unit(), cosI: 0.5, eta: 1.5, wantOK: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := refract(tc.d, up, tc.cosI, tc.eta)
+			if ok != tc.wantOK {
+				t.Fatalf("refract() ok = %v, want %v", ok, tc.wantOK)
+			}
+			if diff := cmp.Diff(tc.want, got, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Errorf("refract() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSchlick(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		cosI   float64
+		n1, n2 float64
+		want   float64
+	}{
+		{name: "head on into glass", cosI: 1, n1: 1, n2: 1.5, want: 0.04},
+		{name: "head on out of glass", cosI: 1, n1: 1.5, n2: 1, want: 0.04},
+		{name: "grazing into glass", cosI: 0, n1: 1, n2: 1.5, want: 1},
+		{name: "at 60 degrees into glass", cosI: 0.5, n1: 1, n2: 1.5, want: 0.04 + 0.96/32},
+		{name: "past the critical angle out of glass", cosI: 0.5, n1: 1.5, n2: 1, want: 1},
+		{name: "equal indices", cosI: 1, n1: 1.5, n2: 1.5, want: 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, schlick(tc.cosI, tc.n1, tc.n2), cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Errorf("schlick() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRayDepth(t *testing.T) {
+	mirror := func(center geometry.Point, normal geometry.Vector3D) objects.StaticBasicObject {
+		obj := opaqueObject(panel(center, normal), colors.White)
+		obj.Material = textures.Mirror()
+		return obj
+	}
+	// turns rays coming down onto the origin towards +x
+	turn := mirror(geometry.OriginPoint, geometry.V3(1, 0, 1))
+	wall := opaqueObject(panel(geometry.Pt(3, 0, 0), geometry.V3(1, 0, 0)), colors.Red)
+	// turns rays coming along +x back up, onto the ceiling
+	turnUp := mirror(geometry.Pt(3, 0, 0), geometry.V3(-1, 0, 1))
+	ceiling := opaqueObject(panel(geometry.Pt(3, 0, 3), geometry.V3(0, 0, 1)), colors.Red)
+	down := geometry.Ray{P: geometry.Pt(0, 0, 5), D: geometry.V3(0, 0, -1)}
+	for _, tc := range []struct {
+		name     string
+		objs     []objects.StaticBasicObject
+		rayDepth int
+		ray      geometry.Ray
+		want     colors.Color
+	}{
+		{
+			name:     "no secondary rays",
+			objs:     []objects.StaticBasicObject{turn, wall},
+			rayDepth: 0,
+			ray:      down,
+			want:     colors.White,
+		},
+		{
+			name:     "one reflection",
+			objs:     []objects.StaticBasicObject{turn, wall},
+			rayDepth: 1,
+			ray:      down,
+			want:     colors.Red,
+		},
+		{
+			name:     "second reflection past the depth",
+			objs:     []objects.StaticBasicObject{turn, turnUp, ceiling},
+			rayDepth: 1,
+			ray:      down,
+			want:     colors.White,
+		},
+		{
+			name:     "two reflections",
+			objs:     []objects.StaticBasicObject{turn, turnUp, ceiling},
+			rayDepth: 2,
+			ray:      down,
+			want:     colors.Red,
+		},
+		{
+			name: "facing mirrors",
+			objs: []objects.StaticBasicObject{
+				mirror(geometry.OriginPoint, geometry.V3(0, 0, 1)),
+				mirror(geometry.Pt(0, 0, 2), geometry.V3(0, 0, 1)),
+			},
+			rayDepth: 8,
+			ray:      geometry.Ray{P: geometry.Pt(0, 0, 1), D: geometry.V3(0, 0, -1)},
+			want:     colors.White,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := testTracer(tc.objs, tc.rayDepth).trace(tc.ray, 0, false)
+			if diff := cmp.Diff(tc.want, got, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Errorf("trace() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -33,24 +33,52 @@ func NewImage(ip ImagePreset) *Image {
 				0, 0, ip.width, ip.height,
 			),
 		),
-		ip: ip,
+		pixels: make([]colors.Color, ip.width*ip.height),
+		ip:     ip,
 	}
 }
 
+// newImageFromColors returns an image with the colors, in row-major order starting with the bottom row
+func newImageFromColors(pixels []colors.Color, ip ImagePreset) *Image {
+	img := NewImage(ip)
+	for y := range ip.height {
+		for x := range ip.width {
+			img.Set(x, y, pixels[y*ip.width+x])
+		}
+	}
+	return img
+}
+
+// Image holds both the 8-bit gamma-corrected image, and the linear colors it was made from,
+// for output formats with a higher bit depth
 type Image struct {
-	im *image.RGBA
-	ip ImagePreset
+	im     *image.RGBA
+	pixels []colors.Color // linear colors, in row-major order starting with the top row, the same as im
+	ip     ImagePreset
 }
 
 // insert pixels with flipped y- coord, so y would be -1 at the bottom, +1 at the top of the image
 func (i *Image) Set(x, y int, c colors.Color) {
-	i.im.Set(x, i.ip.height-y-1, c)
+	if x < 0 || x >= i.ip.width || y < 0 || y >= i.ip.height {
+		return
+	}
+	i.im.Set(x, i.ip.height-y-1, clampColor(c))
+	i.pixels[(i.ip.height-y-1)*i.ip.width+x] = c
 }
 
 func (i *Image) GetImage() image.Image {
 	return i.im
 }
 
+// GetImage16 returns the image with 16 bits per channel, gamma corrected the same way as GetImage
+func (i *Image) GetImage16() image.Image {
+	im := image.NewRGBA64(i.im.Rect)
+	for j, c := range i.pixels {
+		im.Set(j%i.ip.width, j/i.ip.width, clampColor(c))
+	}
+	return im
+}
+
 // adapted from https://en.wikipedia.org/wiki/Bresenham%27s_line_algorithm
 func (i *Image) RenderLine(line *RasterLine, gradient colors.Gradient) {
 	if line == nil {
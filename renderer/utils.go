@@ -3,6 +3,9 @@ package renderer
 import (
 	"os"
 	"path/filepath"
+
+	"github.com/libeks/go-scene-renderer/geometry"
+	"github.com/libeks/go-scene-renderer/scenes"
 )
 
 func cleanUpTempFiles(pattern string) error {
@@ -31,3 +34,8 @@ func getImageSpace(x, pixels int) float64 {
 func getPixelWiggle(pixels int) float64 {
 	return 2.0 / float64(pixels)
 }
+
+// getCamera returns the camera of the scene, with the aspect ratio of the image unless the camera sets its own
+func getCamera(scene scenes.StaticScene, ip ImagePreset) geometry.Camera {
+	return scene.GetCamera().WithAspectRatio(float64(ip.width) / float64(ip.height)).WithDefaults()
+}
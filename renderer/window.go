@@ -7,6 +7,7 @@ import (
 	"slices"
 
 	"github.com/libeks/go-scene-renderer/colors"
+	"github.com/libeks/go-scene-renderer/geometry"
 	"github.com/libeks/go-scene-renderer/objects"
 	"github.com/libeks/go-scene-renderer/scenes"
 )
@@ -14,35 +15,55 @@ import (
 // Window specifies a renderable area along with the triangles within in
 type Window struct {
 	// coordinates are in image pixel space
-	xMin       int                         // inclusive
-	xMax       int                         // non-inclusive
-	yMin       int                         // inclusive
-	yMax       int                         // non-inclusive
-	triangles  []objects.StaticBasicObject // list of triangles whose bounding box intersects the window
+	xMin       int            // inclusive
+	xMax       int            // non-inclusive
+	yMin       int            // inclusive
+	yMax       int            // non-inclusive
+	triangles  []windowObject // list of triangles whose bounding box intersects the window
 	background scenes.Background
+	tracer     *tracer // shades the hits, following secondary rays into the whole scene
 }
 
-// GetColor returns the color at the pixel, as well as the number of triangles, and comparisons before a match was made
-func (w Window) GetColor(x, y float64) (colors.Color, int, int) {
-	minZ := math.MaxFloat64
+// windowObject is an object along with its bounding box as seen by the frame's camera, computed once
+// when the windows are set up, since the objects themselves are read concurrently while rendering
+type windowObject struct {
+	objects.StaticBasicObject
+	bbox objects.BoundingBox
+}
+
+// GetColor returns the color at the pixel, seen through the point lens on the unit disc of the camera's lens,
+// as well as the number of triangles, and comparisons before a match was made.
+// With the BVH, the comparisons are the nodes of the hierarchy that the ray visited.
+func (w Window) GetColor(x, y float64, lens geometry.Vector2D) (colors.Color, int, int) {
+	r := w.tracer.primaryRay(x, y, lens)
+	var hits []objects.Hit
 	checks := 0
-	var closestColor *colors.Color
-	for _, tri := range w.triangles {
-		if tri.GetBoundingBox().MinZDepth > minZ && closestColor != nil {
-			// this triangle is behind the one we've found already, break early
-			break
-		}
-		c, zDepth := tri.GetColorDepth(x, y)
-		checks += 1
-		if c != nil && zDepth < minZ {
-			minZ = zDepth
-			closestColor = c
+	if w.tracer.primaryHitsFromBVH() {
+		hits, checks = w.tracer.objects.CountedVisibleHits(r)
+	} else {
+		minZ := math.MaxFloat64
+		for _, tri := range w.triangles {
+			if tri.bbox.MinZDepth > minZ {
+				// this triangle is behind an opaque one we've found already, break early
+				break
+			}
+			checks += 1
+			for _, hit := range tri.Hits(r) {
+				hits = append(hits, hit)
+				if hit.Alpha >= 1 {
+					minZ = min(minZ, hit.Depth)
+					break
+				}
+			}
 		}
+		// primary rays have a z-component of -1, so sorting by ray parameter sorts the hits by depth
+		hits = objects.FrontToBack(hits)
 	}
-	if closestColor != nil {
-		return *closestColor, len(w.triangles), checks
+	color, transmittance := w.tracer.composite(hits, r, 0, false)
+	if transmittance > 0 {
+		color = color.AddUnclamped(w.tracer.primaryBackground(x, y).Scale(transmittance))
 	}
-	return w.background.GetColor(x, y), len(w.triangles), checks
+	return color, len(w.triangles), checks
 }
 
 func (w Window) Width() int {
@@ -69,12 +90,12 @@ func (w Window) Bisect(ip ImagePreset) []Window {
 	xMid := (w.xMax-w.xMin)/2 + w.xMin
 	yMid := (w.yMax-w.yMin)/2 + w.yMin
 	midXImg, midYImg := getImageSpace(xMid, ip.width), getImageSpace(yMid, ip.height)
-	tlW := []objects.StaticBasicObject{}
-	trW := []objects.StaticBasicObject{}
-	blW := []objects.StaticBasicObject{}
-	brW := []objects.StaticBasicObject{}
+	tlW := []windowObject{}
+	trW := []windowObject{}
+	blW := []windowObject{}
+	brW := []windowObject{}
 	for _, tri := range w.triangles {
-		bbox := tri.GetBoundingBox()
+		bbox := tri.bbox
 		// fmt.Printf("bbox inside %s\n", bbox)
 		if bbox.TopLeft.X <= midXImg && bbox.TopLeft.Y <= midYImg {
 			tlW = append(tlW, tri)
@@ -90,42 +111,41 @@ func (w Window) Bisect(ip ImagePreset) []Window {
 		}
 	}
 	retWins := []Window{
-		{w.xMin, xMid, w.yMin, yMid, tlW, w.background},
-		{xMid, w.xMax, w.yMin, yMid, trW, w.background},
-		{w.xMin, xMid, yMid, w.yMax, blW, w.background},
-		{xMid, w.xMax, yMid, w.yMax, brW, w.background},
+		{w.xMin, xMid, w.yMin, yMid, tlW, w.background, w.tracer},
+		{xMid, w.xMax, w.yMin, yMid, trW, w.background, w.tracer},
+		{w.xMin, xMid, yMid, w.yMax, blW, w.background, w.tracer},
+		{xMid, w.xMax, yMid, w.yMax, brW, w.background, w.tracer},
 	}
 	return retWins
 }
 
-func initiateWindow(scene scenes.StaticScene, ip ImagePreset) []Window {
-	triangles, background := scene.Flatten()
-	i := 0
-	for _, tri := range triangles {
-		bbox := tri.GetBoundingBox()
+func initiateWindow(frame flatFrame, ip ImagePreset) []Window {
+	camera := frame.tracer.camera
+	// the frame's objects are shared by every pass over its samples, so pick the visible ones into a new slice
+	triangles := make([]windowObject, 0, len(frame.objs))
+	for _, tri := range frame.objs {
+		bbox := tri.GetBoundingBox(camera)
 		if bbox.TopLeft.X > 1 || bbox.TopLeft.Y > 1 {
 			continue
 		}
 		if bbox.BottomRight.X < -1 || bbox.BottomRight.Y < -1 {
 			continue
 		}
-		triangles[i] = tri
-		i += 1
+		triangles = append(triangles, windowObject{tri, bbox})
 	}
-	triangles = triangles[:i]
 	// put the closest triangles in the front
-	slices.SortFunc(triangles, func(a, b objects.StaticBasicObject) int {
-		return cmp.Compare(a.GetBoundingBox().MinZDepth, b.GetBoundingBox().MinZDepth)
+	slices.SortFunc(triangles, func(a, b windowObject) int {
+		return cmp.Compare(a.bbox.MinZDepth, b.bbox.MinZDepth)
 	})
 
 	return []Window{
-		{0, ip.width, 0, ip.height, triangles, background},
+		{0, ip.width, 0, ip.height, triangles, frame.tracer.background, frame.tracer},
 	}
 }
 
-func subdivideSceneIntoWindows(scene scenes.StaticScene, ip ImagePreset) []Window {
+func subdivideSceneIntoWindows(frame flatFrame, ip ImagePreset) []Window {
 	// start with one window for the whole image. Assume that all objects fall within the image
-	windows := initiateWindow(scene, ip)
+	windows := initiateWindow(frame, ip)
 	maxTriangles := 0
 	totalWork := 0
 	finalWindows := []Window{}
@@ -148,3 +168,15 @@ func subdivideSceneIntoWindows(scene scenes.StaticScene, ip ImagePreset) []Windo
 	}
 	return finalWindows
 }
+
+// tileImage splits the image into tiles of bvhTileSize pixels, to be rendered concurrently, for when primary rays
+// find their hits in the BVH. Unlike subdivideSceneIntoWindows, no triangles are assigned to the tiles.
+func tileImage(frame flatFrame, ip ImagePreset) []Window {
+	windows := []Window{}
+	for y := 0; y < ip.height; y += bvhTileSize {
+		for x := 0; x < ip.width; x += bvhTileSize {
+			windows = append(windows, Window{x, min(x+bvhTileSize, ip.width), y, min(y+bvhTileSize, ip.height), nil, frame.tracer.background, frame.tracer})
+		}
+	}
+	return windows
+}
@@ -0,0 +1,47 @@
+package sampler
+
+import "github.com/libeks/go-scene-renderer/geometry"
+
+// FilteredSampler is a Sampler that can average its values over the footprint of a pixel, so that details
+// smaller than a pixel are blurred rather than aliased
+type FilteredSampler interface {
+	Sampler
+	GetFilteredFrameValue(x, y, t float64, f geometry.Footprint) float64
+}
+
+// FilteredStaticSampler is the StaticSampler version of FilteredSampler
+type FilteredStaticSampler interface {
+	StaticSampler
+	GetFilteredValue(x, y float64, f geometry.Footprint) float64
+}
+
+// FilteredFrameValue returns the value of the sampler averaged over the footprint around (x,y). FilteredSamplers
+// are band-limited, other samplers are sampled at points spread across the footprint.
+func FilteredFrameValue(s Sampler, x, y, t float64, f geometry.Footprint) float64 {
+	if filtered, ok := s.(FilteredSampler); ok {
+		return filtered.GetFilteredFrameValue(x, y, t, f)
+	}
+	if f.IsZero() {
+		return s.GetFrameValue(x, y, t)
+	}
+	sum := 0.0
+	f.ForEachSample(x, y, func(x, y float64) {
+		sum += s.GetFrameValue(x, y, t) * f.SampleWeight()
+	})
+	return sum
+}
+
+// FilteredValue is FilteredFrameValue for StaticSamplers
+func FilteredValue(s StaticSampler, x, y float64, f geometry.Footprint) float64 {
+	if filtered, ok := s.(FilteredStaticSampler); ok {
+		return filtered.GetFilteredValue(x, y, f)
+	}
+	if f.IsZero() {
+		return s.GetValue(x, y)
+	}
+	sum := 0.0
+	f.ForEachSample(x, y, func(x, y float64) {
+		sum += s.GetValue(x, y) * f.SampleWeight()
+	})
+	return sum
+}
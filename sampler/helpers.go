@@ -4,6 +4,7 @@ import (
 	// "fmt"
 	"math"
 
+	"github.com/libeks/go-scene-renderer/geometry"
 	"github.com/libeks/go-scene-renderer/maths"
 )
 
@@ -96,6 +97,12 @@ func (s Sigmoid) GetFrameValue(x, y, t float64) float64 {
 	return maths.Sigmoid(s.Sampler.GetFrameValue(x, y, t) * s.Ratio)
 }
 
+// GetFilteredFrameValue applies the sigmoid to the filtered value of the inner sampler,
+// so that waves too fine to be seen fade to the middle value
+func (s Sigmoid) GetFilteredFrameValue(x, y, t float64, f geometry.Footprint) float64 {
+	return maths.Sigmoid(FilteredFrameValue(s.Sampler, x, y, t, f) * s.Ratio)
+}
+
 type MinusPlusToZeroOne struct {
 	Sampler
 }
@@ -129,6 +136,14 @@ func (s SineWave) GetFrameValue(x, y, t float64) float64 {
 	return math.Sin(s.Factor * (x + y))
 }
 
+// GetFilteredFrameValue attenuates the wave by how many of its periods fit into the footprint, using
+// a gaussian with the same variance as the box filter over the footprint
+func (s SineWave) GetFilteredFrameValue(x, y, t float64, f geometry.Footprint) float64 {
+	wx := s.Factor * (f.DUDX + f.DVDX) // change in phase towards the next pixel along x
+	wy := s.Factor * (f.DUDY + f.DVDY)
+	return math.Sin(s.Factor*(x+y)) * math.Exp(-(wx*wx+wy*wy)/24)
+}
+
 type SineOtherDirectionWave struct {
 	Factor float64
 }
@@ -197,6 +212,13 @@ func (s Rotated) GetFrameValue(x, y, t float64) float64 {
 	return s.Sampler.GetFrameValue(x, y, t)
 }
 
+func (s Rotated) GetFilteredFrameValue(x, y, t float64, f geometry.Footprint) float64 {
+	cos, sin := math.Cos(s.Angle), math.Sin(s.Angle)
+	x, y = x*2-1, y*2-1
+	x, y = cos*x+sin*y, -sin*x+cos*y
+	return FilteredFrameValue(s.Sampler, x, y, t, f.Transform(2*cos, 2*sin, -2*sin, 2*cos))
+}
+
 type Wiggle struct {
 	Sampler
 	NWiggles float64
@@ -207,6 +229,10 @@ func (s Wiggle) GetFrameValue(x, y, t float64) float64 {
 	return Rotated{s.Sampler, math.Sin(2*t*s.NWiggles*math.Pi) * s.Angle}.GetFrameValue(x, y, t)
 }
 
+func (s Wiggle) GetFilteredFrameValue(x, y, t float64, f geometry.Footprint) float64 {
+	return Rotated{s.Sampler, math.Sin(2*t*s.NWiggles*math.Pi) * s.Angle}.GetFilteredFrameValue(x, y, t, f)
+}
+
 type SineWaveAnimation struct {
 	XYRatio      float64
 	SigmoidRatio float64
@@ -209,3 +209,19 @@ func (s concentricCircles) GetValue(x, y float64) float64 {
 	}
 	return 0
 }
+
+// GetFilteredValue averages the rings over the change in radius across the footprint
+func (s concentricCircles) GetFilteredValue(x, y float64, f geometry.Footprint) float64 {
+	x, y = x*2-1, y*2-1
+	f = f.Scale(2)
+	r := math.Sqrt(x*x + y*y)
+	var w float64
+	if r == 0 {
+		w, _ = f.Extent()
+	} else {
+		// the gradient of the radius is (x,y)/r
+		w = (math.Abs(x*f.DUDX+y*f.DVDX) + math.Abs(x*f.DUDY+y*f.DVDY)) / r
+	}
+	// the radius can't be negative, so near the center, average from the center outwards instead
+	return maths.SquareWaveAverage(max(r, w/2)/s.width, w/s.width)
+}
@@ -1,8 +0,0 @@
-package main
-
-import "github.com/libeks/go-scene-renderer/scenes"
-
-func getScene() scenes.DynamicScene {
-	// return something defined in gallery.go
-	return IntegratedCrossColors
-}
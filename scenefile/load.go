@@ -0,0 +1,467 @@
+// Package scenefile loads scenes described in YAML or JSON files, so that scenes can be changed without recompiling.
+// The format is described in README.md, scenefile/testdata has examples.
+package scenefile
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/libeks/go-scene-renderer/colors"
+	"github.com/libeks/go-scene-renderer/geometry"
+	"github.com/libeks/go-scene-renderer/renderer"
+	"github.com/libeks/go-scene-renderer/scenes"
+	"github.com/libeks/go-scene-renderer/textures"
+)
+
+const degree = math.Pi / 180 // angles are written in degrees
+
+// Error is a mistake in a scene file, at the line of the value that caused it
+type Error struct {
+	File string
+	Line int
+	Msg  string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Msg)
+}
+
+// Load reads the scene file at path, returning it as an entry named after the file, without its extension
+func Load(path string) (scenes.Entry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return scenes.Entry{}, err
+	}
+	return Parse(path, data)
+}
+
+// Parse builds the scene described by data, which was read from file. JSON is parsed as the subset of YAML that it is.
+func Parse(file string, data []byte) (scenes.Entry, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return scenes.Entry{}, fmt.Errorf("%s: %w", file, err)
+	}
+	if len(doc.Content) == 0 {
+		return scenes.Entry{}, fmt.Errorf("%s: empty scene file", file)
+	}
+	l := &loader{file: file}
+	entry, scene := l.scene(doc.Content[0])
+	if l.err != nil {
+		return scenes.Entry{}, l.err
+	}
+	base := filepath.Base(file)
+	entry.Name = strings.TrimSuffix(base, filepath.Ext(base))
+	entry.Scene = func() scenes.DynamicScene {
+		return scene
+	}
+	version, err := l.version(data)
+	if err != nil {
+		return scenes.Entry{}, err
+	}
+	entry.Version = version
+	return entry, nil
+}
+
+// version hashes the scene file along with every file it refers to, so that changing a mesh or an image
+// changes the version of the scene as well
+func (l *loader) version(data []byte) (string, error) {
+	hash := sha256.New()
+	hash.Write(data)
+	for _, file := range l.files {
+		contents, err := os.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", l.file, err)
+		}
+		fmt.Fprintf(hash, "\n%s %d\n", file, len(contents))
+		hash.Write(contents)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// loader builds scene values out of YAML nodes. Only the first error is kept, after which
+// the values returned are meaningless, but safe to construct scenes from.
+type loader struct {
+	file  string
+	err   error
+	files []string // files that the scene refers to, in the order they were read
+}
+
+// uses records that the scene refers to file
+func (l *loader) uses(file string) {
+	l.files = append(l.files, file)
+}
+
+// recordingFS is the directory dir, recording each file that is opened from it as used by the scene,
+// such as the material libraries and textures of a mesh
+type recordingFS struct {
+	fs.FS
+	dir string
+	l   *loader
+}
+
+func (f recordingFS) Open(name string) (fs.File, error) {
+	file, err := f.FS.Open(name)
+	if err == nil {
+		f.l.uses(filepath.Join(f.dir, filepath.FromSlash(name)))
+	}
+	return file, err
+}
+
+func (l *loader) errorf(node *yaml.Node, format string, args ...any) {
+	if l.err == nil {
+		l.err = &Error{File: l.file, Line: node.Line, Msg: fmt.Sprintf(format, args...)}
+	}
+}
+
+func (l *loader) scene(node *yaml.Node) (scenes.Entry, scenes.CombinedDynamicScene) {
+	m := l.mapping(node)
+	entry := scenes.Entry{
+		Description: l.string(m.get("description"), ""),
+		ImagePreset: l.imagePreset(m.get("imagePreset")),
+		VideoPreset: l.videoPreset(m.get("videoPreset")),
+	}
+	scene := scenes.CombinedDynamicScene{
+		Camera:     l.camera(m.get("camera")),
+		Background: scenes.BackgroundFromTexture(textures.StaticTexture(textures.Uniform(colors.Black))),
+		Lights:     l.lights(m.get("lights")),
+	}
+	if n := m.get("cameraPath"); n != nil {
+		scene.CameraPath = l.cameraPath(n)
+	}
+	if n := m.get("background"); n != nil {
+		scene.Background = scenes.BackgroundFromTexture(l.texture(n))
+	}
+	for _, n := range l.sequence(m.get("objects")) {
+		scene.Objects = append(scene.Objects, l.object(n))
+	}
+	m.close()
+	return entry, scene
+}
+
+func (l *loader) imagePreset(node *yaml.Node) string {
+	name := l.string(node, "")
+	if name == "" {
+		return ""
+	}
+	if _, err := renderer.ParseImagePreset(name); err != nil {
+		l.errorf(node, "%s", err)
+	}
+	return name
+}
+
+func (l *loader) videoPreset(node *yaml.Node) string {
+	name := l.string(node, "")
+	if name == "" {
+		return ""
+	}
+	if _, err := renderer.ParseVideoPreset(name); err != nil {
+		l.errorf(node, "%s", err)
+	}
+	return name
+}
+
+// camera returns the camera described by node, with unset values left for the renderer to fill in
+func (l *loader) camera(node *yaml.Node) geometry.Camera {
+	if node == nil {
+		return geometry.Camera{}
+	}
+	m := l.mapping(node)
+	c := geometry.Camera{
+		FOV:           l.angle(m.get("fov"), 0),
+		AspectRatio:   l.float(m.get("aspectRatio"), 0),
+		Near:          l.float(m.get("near"), 0),
+		Orthographic:  l.bool(m.get("orthographic"), false),
+		Height:        l.float(m.get("height"), 0),
+		Aperture:      l.float(m.get("aperture"), 0),
+		FocusDistance: l.float(m.get("focusDistance"), 0),
+	}
+	m.close()
+	return c
+}
+
+// cameraPath returns the bezier path that the camera moves along, over the part of it between start and end
+func (l *loader) cameraPath(node *yaml.Node) geometry.Path {
+	m := l.mapping(node)
+	pointsNode := m.require("points")
+	var points []geometry.Point
+	for _, n := range l.sequence(pointsNode) {
+		points = append(points, l.point(n))
+	}
+	if pointsNode != nil && len(points) < 2 {
+		l.errorf(pointsNode, "camera path needs at least 2 points, got %d", len(points))
+	}
+	path := geometry.SamplePath(geometry.BezierPath{Points: points}, l.float(m.get("start"), 0), l.float(m.get("end"), 1))
+	m.close()
+	return path
+}
+
+// lights returns the lights described by node, either a list of lights, or default for scenes.DefaultLights
+func (l *loader) lights(node *yaml.Node) []scenes.DynamicLight {
+	node = resolve(node)
+	if node == nil {
+		return nil
+	}
+	if node.Kind == yaml.ScalarNode {
+		if node.Value != "default" {
+			l.errorf(node, "could not parse lights '%s', expect default or a list of lights", node.Value)
+		}
+		return scenes.DefaultLights()
+	}
+	var lights []scenes.DynamicLight
+	for _, n := range l.sequence(node) {
+		lights = append(lights, scenes.StaticLight(l.light(n)))
+	}
+	return lights
+}
+
+func (l *loader) light(node *yaml.Node) scenes.Light {
+	m := l.mapping(node)
+	kind := l.kind(m)
+	c := l.color(m.get("color"), colors.White)
+	intensity := l.float(m.get("intensity"), 1)
+	var light scenes.Light
+	switch kind {
+	case "ambient":
+		light = scenes.AmbientLight{Color: c, Intensity: intensity}
+	case "directional":
+		light = scenes.DirectionalLight{Direction: l.vector(m.require("direction")), Color: c, Intensity: intensity}
+	case "point":
+		light = scenes.PointLight{Position: l.point(m.require("position")), Color: c, Intensity: intensity}
+	case "spot":
+		light = scenes.SpotLight{
+			Position:   l.point(m.require("position")),
+			Direction:  l.vector(m.require("direction")),
+			InnerAngle: l.angle(m.get("innerAngle"), 15),
+			OuterAngle: l.angle(m.get("outerAngle"), 30),
+			Color:      c,
+			Intensity:  intensity,
+		}
+	case "area":
+		light = scenes.AreaLight{
+			Corner:    l.point(m.require("corner")),
+			Edge1:     l.vector(m.require("edge1")),
+			Edge2:     l.vector(m.require("edge2")),
+			Samples:   l.int(m.get("samples"), 4),
+			Color:     c,
+			Intensity: intensity,
+		}
+	default:
+		l.unknownKind(m, "light", kind, "ambient, directional, point, spot or area")
+		light = scenes.AmbientLight{}
+	}
+	m.close()
+	return light
+}
+
+// mapping gives access to the values of a YAML mapping, remembering which keys were read,
+// so that any others can be reported as unknown
+type mapping struct {
+	l      *loader
+	node   *yaml.Node
+	keys   []*yaml.Node
+	values map[string]*yaml.Node
+	read   map[string]bool
+}
+
+func (l *loader) mapping(node *yaml.Node) *mapping {
+	node = resolve(node)
+	if node == nil {
+		// a required value that is missing, which was already reported
+		node = &yaml.Node{Kind: yaml.MappingNode}
+	}
+	m := &mapping{
+		l:      l,
+		node:   node,
+		values: map[string]*yaml.Node{},
+		read:   map[string]bool{},
+	}
+	if node.Kind != yaml.MappingNode {
+		l.errorf(node, "expected a mapping of keys to values")
+		return m
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i]
+		if _, ok := m.values[key.Value]; ok {
+			l.errorf(key, "duplicate key '%s'", key.Value)
+		}
+		m.keys = append(m.keys, key)
+		m.values[key.Value] = node.Content[i+1]
+	}
+	return m
+}
+
+// get returns the value of the key, or nil if it is not set
+func (m *mapping) get(key string) *yaml.Node {
+	m.read[key] = true
+	return m.values[key]
+}
+
+// require returns the value of the key, reporting an error if it is not set
+func (m *mapping) require(key string) *yaml.Node {
+	n := m.get(key)
+	if n == nil {
+		m.l.errorf(m.node, "missing key '%s'", key)
+	}
+	return n
+}
+
+// close reports the first key that was never read
+func (m *mapping) close() {
+	for _, key := range m.keys {
+		if !m.read[key.Value] {
+			m.l.errorf(key, "unknown key '%s'", key.Value)
+			return
+		}
+	}
+}
+
+// kind returns the type of the value described by the mapping
+func (l *loader) kind(m *mapping) string {
+	return l.string(m.require("type"), "")
+}
+
+func (l *loader) unknownKind(m *mapping, what, kind, expected string) {
+	node := m.get("type")
+	if node == nil {
+		node = m.node
+	}
+	l.errorf(node, "unknown %s type '%s', expect %s", what, kind, expected)
+}
+
+// resolve follows aliases to the node they refer to, so that anchored values can be reused
+func resolve(node *yaml.Node) *yaml.Node {
+	for node != nil && node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	return node
+}
+
+// sequence returns the items of a YAML sequence, nil if node is not set
+func (l *loader) sequence(node *yaml.Node) []*yaml.Node {
+	node = resolve(node)
+	if node == nil {
+		return nil
+	}
+	if node.Kind != yaml.SequenceNode {
+		l.errorf(node, "expected a list")
+		return nil
+	}
+	return node.Content
+}
+
+func (l *loader) scalar(node *yaml.Node, v any, what string) bool {
+	node = resolve(node)
+	if node.Kind != yaml.ScalarNode || node.Decode(v) != nil {
+		l.errorf(node, "expected %s, got '%s'", what, nodeText(node))
+		return false
+	}
+	return true
+}
+
+func (l *loader) float(node *yaml.Node, def float64) float64 {
+	if node == nil {
+		return def
+	}
+	var v float64
+	if !l.scalar(node, &v, "a number") {
+		return def
+	}
+	return v
+}
+
+func (l *loader) int(node *yaml.Node, def int) int {
+	if node == nil {
+		return def
+	}
+	var v int
+	if !l.scalar(node, &v, "a whole number") {
+		return def
+	}
+	return v
+}
+
+func (l *loader) bool(node *yaml.Node, def bool) bool {
+	if node == nil {
+		return def
+	}
+	var v bool
+	if !l.scalar(node, &v, "true or false") {
+		return def
+	}
+	return v
+}
+
+func (l *loader) string(node *yaml.Node, def string) string {
+	if node == nil {
+		return def
+	}
+	var v string
+	if !l.scalar(node, &v, "a string") {
+		return def
+	}
+	return v
+}
+
+// path returns the file path in node, relative paths are relative to the scene file
+func (l *loader) path(node *yaml.Node) string {
+	file := l.string(node, "")
+	if file == "" || filepath.IsAbs(file) {
+		return file
+	}
+	return filepath.Join(filepath.Dir(l.file), file)
+}
+
+// angle returns the angle in radians, it is written in degrees, as is def
+func (l *loader) angle(node *yaml.Node, def float64) float64 {
+	return l.float(node, def) * degree
+}
+
+// floats returns the n numbers in the list
+func (l *loader) floats(node *yaml.Node, n int) []float64 {
+	items := l.sequence(node)
+	values := make([]float64, n)
+	if node != nil && len(items) != n {
+		l.errorf(resolve(node), "expected a list of %d numbers, got %d items", n, len(items))
+		return values
+	}
+	for i, item := range items {
+		values[i] = l.float(item, 0)
+	}
+	return values
+}
+
+// vector returns a vector written as [x, y, z]
+func (l *loader) vector(node *yaml.Node) geometry.Vector3D {
+	v := l.floats(node, 3)
+	return geometry.V3(v[0], v[1], v[2])
+}
+
+// point returns a point written as [x, y, z]
+func (l *loader) point(node *yaml.Node) geometry.Point {
+	v := l.floats(node, 3)
+	return geometry.Pt(v[0], v[1], v[2])
+}
+
+// nodeText returns the YAML source of the node, shortened to fit in an error message
+func nodeText(node *yaml.Node) string {
+	if node.Kind == yaml.ScalarNode {
+		return node.Value
+	}
+	out, err := yaml.Marshal(node)
+	if err != nil {
+		return node.Tag
+	}
+	text := strings.Join(strings.Fields(string(out)), " ")
+	if len(text) > 40 {
+		text = text[:37] + "..."
+	}
+	return text
+}
@@ -0,0 +1,226 @@
+package scenefile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/libeks/go-scene-renderer/geometry"
+	"github.com/libeks/go-scene-renderer/scenes"
+)
+
+func TestLoadExamples(t *testing.T) {
+	for _, tc := range []struct {
+		file        string
+		wantName    string
+		wantPreset  string
+		wantObjects int
+	}{
+		{file: "mirror_and_glass.yaml", wantName: "mirror_and_glass", wantPreset: "intermediate", wantObjects: 6},
+		{file: "height_map.json", wantName: "height_map", wantObjects: 2},
+		{file: "video_cube.yaml", wantName: "video_cube", wantObjects: 2},
+	} {
+		t.Run(tc.file, func(t *testing.T) {
+			entry, err := Load(filepath.Join("testdata", tc.file))
+			if err != nil {
+				t.Fatalf("Load: %s", err)
+			}
+			if entry.Name != tc.wantName || entry.ImagePreset != tc.wantPreset || entry.Description == "" {
+				t.Errorf("Load() = %+v, want name %s, image preset '%s' and a description", entry, tc.wantName, tc.wantPreset)
+			}
+			scene := entry.Scene().(scenes.CombinedDynamicScene)
+			if len(scene.Objects) != tc.wantObjects {
+				t.Errorf("got %d objects, want %d", len(scene.Objects), tc.wantObjects)
+			}
+			basics, background := scene.GetFrame(0.5).Flatten()
+			if len(basics) == 0 {
+				t.Errorf("frame has no objects")
+			}
+			background.GetColor(0, 0)
+		})
+	}
+}
+
+func TestVersionIncludesReferencedFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, file := range []string{"pyramid.obj", "pyramid.mtl", "frames/frame_0.png", "frames/frame_1.png"} {
+		data, err := os.ReadFile(filepath.Join("testdata", file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, file)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, file), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	scene := filepath.Join(dir, "scene.yaml")
+	source := `background:
+  type: image
+  file: frames/frame_0.png
+objects:
+  - type: mesh
+    file: pyramid.obj
+  - type: cube
+    texture:
+      type: imageSequence
+      files: frames/frame_*.png
+`
+	if err := os.WriteFile(scene, []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	version := func() string {
+		entry, err := Load(scene)
+		if err != nil {
+			t.Fatalf("Load: %s", err)
+		}
+		return entry.Version
+	}
+	previous := version()
+	if again := version(); again != previous {
+		t.Fatalf("version changed from %s to %s without any changes", previous, again)
+	}
+	// trailing bytes don't change how the files are read
+	for _, file := range []string{"pyramid.obj", "pyramid.mtl", "frames/frame_0.png", "frames/frame_1.png"} {
+		f, err := os.OpenFile(filepath.Join(dir, file), os.O_APPEND|os.O_WRONLY, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.WriteString("\n"); err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+		if got := version(); got == previous {
+			t.Errorf("version didn't change after changing %s", file)
+		} else {
+			previous = got
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		source   string
+		wantLine int
+		wantMsg  string
+	}{
+		{
+			name:     "unknown key",
+			source:   "description: test\ncolour: red\n",
+			wantLine: 2,
+			wantMsg:  "unknown key 'colour'",
+		},
+		{
+			name:     "unknown object type",
+			source:   "objects:\n  - type: sphere\n    texture: red\n  - type: teapot\n",
+			wantLine: 4,
+			wantMsg:  "unknown object type 'teapot'",
+		},
+		{
+			name:     "missing key",
+			source:   "objects:\n  - type: parallelogram\n    a: [0, 0, 0]\n    b: [1, 0, 0]\n    texture: red\n",
+			wantLine: 2,
+			wantMsg:  "missing key 'c'",
+		},
+		{
+			name:     "bad number",
+			source:   "objects:\n  - type: cube\n    texture:\n      type: checkerboard\n      squares: many\n",
+			wantLine: 5,
+			wantMsg:  "expected a whole number, got 'many'",
+		},
+		{
+			name:     "bad color",
+			source:   "background: \"#12345z\"\n",
+			wantLine: 1,
+			wantMsg:  "could not parse color '#12345z'",
+		},
+		{
+			name:     "short point",
+			source:   "cameraPath:\n  points:\n    - [0, 0, 0]\n    - [1, 0]\n",
+			wantLine: 4,
+			wantMsg:  "expected a list of 3 numbers, got 2 items",
+		},
+		{
+			name:     "missing mesh",
+			source:   "objects:\n  - type: mesh\n    file: nothing.obj\n",
+			wantLine: 3,
+			wantMsg:  "could not load mesh",
+		},
+		{
+			name:     "missing image",
+			source:   "background:\n  type: image\n  file: nothing.png\n",
+			wantLine: 3,
+			wantMsg:  "could not load image",
+		},
+		{
+			name:     "unknown wrap mode",
+			source:   "background:\n  type: imageSequence\n  files: testdata/frames/*.png\n  wrap: around\n",
+			wantLine: 4,
+			wantMsg:  "could not parse wrap mode 'around'",
+		},
+		{
+			name:     "unknown preset",
+			source:   "videoPreset: huge\n",
+			wantLine: 1,
+			wantMsg:  "huge",
+		},
+		{
+			name:     "keyframes out of order",
+			source:   "objects:\n  - type: sphere\n    texture: red\n    keyframes:\n      - t: 0.5\n      - t: 0.2\n",
+			wantLine: 6,
+			wantMsg:  "not after the one before it",
+		},
+		{
+			name:     "json",
+			source:   "{\n  \"objects\": [\n    {\"type\": \"sphere\", \"texture\": {\"type\": \"plaid\"}}\n  ]\n}\n",
+			wantLine: 3,
+			wantMsg:  "unknown texture type 'plaid'",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse("scene.yaml", []byte(tc.source))
+			var fileErr *Error
+			if !errors.As(err, &fileErr) {
+				t.Fatalf("Parse() error = %v, want an *Error", err)
+			}
+			if fileErr.File != "scene.yaml" || fileErr.Line != tc.wantLine || !strings.Contains(fileErr.Msg, tc.wantMsg) {
+				t.Errorf("Parse() error = %s, want scene.yaml:%d: ...%s...", err, tc.wantLine, tc.wantMsg)
+			}
+		})
+	}
+}
+
+func TestParseSyntaxError(t *testing.T) {
+	_, err := Parse("scene.yaml", []byte("objects: [\n"))
+	if err == nil || !strings.HasPrefix(err.Error(), "scene.yaml: ") {
+		t.Errorf("Parse() error = %v, want one prefixed by the file name", err)
+	}
+}
+
+func TestInterpolateKeyframes(t *testing.T) {
+	frames := []keyframe{
+		{t: 0.2, translate: geometry.V3(0, 0, 0), scale: 1},
+		{t: 0.6, translate: geometry.V3(4, 0, -2), rotate: geometry.V3(0, 1, 0), scale: 3},
+	}
+	for _, tc := range []struct {
+		t    float64
+		want keyframe
+	}{
+		{t: 0, want: frames[0]},
+		{t: 0.3, want: keyframe{t: 0.3, translate: geometry.V3(1, 0, -0.5), rotate: geometry.V3(0, 0.25, 0), scale: 1.5}},
+		{t: 1, want: frames[1]},
+	} {
+		got := interpolateKeyframes(frames, tc.t)
+		if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(keyframe{}), cmp.Comparer(func(a, b float64) bool {
+			return a-b < 1e-9 && b-a < 1e-9
+		})); diff != "" {
+			t.Errorf("interpolateKeyframes(%v) mismatch (-want +got):\n%s", tc.t, diff)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package scenefile
+
+import (
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/libeks/go-scene-renderer/colors"
+	"github.com/libeks/go-scene-renderer/geometry"
+	"github.com/libeks/go-scene-renderer/objects"
+	"github.com/libeks/go-scene-renderer/sampler"
+	"github.com/libeks/go-scene-renderer/scenes"
+	"github.com/libeks/go-scene-renderer/textures"
+)
+
+const cubeFaces = 6
+
+func (l *loader) object(node *yaml.Node) objects.DynamicObject {
+	m := l.mapping(node)
+	kind := l.kind(m)
+	var obj objects.DynamicObject
+	switch kind {
+	case "cube":
+		faces := l.cubeFaces(m)
+		obj = scenes.UnitTextureCube(faces[0], faces[1], faces[2], faces[3], faces[4], faces[5])
+	case "sphere":
+		obj = objects.DynamicObjectFromBasics(objects.DynamicSphere(objects.UnitSphere(), l.surface(m, m.require("texture"))))
+	case "parallelogram":
+		obj = objects.Parallelogram(
+			l.point(m.require("a")),
+			l.point(m.require("b")),
+			l.point(m.require("c")),
+			l.surface(m, m.require("texture")),
+		)
+	case "heightmap":
+		heightMap := objects.HeightMap{
+			Height:   sampler.DynamicFromAnimated(l.sampler(m.require("height"))),
+			Gradient: l.gradient(m.get("gradient"), colors.Grayscale),
+			N:        l.int(m.get("n"), 50),
+		}
+		if n := m.get("texture"); n != nil {
+			heightMap.Texture = l.surface(m, n)
+		}
+		obj = objects.NewDynamicObject(heightMap)
+	case "mesh":
+		obj = l.mesh(m.require("file"))
+	default:
+		l.unknownKind(m, "object", kind, "cube, sphere, parallelogram, heightmap or mesh")
+	}
+	if n := m.get("material"); n != nil {
+		obj = obj.WithMaterial(l.material(n))
+	}
+	if n := m.get("transform"); n != nil {
+		obj = obj.WithTransform(l.transform(n))
+	}
+	if n := m.get("keyframes"); n != nil {
+		obj = obj.WithDynamicTransform(l.keyframes(n))
+	}
+	m.close()
+	return obj
+}
+
+// mesh returns the triangles of a Wavefront OBJ file
+func (l *loader) mesh(node *yaml.Node) objects.DynamicObject {
+	file := l.path(node)
+	if file == "" {
+		return objects.DynamicObject{}
+	}
+	dir := filepath.Dir(file)
+	obj, err := objects.ReadOBJ(recordingFS{os.DirFS(dir), dir, l}, filepath.Base(file))
+	if err != nil {
+		l.errorf(node, "could not load mesh: %s", err)
+	}
+	return obj
+}
+
+// cubeFaces returns the textures of the cube's faces, either one texture for all of them, or a list of six
+func (l *loader) cubeFaces(m *mapping) []textures.DynamicTransparentTexture {
+	faces := make([]textures.DynamicTransparentTexture, cubeFaces)
+	texture, list := m.get("texture"), m.get("faces")
+	switch {
+	case texture != nil && list != nil:
+		l.errorf(list, "expect either texture or faces, not both")
+	case texture != nil:
+		surface := l.surface(m, texture)
+		for i := range faces {
+			faces[i] = surface
+		}
+	case list != nil:
+		items := l.sequence(list)
+		if len(items) != cubeFaces {
+			l.errorf(resolve(list), "expected %d faces, got %d", cubeFaces, len(items))
+		}
+		for i := range faces {
+			if i < len(items) {
+				faces[i] = l.surface(m, items[i])
+			}
+		}
+	default:
+		l.errorf(m.node, "missing key 'texture' or 'faces'")
+	}
+	return faces
+}
+
+// surface returns the texture, made transparent by the object's transparency, if it has one
+func (l *loader) surface(m *mapping, texture *yaml.Node) textures.DynamicTransparentTexture {
+	t := l.texture(texture)
+	if n := m.get("transparency"); n != nil {
+		return textures.GetDynamicTransparentTexture(t, l.transparency(n))
+	}
+	return textures.OpaqueDynamicTexture(t)
+}
+
+// material returns either mirror or glass, or a material described by its properties
+func (l *loader) material(node *yaml.Node) textures.Material {
+	node = resolve(node)
+	if node.Kind == yaml.ScalarNode {
+		switch node.Value {
+		case "mirror":
+			return textures.Mirror()
+		case "glass":
+			return textures.Glass()
+		default:
+			l.errorf(node, "could not parse material '%s', expect mirror, glass or a mapping of its properties", node.Value)
+			return textures.Material{}
+		}
+	}
+	m := l.mapping(node)
+	material := textures.Material{
+		Reflectivity: l.float(m.get("reflectivity"), 0),
+		Transmission: l.float(m.get("transmission"), 0),
+		IOR:          l.float(m.get("ior"), 0),
+		Roughness:    l.float(m.get("roughness"), 0),
+	}
+	m.close()
+	return material
+}
+
+// transform returns the product of a list of translate, scale, rotateX, rotateY and rotateZ steps.
+// As with geometry.MatrixProduct, the last step is applied to the object first.
+func (l *loader) transform(node *yaml.Node) geometry.HomogeneusMatrix {
+	var steps []geometry.HomogeneusMatrix
+	for _, n := range l.sequence(node) {
+		m := l.mapping(n)
+		if len(m.keys) != 1 {
+			l.errorf(m.node, "expected a single step, one of translate, scale, rotateX, rotateY or rotateZ")
+			continue
+		}
+		key := m.keys[0]
+		value := m.get(key.Value)
+		switch key.Value {
+		case "translate":
+			steps = append(steps, geometry.TranslationMatrix(l.vector(value)))
+		case "scale":
+			steps = append(steps, geometry.ScaleMatrix(l.float(value, 1)))
+		case "rotateX":
+			steps = append(steps, geometry.RotateMatrixX(l.angle(value, 0)))
+		case "rotateY":
+			steps = append(steps, geometry.RotateMatrixY(l.angle(value, 0)))
+		case "rotateZ":
+			steps = append(steps, geometry.RotateMatrixZ(l.angle(value, 0)))
+		default:
+			l.errorf(key, "unknown transform step '%s', expect translate, scale, rotateX, rotateY or rotateZ", key.Value)
+		}
+	}
+	return geometry.MatrixProduct(steps...)
+}
+
+// keyframe is the placement of an object at time t, rotations are in radians around the x, y and z axes
+type keyframe struct {
+	t         float64
+	translate geometry.Vector3D
+	rotate    geometry.Vector3D
+	scale     float64
+}
+
+func (k keyframe) matrix() geometry.HomogeneusMatrix {
+	return geometry.MatrixProduct(
+		geometry.TranslationMatrix(k.translate),
+		geometry.RotateMatrixZ(k.rotate.Z),
+		geometry.RotateMatrixY(k.rotate.Y),
+		geometry.RotateMatrixX(k.rotate.X),
+		geometry.ScaleMatrix(k.scale),
+	)
+}
+
+// keyframes returns a transform that interpolates linearly between the keyframes, holding the first and last ones
+// before and after them. Values left out of a keyframe keep those of the one before it.
+func (l *loader) keyframes(node *yaml.Node) func(t float64) geometry.HomogeneusMatrix {
+	previous := keyframe{scale: 1}
+	var frames []keyframe
+	for i, n := range l.sequence(node) {
+		m := l.mapping(n)
+		frame := previous
+		frame.t = l.float(m.require("t"), 0)
+		if i > 0 && frame.t <= previous.t {
+			l.errorf(m.node, "keyframe at t=%v is not after the one before it, at t=%v", frame.t, previous.t)
+		}
+		if v := m.get("translate"); v != nil {
+			frame.translate = l.vector(v)
+		}
+		if v := m.get("rotate"); v != nil {
+			frame.rotate = l.vector(v).ScalarMultiply(degree)
+		}
+		if v := m.get("scale"); v != nil {
+			frame.scale = l.float(v, 1)
+		}
+		m.close()
+		frames = append(frames, frame)
+		previous = frame
+	}
+	if len(frames) == 0 {
+		l.errorf(resolve(node), "expected at least one keyframe")
+		return func(t float64) geometry.HomogeneusMatrix {
+			return geometry.HomogeneusIdentity
+		}
+	}
+	return func(t float64) geometry.HomogeneusMatrix {
+		return interpolateKeyframes(frames, t).matrix()
+	}
+}
+
+func interpolateKeyframes(frames []keyframe, t float64) keyframe {
+	if t <= frames[0].t {
+		return frames[0]
+	}
+	for i := 1; i < len(frames); i++ {
+		a, b := frames[i-1], frames[i]
+		if t < b.t {
+			r := (t - a.t) / (b.t - a.t)
+			return keyframe{
+				t:         t,
+				translate: a.translate.ScalarMultiply(1 - r).AddVector(b.translate.ScalarMultiply(r)),
+				rotate:    a.rotate.ScalarMultiply(1 - r).AddVector(b.rotate.ScalarMultiply(r)),
+				scale:     a.scale*(1-r) + b.scale*r,
+			}
+		}
+	}
+	return frames[len(frames)-1]
+}
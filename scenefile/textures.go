@@ -0,0 +1,268 @@
+package scenefile
+
+import (
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/libeks/go-scene-renderer/colors"
+	"github.com/libeks/go-scene-renderer/sampler"
+	"github.com/libeks/go-scene-renderer/textures"
+)
+
+var namedColors = map[string]colors.Color{
+	"black":   colors.Black,
+	"white":   colors.White,
+	"gray":    colors.Gray,
+	"red":     colors.Red,
+	"green":   colors.Green,
+	"blue":    colors.Blue,
+	"cyan":    colors.Cyan,
+	"magenta": colors.Magenta,
+	"yellow":  colors.Yellow,
+}
+
+// texture returns the texture described by node, a color on its own is a uniform texture
+func (l *loader) texture(node *yaml.Node) textures.DynamicTexture {
+	node = resolve(node)
+	if node != nil && node.Kind != yaml.MappingNode {
+		return textures.StaticTexture(textures.Uniform(l.color(node, colors.Black)))
+	}
+	m := l.mapping(node)
+	kind := l.kind(m)
+	var texture textures.DynamicTexture
+	switch kind {
+	case "uniform":
+		texture = textures.StaticTexture(textures.Uniform(l.color(m.require("color"), colors.Black)))
+	case "checkerboard":
+		texture = textures.StaticTexture(textures.Checkerboard{Squares: l.int(m.get("squares"), 8)})
+	case "verticalGradient":
+		texture = textures.StaticTexture(textures.VerticalGradient{Gradient: l.gradient(m.require("gradient"), colors.Grayscale)})
+	case "horizontalGradient":
+		texture = textures.StaticTexture(textures.HorizontalGradient{Gradient: l.gradient(m.require("gradient"), colors.Grayscale)})
+	case "squareGradient":
+		c := l.colors(m.require("colors"), 4)
+		texture = textures.StaticTexture(textures.SquareGradientTexture(c[0], c[1], c[2], c[3]))
+	case "perlin":
+		texture = textures.DynamicFromAnimatedTexture(textures.NewPerlinNoiseTexture(l.gradient(m.get("gradient"), colors.Grayscale)))
+	case "sampler":
+		texture = textures.DynamicFromAnimatedTexture(textures.GetAniTextureFromSampler(
+			l.sampler(m.require("sampler")),
+			l.gradient(m.get("gradient"), colors.Grayscale),
+		))
+	case "fuzzy":
+		texture = textures.FuzzyDynamic{Texture: l.texture(m.require("texture")), StdDev: l.float(m.get("stdDev"), 0.01)}
+	case "image":
+		texture = textures.StaticTexture(l.image(m))
+	case "imageSequence":
+		texture = l.imageSequence(m)
+	default:
+		l.unknownKind(m, "texture", kind, "uniform, checkerboard, verticalGradient, horizontalGradient, squareGradient, perlin, sampler, fuzzy, image or imageSequence")
+		texture = textures.StaticTexture(textures.Uniform(colors.Black))
+	}
+	m.close()
+	return texture
+}
+
+// image returns the texture of the image file, with its wrap and filter modes
+func (l *loader) image(m *mapping) textures.Texture {
+	node := m.require("file")
+	file := l.path(node)
+	if file == "" {
+		return textures.Uniform(colors.Black)
+	}
+	image, err := textures.LoadImageTexture(file)
+	if err != nil {
+		l.errorf(node, "could not load image: %s", err)
+		return textures.Uniform(colors.Black)
+	}
+	l.uses(file)
+	return image.WithWrap(l.wrapMode(m.get("wrap"))).WithFilter(l.filterMode(m.get("filter")))
+}
+
+// imageSequence returns the sequence of the image files matching the glob pattern in files
+func (l *loader) imageSequence(m *mapping) textures.DynamicTexture {
+	node := m.require("files")
+	pattern := l.path(node)
+	if pattern == "" {
+		return textures.StaticTexture(textures.Uniform(colors.Black))
+	}
+	sequence, err := textures.LoadImageSequence(pattern)
+	if err != nil {
+		l.errorf(node, "could not load images: %s", err)
+		return textures.StaticTexture(textures.Uniform(colors.Black))
+	}
+	// the same files that the sequence was read from, in the same order
+	files, _ := filepath.Glob(pattern)
+	slices.Sort(files)
+	for _, file := range files {
+		l.uses(file)
+	}
+	sequence.Loops = l.int(m.get("loops"), 1)
+	return sequence.WithWrap(l.wrapMode(m.get("wrap"))).WithFilter(l.filterMode(m.get("filter")))
+}
+
+func (l *loader) wrapMode(node *yaml.Node) textures.WrapMode {
+	wrap, err := textures.ParseWrapMode(l.string(node, "repeat"))
+	if err != nil {
+		l.errorf(node, "%s", err)
+	}
+	return wrap
+}
+
+func (l *loader) filterMode(node *yaml.Node) textures.FilterMode {
+	filter, err := textures.ParseFilterMode(l.string(node, "trilinear"))
+	if err != nil {
+		l.errorf(node, "%s", err)
+	}
+	return filter
+}
+
+// transparency returns the transparency described by node, a number on its own is a constant opacity
+func (l *loader) transparency(node *yaml.Node) textures.DynamicTransparency {
+	node = resolve(node)
+	if node.Kind == yaml.ScalarNode {
+		return textures.StaticTransparency(textures.ConstantTransparency(l.float(node, 1)))
+	}
+	m := l.mapping(node)
+	kind := l.kind(m)
+	var transparency textures.DynamicTransparency
+	switch kind {
+	case "constant":
+		transparency = textures.StaticTransparency(textures.ConstantTransparency(l.float(m.require("alpha"), 1)))
+	case "circleCutout":
+		transparency = textures.DynamicFromAnimatedTransparency(textures.CircleCutout{Radius: l.float(m.require("radius"), 1)})
+	case "middleBand":
+		transparency = textures.StaticTransparency(textures.MiddleBand{Min: l.float(m.require("min"), 0), Max: l.float(m.require("max"), 1)})
+	case "sampler":
+		transparency = textures.DynamicFromAnimatedTransparency(textures.SamplerTransparency(
+			l.sampler(m.require("sampler")),
+			l.float(m.get("threshold"), 0.5),
+			l.float(m.get("softness"), 0),
+		))
+	default:
+		l.unknownKind(m, "transparency", kind, "constant, circleCutout, middleBand or sampler")
+		transparency = textures.StaticTransparency(textures.Opaque())
+	}
+	m.close()
+	return transparency
+}
+
+// sampler returns the sampler described by node, a number on its own is a constant sampler.
+// Samplers that modify another one take it as their sampler key.
+func (l *loader) sampler(node *yaml.Node) sampler.Sampler {
+	node = resolve(node)
+	if node != nil && node.Kind == yaml.ScalarNode {
+		return sampler.Constant{Val: l.float(node, 0)}
+	}
+	m := l.mapping(node)
+	kind := l.kind(m)
+	var s sampler.Sampler
+	switch kind {
+	case "perlin":
+		s = sampler.NewPerlinNoise()
+	case "constant":
+		s = sampler.Constant{Val: l.float(m.require("value"), 0)}
+	case "sineWave":
+		s = sampler.SineWave{Factor: l.float(m.require("factor"), 1)}
+	case "sineWaveAnimation":
+		s = sampler.SineWaveAnimation{
+			XYRatio:      l.float(m.require("xyRatio"), 1),
+			SigmoidRatio: l.float(m.require("sigmoidRatio"), 1),
+			SinCycles:    l.int(m.get("sinCycles"), 1),
+		}
+	case "pulsingSquare":
+		s = sampler.PulsingSquare{}
+	case "sigmoid":
+		s = sampler.Sigmoid{Sampler: l.sampler(m.require("sampler")), Ratio: l.float(m.require("ratio"), 1)}
+	case "scalar":
+		s = sampler.Scalar{Sampler: l.sampler(m.require("sampler")), Factor: l.float(m.require("factor"), 1)}
+	case "zeroOne":
+		s = sampler.MinusPlusToZeroOne{Sampler: l.sampler(m.require("sampler"))}
+	case "rotated":
+		s = sampler.Rotated{Sampler: l.sampler(m.require("sampler")), Angle: l.angle(m.require("angle"), 0)}
+	case "wiggle":
+		s = sampler.Wiggle{
+			Sampler:  l.sampler(m.require("sampler")),
+			NWiggles: l.float(m.get("wiggles"), 1),
+			Angle:    l.angle(m.require("angle"), 0),
+		}
+	case "timeShifted":
+		s = sampler.TimeShifted(l.sampler(m.require("sampler")), l.float(m.require("t"), 0))
+	default:
+		l.unknownKind(m, "sampler", kind, "perlin, constant, sineWave, sineWaveAnimation, pulsingSquare, sigmoid, scalar, zeroOne, rotated, wiggle or timeShifted")
+		s = sampler.Constant{}
+	}
+	m.close()
+	return s
+}
+
+// gradient returns either the grayscale gradient, or one interpolating linearly between a list of colors
+func (l *loader) gradient(node *yaml.Node, def colors.Gradient) colors.Gradient {
+	node = resolve(node)
+	if node == nil {
+		return def
+	}
+	if node.Kind == yaml.ScalarNode {
+		if node.Value != "grayscale" {
+			l.errorf(node, "could not parse gradient '%s', expect grayscale or a list of colors", node.Value)
+		}
+		return colors.Grayscale
+	}
+	items := l.sequence(node)
+	if len(items) < 2 {
+		l.errorf(node, "gradient needs at least 2 colors, got %d", len(items))
+		return def
+	}
+	points := make([]colors.Color, len(items))
+	for i, item := range items {
+		points[i] = l.color(item, colors.Black)
+	}
+	return colors.LinearGradient{Points: points}
+}
+
+// colors returns a list of n colors
+func (l *loader) colors(node *yaml.Node, n int) []colors.Color {
+	items := l.sequence(node)
+	c := make([]colors.Color, n)
+	if node != nil && len(items) != n {
+		l.errorf(resolve(node), "expected a list of %d colors, got %d", n, len(items))
+		return c
+	}
+	for i, item := range items {
+		c[i] = l.color(item, colors.Black)
+	}
+	return c
+}
+
+// color returns a color written as a name, a hex string like "#ff4500", or a list of linear [r, g, b] values from 0 to 1
+func (l *loader) color(node *yaml.Node, def colors.Color) colors.Color {
+	node = resolve(node)
+	if node == nil {
+		return def
+	}
+	if node.Kind == yaml.SequenceNode {
+		v := l.floats(node, 3)
+		return colors.Color{R: v[0], G: v[1], B: v[2]}
+	}
+	s := l.string(node, "")
+	if c, ok := namedColors[s]; ok {
+		return c
+	}
+	if isHexColor(s) {
+		return colors.Hex(s)
+	}
+	l.errorf(node, "could not parse color '%s', expect a name, #rrggbb, #rgb or [r, g, b]", nodeText(node))
+	return def
+}
+
+func isHexColor(s string) bool {
+	if !strings.HasPrefix(s, "#") || (len(s) != 7 && len(s) != 4) {
+		return false
+	}
+	_, err := strconv.ParseUint(s[1:], 16, 32)
+	return err == nil
+}
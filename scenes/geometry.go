@@ -8,6 +8,7 @@ import (
 	"github.com/libeks/go-scene-renderer/maths"
 	"github.com/libeks/go-scene-renderer/objects"
 	"github.com/libeks/go-scene-renderer/sampler"
+	"github.com/libeks/go-scene-renderer/sdf"
 	"github.com/libeks/go-scene-renderer/textures"
 )
 
@@ -34,7 +35,7 @@ func DummySpinningCubes(background DynamicBackground) DynamicScene {
 	}
 }
 
-func SpinningMulticube(background DynamicBackground) DynamicScene {
+func SpinningMulticube(background DynamicBackground) CombinedDynamicScene {
 	initialCube := UnitRGBCube()
 	diagonalCube := initialCube.WithTransform(geometry.MatrixProduct(
 		geometry.RotateMatrixX(-0.615),
@@ -236,7 +237,7 @@ func MulticubeDance(g1, g2, g3 colors.Gradient, background DynamicBackground) Dy
 	}
 }
 
-func DummySpinningCube(background DynamicBackground) DynamicScene {
+func DummySpinningCube(background DynamicBackground) CombinedDynamicScene {
 	return CombinedDynamicScene{
 		Objects: []objects.DynamicObjectInt{
 			UnitRGBCube().WithDynamicTransform(func(t float64) geometry.HomogeneusMatrix {
@@ -632,7 +633,7 @@ func CheckerboardSquareWithRoundHole(background DynamicBackground) DynamicScene
 	}
 }
 
-func HeightMap(background DynamicBackground) DynamicScene {
+func HeightMap(background DynamicBackground) CombinedDynamicScene {
 	return CombinedDynamicScene{
 		Objects: []objects.DynamicObjectInt{
 			objects.NewDynamicObject(
@@ -668,6 +669,30 @@ func HeightMap(background DynamicBackground) DynamicScene {
 	}
 }
 
+// CheckerboardHeightMap is a height map of Perlin noise with a single checkerboard spanning all of its triangles
+func CheckerboardHeightMap(background DynamicBackground) CombinedDynamicScene {
+	return CombinedDynamicScene{
+		Objects: []objects.DynamicObjectInt{
+			objects.NewDynamicObject(
+				objects.HeightMap{
+					Height:  sampler.DynamicFromAnimated(sampler.Sigmoid{Sampler: sampler.NewPerlinNoise(), Ratio: 5}),
+					Texture: textures.OpaqueDynamicTexture(textures.StaticTexture(textures.Checkerboard{Squares: 16})),
+					N:       50,
+				},
+			).WithDynamicTransform(
+				func(t float64) geometry.HomogeneusMatrix {
+					return geometry.MatrixProduct(
+						geometry.RotateMatrixX(0.4),
+						geometry.TranslationMatrix(geometry.V3(0, -0.8, -2)),
+						geometry.RotateMatrixY(t*maths.Rotation),
+					)
+				},
+			),
+		},
+		Background: background,
+	}
+}
+
 func SquaresAlongPath(background DynamicBackground) DynamicScene {
 	path := geometry.BezierPath{
 		Points: []geometry.Point{
@@ -813,7 +838,7 @@ func CameraWithAxisTriangles(background DynamicBackground) DynamicScene {
 	}
 }
 
-func ThreeSpheres(background DynamicBackground) DynamicScene {
+func ThreeSpheres(background DynamicBackground) CombinedDynamicScene {
 	checkerTexture := textures.DynamicTexture(textures.StaticTexture(textures.Checkerboard{Squares: 16}))
 	whiteTexture := textures.StaticTexture(textures.Uniform(colors.White))
 	texture := textures.GetDynamicTransparentTexture(
@@ -871,7 +896,7 @@ func ThreeSpheres(background DynamicBackground) DynamicScene {
 	}
 }
 
-func NineSpheres(background DynamicBackground) DynamicScene {
+func NineSpheres(background DynamicBackground) CombinedDynamicScene {
 	redTexture := textures.OpaqueDynamicTexture(textures.StaticTexture(textures.Uniform(colors.Red)))
 	yellowSquare := objects.Parallelogram(geometry.Pt(-1, -1, 0), geometry.Pt(1, -1, 0), geometry.Pt(-1, 1, 0),
 		textures.OpaqueDynamicTexture(textures.StaticTexture(textures.Uniform(colors.Yellow))),
@@ -1066,3 +1091,307 @@ func HeightMapCross(background DynamicBackground) DynamicScene {
 		Background: background,
 	}
 }
+
+func MirrorAndGlass(background DynamicBackground) DynamicScene {
+	floor := objects.Parallelogram(
+		geometry.Pt(-6, -1, 0),
+		geometry.Pt(6, -1, 0),
+		geometry.Pt(-6, -1, -12),
+		textures.OpaqueDynamicTexture(textures.StaticTexture(textures.Checkerboard{Squares: 12})),
+	)
+	mirrorSphere := objects.DynamicObjectFromBasics(
+		objects.DynamicSphere(objects.UnitSphere(), textures.OpaqueDynamicTexture(textures.StaticTexture(textures.Uniform(colors.White)))),
+	).WithMaterial(textures.Mirror())
+	redSphere := objects.DynamicObjectFromBasics(
+		objects.DynamicSphere(objects.UnitSphere(), textures.OpaqueDynamicTexture(textures.StaticTexture(textures.Uniform(colors.Red)))),
+	)
+	glassCube := UnitTextureCube(
+		textures.OpaqueDynamicTexture(textures.StaticTexture(textures.Uniform(colors.Hex("#E0F0FF")))),
+		textures.OpaqueDynamicTexture(textures.StaticTexture(textures.Uniform(colors.Hex("#E0F0FF")))),
+		textures.OpaqueDynamicTexture(textures.StaticTexture(textures.Uniform(colors.Hex("#E0F0FF")))),
+		textures.OpaqueDynamicTexture(textures.StaticTexture(textures.Uniform(colors.Hex("#E0F0FF")))),
+		textures.OpaqueDynamicTexture(textures.StaticTexture(textures.Uniform(colors.Hex("#E0F0FF")))),
+		textures.OpaqueDynamicTexture(textures.StaticTexture(textures.Uniform(colors.Hex("#E0F0FF")))),
+	).WithMaterial(textures.Glass())
+
+	return CombinedDynamicScene{
+		Objects: []objects.DynamicObjectInt{
+			floor,
+			mirrorSphere.WithTransform(geometry.TranslationMatrix(geometry.V3(-1.3, 0, -6))),
+			redSphere.WithTransform(geometry.MatrixProduct(
+				geometry.TranslationMatrix(geometry.V3(1.5, -0.5, -9)),
+				geometry.ScaleMatrix(0.5),
+			)),
+			glassCube.WithDynamicTransform(func(t float64) geometry.HomogeneusMatrix {
+				return geometry.MatrixProduct(
+					geometry.TranslationMatrix(geometry.V3(1.2, -0.2, -5)),
+					geometry.RotateMatrixY(t*maths.Rotation),
+					geometry.ScaleMatrix(1.2),
+				)
+			}),
+		},
+		Background: background,
+		Lights:     DefaultLights(),
+	}
+}
+
+// SemiTransparentCube is a spinning half-transparent cube with a soft-edged perlin cutout, in front of
+// an opaque checkerboard, so that the cube's back faces and the checkerboard show through its front faces
+func SemiTransparentCube(background DynamicBackground) DynamicScene {
+	texture := textures.StaticTexture(textures.Uniform(colors.Hex("#40A0E0")))
+	cube := UnitTextureCubeWithTransparency(
+		texture,
+		texture,
+		texture,
+		texture,
+		texture,
+		texture,
+		textures.StaticTransparency(textures.ConstantTransparency(0.5)),
+	)
+	softCube := UnitTextureCubeWithTransparency(
+		texture,
+		texture,
+		texture,
+		texture,
+		texture,
+		texture,
+		textures.DynamicFromAnimatedTransparency(
+			textures.SamplerTransparency(sampler.NewPerlinNoise(), 0, 0.1),
+		),
+	)
+	checkerboard := objects.Parallelogram(
+		geometry.Pt(-2, -2, 0),
+		geometry.Pt(2, -2, 0),
+		geometry.Pt(-2, 2, 0),
+		textures.OpaqueDynamicTexture(textures.StaticTexture(textures.Checkerboard{Squares: 8})),
+	)
+	return CombinedDynamicScene{
+		Objects: []objects.DynamicObjectInt{
+			checkerboard.WithTransform(geometry.TranslationMatrix(geometry.V3(0, 0, -4))),
+			cube.WithDynamicTransform(func(t float64) geometry.HomogeneusMatrix {
+				return geometry.MatrixProduct(
+					geometry.TranslationMatrix(geometry.V3(-0.6, 0, -2.5)),
+					geometry.RotateMatrixY(t*maths.Rotation),
+					geometry.RotateMatrixX(-0.615),    // arcsin of 1/sqrt(3) (angle between short and long diagonals in a cube)
+					geometry.RotateMatrixZ(math.Pi/4), // arcsin(1/sqrt(2)), angle between edge and short diagonal
+					geometry.ScaleMatrix(0.7),
+				)
+			}),
+			softCube.WithDynamicTransform(func(t float64) geometry.HomogeneusMatrix {
+				return geometry.MatrixProduct(
+					geometry.TranslationMatrix(geometry.V3(0.6, 0, -2.5)),
+					geometry.RotateMatrixY(-t*maths.Rotation),
+					geometry.ScaleMatrix(0.7),
+				)
+			}),
+		},
+		Background: background,
+	}
+}
+
+// FocusPull is three spheres at increasing distances on a checkerboard floor, seen through a wide aperture.
+// The focus moves from the nearest sphere to the farthest one over the course of the animation.
+func FocusPull(background DynamicBackground) CombinedDynamicScene {
+	floor := objects.Parallelogram(
+		geometry.Pt(-6, -1, 0),
+		geometry.Pt(6, -1, 0),
+		geometry.Pt(-6, -1, -16),
+		textures.OpaqueDynamicTexture(textures.StaticTexture(textures.Checkerboard{Squares: 16})),
+	)
+	sphere := func(c colors.Color) objects.DynamicObject {
+		return objects.DynamicObjectFromBasics(
+			objects.DynamicSphere(objects.UnitSphere(), textures.OpaqueDynamicTexture(textures.StaticTexture(textures.Uniform(c)))),
+		)
+	}
+	nearZ, farZ := 3.0, 12.0
+	return CombinedDynamicScene{
+		Objects: []objects.DynamicObjectInt{
+			floor,
+			sphere(colors.Red).WithTransform(geometry.MatrixProduct(
+				geometry.TranslationMatrix(geometry.V3(-1.2, -0.5, -nearZ)),
+				geometry.ScaleMatrix(0.5),
+			)),
+			sphere(colors.Green).WithTransform(geometry.MatrixProduct(
+				geometry.TranslationMatrix(geometry.V3(0.5, -0.3, -(nearZ+farZ)/2)),
+				geometry.ScaleMatrix(0.7),
+			)),
+			sphere(colors.Blue).WithTransform(geometry.TranslationMatrix(geometry.V3(2.5, 0, -farZ))),
+		},
+		Camera: geometry.Camera{Aperture: 0.15},
+		Focus: func(t float64) float64 {
+			return nearZ + (farZ-nearZ)*maths.SigmoidSlowFastSlow(t)
+		},
+		Background: background,
+		Lights:     DefaultLights(),
+	}
+}
+
+// CSGShapes is a sphere with a cube bitten out of it, a lens where two spheres overlap, and a hemisphere,
+// each spinning above a floor that extends to the horizon
+func CSGShapes(background DynamicBackground) DynamicScene {
+	uniform := func(c colors.Color) textures.DynamicTransparentTexture {
+		return textures.OpaqueDynamicTexture(textures.StaticTexture(textures.Uniform(c)))
+	}
+	sphereAt := func(x, y, z float64) *objects.Sphere {
+		sphere := objects.UnitSphere()
+		sphere.Center = geometry.Pt(x, y, z)
+		return &sphere
+	}
+	floor := objects.DynamicObjectFromBasics(
+		objects.DynamicHalfSpace(
+			objects.NewHalfSpace(geometry.Pt(0, -1.5, 0), geometry.V3(0, 1, 0)),
+			textures.OpaqueDynamicTexture(textures.StaticTexture(textures.Checkerboard{Squares: 2})),
+		),
+	)
+	bitten := objects.DynamicObjectFromBasics(objects.DynamicCSG(
+		objects.Subtract(
+			sphereAt(0, 0, 0),
+			objects.NewAxisAlignedBox(geometry.Pt(-0.2, -0.2, -0.2), geometry.Pt(1.8, 1.8, 1.8)),
+		),
+		uniform(colors.Red),
+	))
+	lens := objects.DynamicObjectFromBasics(objects.DynamicCSG(
+		objects.Intersect(
+			sphereAt(-0.6, 0, 0),
+			sphereAt(0.6, 0, 0),
+		),
+		uniform(colors.Hex("#40A0E0")),
+	))
+	hemisphere := objects.DynamicObjectFromBasics(objects.DynamicCSG(
+		objects.Intersect(
+			sphereAt(0, 0, 0),
+			objects.NewHalfSpace(geometry.OriginPoint, geometry.V3(0, 1, 0)),
+		),
+		textures.OpaqueDynamicTexture(textures.StaticTexture(textures.Checkerboard{Squares: 8})),
+	))
+	spinning := func(obj objects.DynamicObject, x, spins float64) objects.DynamicObject {
+		return obj.WithDynamicTransform(func(t float64) geometry.HomogeneusMatrix {
+			return geometry.MatrixProduct(
+				geometry.TranslationMatrix(geometry.V3(x, 0, -6)),
+				geometry.RotateMatrixY(spins*t*maths.Rotation),
+				geometry.RotateMatrixX(0.4),
+			)
+		})
+	}
+	return CombinedDynamicScene{
+		Objects: []objects.DynamicObjectInt{
+			floor,
+			spinning(bitten, -2.6, 1),
+			spinning(lens, 0, -1),
+			spinning(hemisphere, 2.6, 1),
+		},
+		Background: background,
+		Lights:     DefaultLights(),
+	}
+}
+
+// Primitives shows the analytic primitives on an infinite ground plane, between two rows of pillars
+func Primitives(background DynamicBackground) DynamicScene {
+	uniform := func(c colors.Color) textures.DynamicTransparentTexture {
+		return textures.OpaqueDynamicTexture(textures.StaticTexture(textures.Uniform(c)))
+	}
+	checkerboard := func(squares int) textures.DynamicTransparentTexture {
+		return textures.OpaqueDynamicTexture(textures.StaticTexture(textures.Checkerboard{Squares: squares}))
+	}
+	const floorY = -1.5
+	stone := uniform(colors.Hex("#D8D0C0"))
+	objs := []objects.DynamicObjectInt{
+		objects.DynamicObjectFromBasics(objects.DynamicPlane(
+			objects.NewPlane(geometry.Pt(0, floorY, 0), geometry.V3(0, 1, 0)),
+			checkerboard(2),
+		)),
+	}
+	for _, x := range []float64{-3, 3} {
+		for _, z := range []float64{-6, -9, -12} {
+			objs = append(objs, objects.DynamicObjectFromBasics(
+				objects.DynamicBox(objects.NewAxisAlignedBox(geometry.Pt(x-0.45, floorY, z-0.45), geometry.Pt(x+0.45, floorY+0.3, z+0.45)), stone),
+				objects.DynamicCylinder(objects.NewCylinder(geometry.Pt(x, floorY+0.3, z), geometry.Pt(x, 2.5, z), 0.3), stone),
+			))
+		}
+	}
+	spinning := func(obj objects.DynamicObject, at geometry.Vector3D, tilt float64) objects.DynamicObject {
+		return obj.WithDynamicTransform(func(t float64) geometry.HomogeneusMatrix {
+			return geometry.MatrixProduct(
+				geometry.TranslationMatrix(at),
+				geometry.RotateMatrixY(t*maths.Rotation),
+				geometry.RotateMatrixX(tilt),
+			)
+		})
+	}
+	torus := objects.DynamicObjectFromBasics(objects.DynamicTorus(objects.UnitTorus(0.3), checkerboard(8)))
+	cone := objects.DynamicObjectFromBasics(objects.DynamicCone(
+		objects.NewCone(geometry.Pt(0, -1, 0), geometry.Pt(0, 0.6, 0), 0.7),
+		uniform(colors.Hex("#E07030")),
+	))
+	disc := objects.DynamicObjectFromBasics(objects.DynamicDisc(
+		objects.NewDisc(geometry.OriginPoint, geometry.V3(0, 0, 1), 0.8),
+		checkerboard(4),
+	))
+	objs = append(objs,
+		spinning(torus, geometry.V3(0, 0, -8), 1),
+		spinning(cone, geometry.V3(-1.6, floorY+1, -7), 0),
+		spinning(disc, geometry.V3(1.6, floorY+0.8, -7), 0),
+	)
+	return CombinedDynamicScene{
+		Objects:    objs,
+		Background: background,
+		Lights:     DefaultLights(),
+	}
+}
+
+// SDFShapes shows shapes of signed distance functions, blended together, spinning above a ground plane
+func SDFShapes(background DynamicBackground) DynamicScene {
+	checkerboard := func(squares int) textures.DynamicTransparentTexture {
+		return textures.OpaqueDynamicTexture(textures.StaticTexture(textures.Checkerboard{Squares: squares}))
+	}
+	const floorY = -1.5
+	floor := objects.DynamicObjectFromBasics(objects.DynamicPlane(
+		objects.NewPlane(geometry.Pt(0, floorY, 0), geometry.V3(0, 1, 0)),
+		checkerboard(2),
+	))
+	// three spheres melting into each other, threaded onto a ring
+	blob := sdf.SmoothUnion(0.4,
+		sdf.Union(
+			sdf.SmoothUnion(0.6,
+				sdf.Translate(sdf.Sphere(0.5), geometry.V3(-0.6, 0, 0)),
+				sdf.Translate(sdf.Sphere(0.4), geometry.V3(0.6, 0.1, 0)),
+			),
+			sdf.Translate(sdf.Sphere(0.35), geometry.V3(0, 0.7, 0)),
+		),
+		sdf.Transform(sdf.Torus(0.8, 0.1), geometry.RotateMatrixX(math.Pi/2)),
+	)
+	// a rounded cube with a sphere scooped out of each of its sides
+	scooped := sdf.SmoothSubtract(0.1,
+		sdf.Round(sdf.Box(geometry.V3(0.6, 0.6, 0.6)), 0.1),
+		sdf.Union(
+			sdf.Translate(sdf.Sphere(0.5), geometry.V3(0.8, 0, 0)),
+			sdf.Translate(sdf.Sphere(0.5), geometry.V3(-0.8, 0, 0)),
+			sdf.Translate(sdf.Sphere(0.5), geometry.V3(0, 0, 0.8)),
+			sdf.Translate(sdf.Sphere(0.5), geometry.V3(0, 0, -0.8)),
+		),
+	)
+	capsules := sdf.SmoothUnion(0.3,
+		sdf.Capsule(geometry.Pt(-0.5, -0.7, 0), geometry.Pt(0.5, 0.7, 0), 0.25),
+		sdf.Capsule(geometry.Pt(0.5, -0.7, 0), geometry.Pt(-0.5, 0.7, 0), 0.25),
+	)
+	spinning := func(shape sdf.Shape, projection objects.SDFProjection, texture textures.DynamicTransparentTexture, x, spins float64) objects.DynamicObject {
+		obj := objects.DynamicObjectFromBasics(objects.DynamicSDF(objects.NewSDFObject(shape, projection), texture))
+		return obj.WithDynamicTransform(func(t float64) geometry.HomogeneusMatrix {
+			return geometry.MatrixProduct(
+				geometry.TranslationMatrix(geometry.V3(x, -0.4, -5)),
+				geometry.RotateMatrixY(spins*t*maths.Rotation),
+				geometry.RotateMatrixX(0.3),
+			)
+		})
+	}
+	return CombinedDynamicScene{
+		Objects: []objects.DynamicObjectInt{
+			floor,
+			spinning(scooped, objects.SDFTriplanar, checkerboard(4), -2.4, 1),
+			spinning(blob, objects.SDFSpherical, checkerboard(8), 0, -1),
+			spinning(capsules, objects.SDFTriplanar, textures.OpaqueDynamicTexture(textures.StaticTexture(textures.Uniform(colors.Hex("#E0A030")))), 2.4, 1),
+		},
+		Background: background,
+		Lights:     DefaultLights(),
+	}
+}
@@ -2,6 +2,7 @@ package scenes
 
 import (
 	"github.com/libeks/go-scene-renderer/colors"
+	"github.com/libeks/go-scene-renderer/geometry"
 	"github.com/libeks/go-scene-renderer/textures"
 )
 
@@ -14,6 +15,10 @@ func (b textureBackground) GetColor(x, y float64) colors.Color {
 	return b.t.GetTextureColor(x/2+0.5, y/2+0.5)
 }
 
+func (b textureBackground) GetFilteredColor(x, y float64, f geometry.Footprint) colors.Color {
+	return textures.FilteredTextureColor(b.t, x/2+0.5, y/2+0.5, f.Scale(0.5))
+}
+
 type dynamicTextureBackground struct {
 	t textures.DynamicTexture
 }
@@ -0,0 +1,270 @@
+package scenes
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/libeks/go-scene-renderer/colors"
+	"github.com/libeks/go-scene-renderer/geometry"
+	"github.com/libeks/go-scene-renderer/objects"
+)
+
+const (
+	// Phong shading parameters. The shininess applies to perfectly smooth surfaces,
+	// rougher materials get wider highlights.
+	specularStrength = 0.4
+	shininess        = 20.0
+
+	// shadow rays start this far off the surface, so that surfaces don't shadow themselves
+	shadowBias = 1e-5
+)
+
+// Light is a source of light in a StaticScene. Lights are positioned in world space, the scene
+// moves them into camera space together with the objects.
+type Light interface {
+	// Illuminate returns the light arriving at point p. Most lights return a single sample,
+	// lights with an area return several, which together produce soft shadows.
+	Illuminate(p geometry.Point) []LightSample
+	ApplyMatrix(m geometry.HomogeneusMatrix) Light
+}
+
+type DynamicLight interface {
+	GetFrame(t float64) Light
+}
+
+// LightSample describes the light arriving at a point from a single Light
+type LightSample struct {
+	// Direction is the unit vector from the point towards the light.
+	// It is the zero vector for ambient light, which has no direction.
+	Direction geometry.Vector3D
+	Distance  float64      // distance to the light, +Inf for lights infinitely far away
+	Color     colors.Color // color of the light, already scaled by its intensity and falloff
+}
+
+// a helper for when a static light is needed as a dynamic light
+type staticLight struct {
+	l Light
+}
+
+func (l staticLight) GetFrame(t float64) Light {
+	return l.l
+}
+
+func StaticLight(l Light) DynamicLight {
+	return staticLight{l}
+}
+
+// AmbientLight lights every surface evenly, regardless of its orientation
+type AmbientLight struct {
+	Color     colors.Color
+	Intensity float64
+}
+
+func (l AmbientLight) Illuminate(p geometry.Point) []LightSample {
+	return []LightSample{{
+		Distance: math.Inf(1),
+		Color:    l.Color.Scale(l.Intensity),
+	}}
+}
+
+func (l AmbientLight) ApplyMatrix(m geometry.HomogeneusMatrix) Light {
+	return l
+}
+
+// DirectionalLight is a light infinitely far away, like the sun, with parallel rays
+type DirectionalLight struct {
+	Direction geometry.Vector3D // direction the light is shining in
+	Color     colors.Color
+	Intensity float64
+}
+
+func (l DirectionalLight) Illuminate(p geometry.Point) []LightSample {
+	return []LightSample{{
+		Direction: l.Direction.ScalarMultiply(-1).Unit(),
+		Distance:  math.Inf(1),
+		Color:     l.Color.Scale(l.Intensity),
+	}}
+}
+
+func (l DirectionalLight) ApplyMatrix(m geometry.HomogeneusMatrix) Light {
+	return DirectionalLight{
+		Direction: m.Slice3DMatrix().MultVect(l.Direction),
+		Color:     l.Color,
+		Intensity: l.Intensity,
+	}
+}
+
+// PointLight shines in all directions from Position, its intensity falling off with the square of the distance
+type PointLight struct {
+	Position  geometry.Point
+	Color     colors.Color
+	Intensity float64 // intensity at distance 1
+}
+
+func (l PointLight) Illuminate(p geometry.Point) []LightSample {
+	return []LightSample{pointSample(l.Position, p, l.Color.Scale(l.Intensity))}
+}
+
+// returns the light arriving at point p from a point source at position
+func pointSample(position, p geometry.Point, c colors.Color) LightSample {
+	toLight := position.Subtract(p)
+	d := toLight.Mag()
+	return LightSample{
+		Direction: toLight.Unit(),
+		Distance:  d,
+		Color:     c.Scale(1 / (d * d)),
+	}
+}
+
+func (l PointLight) ApplyMatrix(m geometry.HomogeneusMatrix) Light {
+	return PointLight{
+		Position:  applyMatrixToPoint(m, l.Position),
+		Color:     l.Color,
+		Intensity: l.Intensity,
+	}
+}
+
+// SpotLight is a PointLight that only shines in a cone around Direction.
+// It is at full intensity within InnerAngle, fading out smoothly up to OuterAngle (both in radians).
+type SpotLight struct {
+	Position   geometry.Point
+	Direction  geometry.Vector3D // direction the light is shining in
+	InnerAngle float64
+	OuterAngle float64
+	Color      colors.Color
+	Intensity  float64 // intensity at distance 1
+}
+
+func (l SpotLight) Illuminate(p geometry.Point) []LightSample {
+	sample := pointSample(l.Position, p, l.Color.Scale(l.Intensity))
+	cosAngle := sample.Direction.ScalarMultiply(-1).DotProduct(l.Direction.Unit())
+	cosInner, cosOuter := math.Cos(l.InnerAngle), math.Cos(l.OuterAngle)
+	var factor float64
+	switch {
+	case cosAngle >= cosInner:
+		factor = 1
+	case cosAngle <= cosOuter:
+		factor = 0
+	default:
+		factor = smoothstep((cosAngle - cosOuter) / (cosInner - cosOuter))
+	}
+	sample.Color = sample.Color.Scale(factor)
+	return []LightSample{sample}
+}
+
+func (l SpotLight) ApplyMatrix(m geometry.HomogeneusMatrix) Light {
+	return SpotLight{
+		Position:   applyMatrixToPoint(m, l.Position),
+		Direction:  m.Slice3DMatrix().MultVect(l.Direction),
+		InnerAngle: l.InnerAngle,
+		OuterAngle: l.OuterAngle,
+		Color:      l.Color,
+		Intensity:  l.Intensity,
+	}
+}
+
+// AreaLight is a parallelogram that emits light, spanned by Edge1 and Edge2 from Corner.
+// It is sampled in a jittered Samples x Samples grid, which results in soft shadows.
+type AreaLight struct {
+	Corner    geometry.Point
+	Edge1     geometry.Vector3D
+	Edge2     geometry.Vector3D
+	Samples   int
+	Color     colors.Color
+	Intensity float64 // total intensity at distance 1
+}
+
+func (l AreaLight) Illuminate(p geometry.Point) []LightSample {
+	n := max(l.Samples, 1)
+	c := l.Color.Scale(l.Intensity / float64(n*n))
+	samples := make([]LightSample, 0, n*n)
+	for i := range n {
+		for j := range n {
+			u := (float64(i) + rand.Float64()) / float64(n)
+			v := (float64(j) + rand.Float64()) / float64(n)
+			position := geometry.Point(l.Corner.Vector().AddVector(l.Edge1.ScalarMultiply(u)).AddVector(l.Edge2.ScalarMultiply(v)))
+			samples = append(samples, pointSample(position, p, c))
+		}
+	}
+	return samples
+}
+
+func (l AreaLight) ApplyMatrix(m geometry.HomogeneusMatrix) Light {
+	m3D := m.Slice3DMatrix()
+	return AreaLight{
+		Corner:    applyMatrixToPoint(m, l.Corner),
+		Edge1:     m3D.MultVect(l.Edge1),
+		Edge2:     m3D.MultVect(l.Edge2),
+		Samples:   l.Samples,
+		Color:     l.Color,
+		Intensity: l.Intensity,
+	}
+}
+
+// DefaultLights returns a simple light rig: a white key light from the top left behind the camera,
+// a dimmer fill light from the right, and some ambient light so that no surface is pitch black
+func DefaultLights() []DynamicLight {
+	return []DynamicLight{
+		StaticLight(AmbientLight{Color: colors.White, Intensity: 0.15}),
+		StaticLight(DirectionalLight{Direction: geometry.V3(1, -1, -1), Color: colors.White, Intensity: 0.9}),
+		StaticLight(DirectionalLight{Direction: geometry.V3(-1, 0, -0.5), Color: colors.White, Intensity: 0.3}),
+	}
+}
+
+// Shade applies the lights to the hit, using Lambert diffuse and Phong specular terms.
+// view is the vector from the hit towards the viewer. If occluders is not nil, it is used
+// to cast shadow rays towards each light, which are attenuated by the semi-transparent objects they pass through,
+// and blocked by opaque ones.
+func Shade(hit objects.Hit, view geometry.Vector3D, lights []Light, occluders objects.RayQuerier) colors.Color {
+	view = view.Unit()
+	normal := hit.Normal
+	// surfaces are two-sided, light the side facing the viewer
+	if normal.DotProduct(view) < 0 {
+		normal = normal.ScalarMultiply(-1)
+	}
+	exponent := 1 + (shininess-1)*(1-min(1, hit.Material.Roughness))
+	shadowOrigin := geometry.Point(hit.Point.Vector().AddVector(normal.ScalarMultiply(shadowBias * max(1, hit.Depth))))
+	ret := colors.Black
+	for _, light := range lights {
+		for _, sample := range light.Illuminate(hit.Point) {
+			if sample.Direction == geometry.NilVector3D {
+				// ambient light
+				ret = ret.AddUnclamped(hit.Color.Multiply(sample.Color))
+				continue
+			}
+			diffuse := normal.DotProduct(sample.Direction)
+			if diffuse <= 0 {
+				// light is behind the surface
+				continue
+			}
+			lightColor := sample.Color
+			if occluders != nil {
+				transmittance := occluders.Transmittance(geometry.Ray{P: shadowOrigin, D: sample.Direction}, sample.Distance)
+				if transmittance == 0 {
+					// in the shadow of another object
+					continue
+				}
+				lightColor = lightColor.Scale(transmittance)
+			}
+			ret = ret.AddUnclamped(hit.Color.Multiply(lightColor).Scale(diffuse))
+			reflected := normal.ScalarMultiply(2 * diffuse).AddVector(sample.Direction.ScalarMultiply(-1))
+			if specular := reflected.DotProduct(view); specular > 0 {
+				ret = ret.AddUnclamped(lightColor.Scale(specularStrength * math.Pow(specular, exponent)))
+			}
+		}
+	}
+	return ret
+}
+
+func applyMatrixToPoint(m geometry.HomogeneusMatrix, p geometry.Point) geometry.Point {
+	pt, ok := m.MultVect(p.ToHomogenous()).ToPoint()
+	if !ok {
+		return p
+	}
+	return pt
+}
+
+// maps (0,1) to (0,1) with an S-curve
+func smoothstep(v float64) float64 {
+	return v * v * (3 - 2*v)
+}
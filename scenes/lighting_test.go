@@ -0,0 +1,419 @@
+package scenes
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"github.com/libeks/go-scene-renderer/colors"
+	"github.com/libeks/go-scene-renderer/geometry"
+	"github.com/libeks/go-scene-renderer/objects"
+	"github.com/libeks/go-scene-renderer/textures"
+)
+
+func TestIlluminate(t *testing.T) {
+	// offset along x of a point below the spot light, half way between its inner and outer cones
+	cosHalfway := (math.Cos(math.Pi/6) + math.Cos(math.Pi/3)) / 2
+	halfway := math.Sqrt(1/(cosHalfway*cosHalfway) - 1)
+	spot := SpotLight{
+		Position:   geometry.Pt(0, 1, 0),
+		Direction:  geometry.V3(0, -2, 0),
+		InnerAngle: math.Pi / 6,
+		OuterAngle: math.Pi / 3,
+		Color:      colors.White,
+		Intensity:  0.8,
+	}
+	for _, tc := range []struct {
+		name  string
+		light Light
+		p     geometry.Point
+		want  []LightSample
+	}{
+		{
+			name:  "ambient",
+			light: AmbientLight{Color: colors.Red, Intensity: 0.5},
+			p:     geometry.Pt(1, 2, 3),
+			want:  []LightSample{{Distance: math.Inf(1), Color: colors.Color{R: 0.5}}},
+		},
+		{
+			name:  "directional",
+			light: DirectionalLight{Direction: geometry.V3(0, -2, 0), Color: colors.White, Intensity: 0.5},
+			p:     geometry.Pt(1, 2, 3),
+			want:  []LightSample{{Direction: geometry.V3(0, 1, 0), Distance: math.Inf(1), Color: colors.Gray}},
+		},
+		{
+			name:  "point light at distance 1",
+			light: PointLight{Position: geometry.Pt(0, 0, 1), Color: colors.White, Intensity: 0.5},
+			p:     geometry.OriginPoint,
+			want:  []LightSample{{Direction: geometry.V3(0, 0, 1), Distance: 1, Color: colors.Gray}},
+		},
+		{
+			name:  "point light falls off with the square of the distance",
+			light: PointLight{Position: geometry.Pt(0, 0, 2), Color: colors.White, Intensity: 2},
+			p:     geometry.OriginPoint,
+			want:  []LightSample{{Direction: geometry.V3(0, 0, 1), Distance: 2, Color: colors.Gray}},
+		},
+		{
+			name:  "spot light within the inner cone",
+			light: spot,
+			p:     geometry.OriginPoint,
+			want:  []LightSample{{Direction: geometry.V3(0, 1, 0), Distance: 1, Color: colors.GrayscaleColor(0.8)}},
+		},
+		{
+			name:  "spot light half way between the cones",
+			light: spot,
+			p:     geometry.Pt(halfway, 0, 0),
+			want: []LightSample{{
+				Direction: geometry.V3(-halfway, 1, 0).Unit(),
+				Distance:  math.Hypot(halfway, 1),
+				Color:     colors.GrayscaleColor(0.8 * 0.5 / (1 + halfway*halfway)),
+			}},
+		},
+		{
+			name:  "spot light outside the outer cone",
+			light: spot,
+			p:     geometry.Pt(2, 0, 0),
+			want: []LightSample{{
+				Direction: geometry.V3(-2, 1, 0).Unit(),
+				Distance:  math.Sqrt(5),
+				Color:     colors.Black,
+			}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, tc.light.Illuminate(tc.p), cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Errorf("Illuminate() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestShade(t *testing.T) {
+	surface := colors.Color{R: 0.5, G: 0.2, B: 0.1}
+	facingCamera := objects.Hit{
+		Color:    surface,
+		Alpha:    1,
+		Point:    geometry.OriginPoint,
+		Normal:   geometry.V3(0, 0, 1),
+		Material: textures.Material{Roughness: 1},
+	}
+	facingAway := facingCamera
+	facingAway.Normal = geometry.V3(0, 0, -1)
+	shiny := facingCamera
+	shiny.Material = textures.Material{}
+	headOn := DirectionalLight{Direction: geometry.V3(0, 0, -1), Color: colors.White, Intensity: 0.5}
+	// shines onto the surface at 60 degrees from the normal
+	grazing := DirectionalLight{Direction: geometry.V3(-math.Sqrt(3), 0, -1), Color: colors.White, Intensity: 0.5}
+	for _, tc := range []struct {
+		name   string
+		hit    objects.Hit
+		view   geometry.Vector3D
+		lights []Light
+		want   colors.Color
+	}{
+		{
+			name: "no lights",
+			hit:  facingCamera,
+			view: geometry.V3(0, 0, 1),
+			want: colors.Black,
+		},
+		{
+			name:   "ambient",
+			hit:    facingCamera,
+			view:   geometry.V3(0, 0, 1),
+			lights: []Light{AmbientLight{Color: colors.White, Intensity: 0.4}},
+			want:   colors.Color{R: 0.2, G: 0.08, B: 0.04},
+		},
+		{
+			// full diffuse, and the reflection points straight at the viewer
+			name:   "head on",
+			hit:    facingCamera,
+			view:   geometry.V3(0, 0, 1),
+			lights: []Light{headOn},
+			want:   colors.Color{R: 0.25 + 0.2, G: 0.1 + 0.2, B: 0.05 + 0.2},
+		},
+		{
+			name:   "view is normalized",
+			hit:    facingCamera,
+			view:   geometry.V3(0, 0, 5),
+			lights: []Light{headOn},
+			want:   colors.Color{R: 0.25 + 0.2, G: 0.1 + 0.2, B: 0.05 + 0.2},
+		},
+		{
+			name:   "back side faces the viewer",
+			hit:    facingAway,
+			view:   geometry.V3(0, 0, 1),
+			lights: []Light{headOn},
+			want:   colors.Color{R: 0.25 + 0.2, G: 0.1 + 0.2, B: 0.05 + 0.2},
+		},
+		{
+			// diffuse at cos(60)=0.5, the reflection is 60 degrees off the view, with an exponent of 1
+			name:   "grazing rough surface",
+			hit:    facingCamera,
+			view:   geometry.V3(0, 0, 1),
+			lights: []Light{grazing},
+			want:   colors.Color{R: 0.125 + 0.1, G: 0.05 + 0.1, B: 0.025 + 0.1},
+		},
+		{
+			// smooth surfaces have a narrow highlight, with an exponent of 20
+			name:   "grazing smooth surface",
+			hit:    shiny,
+			view:   geometry.V3(0, 0, 1),
+			lights: []Light{grazing},
+			want: colors.Color{
+				R: 0.125 + 0.2*math.Pow(0.5, shininess),
+				G: 0.05 + 0.2*math.Pow(0.5, shininess),
+				B: 0.025 + 0.2*math.Pow(0.5, shininess),
+			},
+		},
+		{
+			name:   "light behind the surface",
+			hit:    facingCamera,
+			view:   geometry.V3(0, 0, 1),
+			lights: []Light{DirectionalLight{Direction: geometry.V3(0, 0, 1), Color: colors.White, Intensity: 1}},
+			want:   colors.Black,
+		},
+		{
+			name:   "attenuated point light",
+			hit:    facingCamera,
+			view:   geometry.V3(0, 0, 1),
+			lights: []Light{PointLight{Position: geometry.Pt(0, 0, 2), Color: colors.White, Intensity: 2}},
+			want:   colors.Color{R: 0.25 + 0.2, G: 0.1 + 0.2, B: 0.05 + 0.2},
+		},
+		{
+			name: "spot light pointing away",
+			hit:  facingCamera,
+			view: geometry.V3(0, 0, 1),
+			lights: []Light{SpotLight{
+				Position:   geometry.Pt(0, 0, 1),
+				Direction:  geometry.V3(0, 0, 1),
+				InnerAngle: math.Pi / 6,
+				OuterAngle: math.Pi / 3,
+				Color:      colors.White,
+				Intensity:  1,
+			}},
+			want: colors.Black,
+		},
+		{
+			name: "several lights add up",
+			hit:  facingCamera,
+			view: geometry.V3(0, 0, 1),
+			lights: []Light{
+				AmbientLight{Color: colors.White, Intensity: 0.4},
+				headOn,
+			},
+			want: colors.Color{R: 0.2 + 0.25 + 0.2, G: 0.08 + 0.1 + 0.2, B: 0.04 + 0.05 + 0.2},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Shade(tc.hit, tc.view, tc.lights, nil)
+			if diff := cmp.Diff(tc.want, got, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Errorf("Shade() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func opaqueObject(b objects.BasicObject, c colors.Color) objects.StaticBasicObject {
+	return objects.NewStaticBasicObject(b, textures.OpaqueTexture(textures.Uniform(c)))
+}
+
+// ball returns a sphere of the given radius around center
+func ball(center geometry.Point, radius float64) objects.BasicObject {
+	return objects.UnitSphere().ApplyMatrix(geometry.MatrixProduct(geometry.TranslationMatrix(center.Vector()), geometry.ScaleMatrix(radius)))
+}
+
+// shadeClosest returns the shaded color of the closest hit of the ray, with shadows cast by all the objects
+func shadeClosest(t *testing.T, objs objects.ObjectList, lights []Light, r geometry.Ray) (colors.Color, *objects.Hit) {
+	t.Helper()
+	hit := objs.ClosestHit(r)
+	if hit == nil {
+		t.Fatalf("ray %s misses the scene", r)
+	}
+	return Shade(*hit, r.D.ScalarMultiply(-1), lights, objs), hit
+}
+
+func TestShadows(t *testing.T) {
+	// the floor is the z=0 plane, around the origin
+	floor := opaqueObject(objects.Tri(geometry.Pt(-100, -100, 0), geometry.Pt(300, -100, 0), geometry.Pt(-100, 300, 0)), colors.White)
+	// looks down at the origin on the floor, past the edges of the blockers
+	onFloor := geometry.Ray{P: geometry.Pt(3, 0, 3), D: geometry.V3(-1, 0, -1)}
+	overhead := PointLight{Position: geometry.Pt(0, 0, 2), Color: colors.White, Intensity: 0.5}
+	for _, tc := range []struct {
+		name     string
+		objs     objects.ObjectList
+		light    Light
+		ray      geometry.Ray
+		shadowed bool
+	}{
+		{
+			name:  "nothing in the way",
+			objs:  objects.ObjectList{floor},
+			light: overhead,
+			ray:   onFloor,
+		},
+		{
+			name:     "blocker between the floor and the light",
+			objs:     objects.ObjectList{floor, opaqueObject(ball(geometry.Pt(0, 0, 1), 0.5), colors.White)},
+			light:    overhead,
+			ray:      onFloor,
+			shadowed: true,
+		},
+		{
+			name:  "blocker beside the shadow ray",
+			objs:  objects.ObjectList{floor, opaqueObject(ball(geometry.Pt(2, 0, 1), 0.5), colors.White)},
+			light: overhead,
+			ray:   onFloor,
+		},
+		{
+			name:  "blocker behind the light",
+			objs:  objects.ObjectList{floor, opaqueObject(ball(geometry.Pt(0, 0, 3), 0.5), colors.White)},
+			light: overhead,
+			ray:   onFloor,
+		},
+		{
+			// the shadow ray starts above the floor, but not above the blocker
+			name: "blocker just above the floor",
+			objs: objects.ObjectList{
+				floor,
+				opaqueObject(objects.Tri(geometry.Pt(-0.001, -1, 1e-3), geometry.Pt(-0.001, 1, 1e-3), geometry.Pt(-1, 0, 1e-3)), colors.White),
+			},
+			light:    PointLight{Position: geometry.Pt(-1, 0, 0.5), Color: colors.White, Intensity: 0.5},
+			ray:      onFloor,
+			shadowed: true,
+		},
+		{
+			// the floor is hit far from the camera, where the hit point is less precise
+			name: "distant floor doesn't shadow itself",
+			objs: objects.ObjectList{
+				opaqueObject(objects.Tri(geometry.Pt(-1e5, -1, 1e5), geometry.Pt(1e5, -1, 1e5), geometry.Pt(0, -1, -1e5)), colors.White),
+			},
+			light: DirectionalLight{Direction: geometry.V3(0.3, -1, 0), Color: colors.White, Intensity: 0.5},
+			ray:   geometry.Ray{P: geometry.OriginPoint, D: geometry.V3(0, -1, -1000)},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			lights := []Light{tc.light}
+			got, hit := shadeClosest(t, tc.objs, lights, tc.ray)
+			want := colors.Black
+			if !tc.shadowed {
+				want = Shade(*hit, tc.ray.D.ScalarMultiply(-1), lights, nil)
+				if want == colors.Black {
+					t.Fatalf("hit is not lit, even without shadows")
+				}
+			}
+			if diff := cmp.Diff(want, got, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Errorf("Shade() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNoSelfShadowing(t *testing.T) {
+	lights := []Light{DirectionalLight{Direction: geometry.V3(0.3, -0.2, -1), Color: colors.White, Intensity: 0.5}}
+	for _, tc := range []struct {
+		name     string
+		distance float64
+	}{
+		{name: "near sphere", distance: 10},
+		{name: "distant sphere", distance: 1000},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			objs := objects.ObjectList{opaqueObject(ball(geometry.Pt(0, 0, -tc.distance), 1), colors.White)}
+			// rays across the sphere, where rounding puts hit points on either side of its surface
+			for i := range 20 {
+				for j := range 20 {
+					r := geometry.Ray{P: geometry.OriginPoint, D: geometry.V3(float64(i-10)/20/tc.distance, float64(j-10)/20/tc.distance, -1)}
+					got, hit := shadeClosest(t, objs, lights, r)
+					if want := Shade(*hit, r.D.ScalarMultiply(-1), lights, nil); got != want {
+						t.Fatalf("hit at %s is %v, want %v", hit.Point, got, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestAreaLightSoftShadow(t *testing.T) {
+	floor := opaqueObject(objects.Tri(geometry.Pt(-100, -100, 0), geometry.Pt(300, -100, 0), geometry.Pt(-100, 300, 0)), colors.White)
+	// covers the middle of the light, as seen from the origin, but not its corners
+	blocker := opaqueObject(ball(geometry.Pt(0, 0, 1), 0.5), colors.White)
+	lights := []Light{AreaLight{
+		Corner:    geometry.Pt(-1, -1, 2),
+		Edge1:     geometry.V3(2, 0, 0),
+		Edge2:     geometry.V3(0, 2, 0),
+		Samples:   8,
+		Color:     colors.White,
+		Intensity: 2,
+	}}
+	ray := geometry.Ray{P: geometry.Pt(3, 0, 3), D: geometry.V3(-1, 0, -1)}
+
+	lit, _ := shadeClosest(t, objects.ObjectList{floor}, lights, ray)
+	shadowed, _ := shadeClosest(t, objects.ObjectList{floor, blocker}, lights, ray)
+	if lit.R <= 0 {
+		t.Fatalf("floor is not lit by the area light: %v", lit)
+	}
+	if shadowed.R <= 0 || shadowed.R >= lit.R {
+		t.Errorf("partially blocked area light gives %v, want a fraction of %v", shadowed, lit)
+	}
+}
+
+func TestSemiTransparentShadows(t *testing.T) {
+	surface := colors.Color{R: 0.5, G: 0.2, B: 0.1}
+	hit := objects.Hit{
+		Color:    surface,
+		Alpha:    1,
+		Point:    geometry.OriginPoint,
+		Normal:   geometry.V3(0, 0, 1),
+		Material: textures.Material{Roughness: 1},
+	}
+	headOn := DirectionalLight{Direction: geometry.V3(0, 0, -1), Color: colors.White, Intensity: 0.5}
+	// a triangle between the surface and the light, at height z
+	occluder := func(z, alpha float64) objects.StaticBasicObject {
+		texture := textures.GetDynamicTransparentTexture(
+			textures.StaticTexture(textures.Uniform(colors.White)),
+			textures.StaticTransparency(textures.ConstantTransparency(alpha)),
+		).GetFrame(0)
+		return objects.NewStaticBasicObject(objects.Tri(geometry.Pt(-1, -1, z), geometry.Pt(2, -1, z), geometry.Pt(-1, 2, z)), texture)
+	}
+	lit := colors.Color{R: 0.25 + 0.2, G: 0.1 + 0.2, B: 0.05 + 0.2}
+	for _, tc := range []struct {
+		name      string
+		occluders objects.ObjectList
+		want      colors.Color
+	}{
+		{
+			name: "nothing in the way",
+			want: lit,
+		},
+		{
+			name:      "opaque occluder",
+			occluders: objects.ObjectList{occluder(1, 1)},
+			want:      colors.Black,
+		},
+		{
+			name:      "transparent occluder",
+			occluders: objects.ObjectList{occluder(1, 0)},
+			want:      lit,
+		},
+		{
+			name:      "semi-transparent occluder",
+			occluders: objects.ObjectList{occluder(1, 0.75)},
+			want:      lit.Scale(0.25),
+		},
+		{
+			name:      "semi-transparent occluders multiply",
+			occluders: objects.ObjectList{occluder(1, 0.5), occluder(2, 0.5)},
+			want:      lit.Scale(0.25),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Shade(hit, geometry.V3(0, 0, 1), []Light{headOn}, tc.occluders)
+			if diff := cmp.Diff(tc.want, got, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Errorf("Shade() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package scenes
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+)
+
+// Entry is a scene registered under a name, which can be rendered from the command line
+type Entry struct {
+	Name        string
+	Description string
+	// names of the presets that the scene is rendered with unless others are requested, empty for the global defaults
+	ImagePreset string
+	VideoPreset string
+	Scene       func() DynamicScene // constructs the scene, only called when it is rendered, as some take a while
+	// changes whenever the scene does without the executable being rebuilt, as for scenes loaded from files,
+	// so that resumed renders don't reuse frames of an earlier version
+	Version string
+}
+
+var registry = map[string]Entry{}
+
+// Register adds the scene to the registry, panicking if another scene is registered under the same name
+func Register(entries ...Entry) {
+	for _, entry := range entries {
+		if _, ok := registry[entry.Name]; ok {
+			panic(fmt.Sprintf("scene %s is registered twice", entry.Name))
+		}
+		registry[entry.Name] = entry
+	}
+}
+
+// Lookup returns the scene registered under the name
+func Lookup(name string) (Entry, error) {
+	entry, ok := registry[name]
+	if !ok {
+		return Entry{}, fmt.Errorf("no scene registered as '%s', list them with -list-scenes", name)
+	}
+	return entry, nil
+}
+
+// Registered returns all registered scenes, sorted by name
+func Registered() []Entry {
+	entries := make([]Entry, 0, len(registry))
+	for _, entry := range registry {
+		entries = append(entries, entry)
+	}
+	slices.SortFunc(entries, func(a, b Entry) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return entries
+}
+
+func init() {
+	Register(
+		Entry{Name: "PerlinColors", Description: "Perlin noise, shifted in time for each color channel", Scene: PerlinColors},
+		Entry{Name: "ColorRotation", Description: "A rotating cross, shifted in time for each color channel", Scene: ColorRotation},
+		Entry{Name: "ShuffledColorRotation", Description: "Rotating crosses in a shuffled grid, shifted in time for each color channel", Scene: ShuffledColorRotation},
+		Entry{Name: "FourColorSquares", Description: "A grid of square gradients, with each quadrant's colors rotated", Scene: FourColorSquares},
+		Entry{Name: "VerticalWiggler", Description: "Vertical lines wiggling sideways", Scene: VerticalWiggler},
+		Entry{Name: "VerticalLineConcentricCircles", Description: "Vertical lines over concentric circles", Scene: VerticalLineConcentricCircles},
+		Entry{Name: "ShuffledConcentricCircles", Description: "Concentric circles in a shuffled grid", Scene: ShuffledConcentricCircles},
+		Entry{Name: "ConcentricCircles", Description: "Concentric circles, spreading outwards", Scene: ConcentricCircles},
+		Entry{Name: "IntegratedSpinners", Description: "A grid of spinners, integrated over time", Scene: IntegratedSpinners},
+		Entry{Name: "IntegratedCrossColors", Description: "Rotating crosses integrated over time, one per color channel", Scene: IntegratedCrossColors},
+		Entry{Name: "Noise", Description: "A Perlin noise square in front of the same noise", Scene: NoiseTest},
+	)
+}
@@ -12,12 +12,32 @@ type DynamicScene interface {
 
 type StaticScene interface {
 	Flatten() ([]objects.StaticBasicObject, Background)
+	// GetLights returns the lights in camera space. A scene without lights is rendered unshaded.
+	GetLights() []Light
+	// GetCamera returns the camera projection that the scene is viewed through, unset values take on defaults
+	GetCamera() geometry.Camera
 }
 
 type Background interface {
 	GetColor(x, y float64) colors.Color
 }
 
+// FilteredBackground is a Background that can average its colors over the footprint of a pixel,
+// in image space, see textures.FilteredTexture
+type FilteredBackground interface {
+	Background
+	GetFilteredColor(x, y float64, f geometry.Footprint) colors.Color
+}
+
+// FilteredBackgroundColor returns the color of the background averaged over the footprint around (x,y),
+// or just the color at (x,y) if the background can't be filtered
+func FilteredBackgroundColor(b Background, x, y float64, f geometry.Footprint) colors.Color {
+	if filtered, ok := b.(FilteredBackground); ok {
+		return filtered.GetFilteredColor(x, y, f)
+	}
+	return b.GetColor(x, y)
+}
+
 type DynamicBackground interface {
 	GetFrame(t float64) Background
 }
@@ -26,6 +46,8 @@ type ObjectScene struct {
 	Objects []objects.StaticObject
 	Background
 	CameraDirection geometry.Direction
+	Camera          geometry.Camera
+	Lights          []Light
 }
 
 func (s ObjectScene) Flatten() ([]objects.StaticBasicObject, Background) {
@@ -43,11 +65,28 @@ func (s ObjectScene) Flatten() ([]objects.StaticBasicObject, Background) {
 	return tris, s.Background
 }
 
+func (s ObjectScene) GetLights() []Light {
+	inverseMatrix := s.CameraDirection.InverseHomoMatrix()
+	lights := make([]Light, len(s.Lights))
+	for i, light := range s.Lights {
+		lights[i] = light.ApplyMatrix(inverseMatrix)
+	}
+	return lights
+}
+
+// GetCamera returns the camera as set on the scene, the renderer fills in any unset values
+func (s ObjectScene) GetCamera() geometry.Camera {
+	return s.Camera
+}
+
 // implements DynamicScene
 type CombinedDynamicScene struct {
 	Objects    []objects.DynamicObjectInt
 	CameraPath geometry.Path
+	Camera     geometry.Camera         // unset values are taken from geometry.DefaultCamera, except for the aspect ratio
+	Focus      func(t float64) float64 // if set, overrides the camera's focus distance at each frame
 	Background DynamicBackground
+	Lights     []DynamicLight
 }
 
 func (s CombinedDynamicScene) GetFrame(t float64) StaticScene {
@@ -57,13 +96,41 @@ func (s CombinedDynamicScene) GetFrame(t float64) StaticScene {
 		frameObjects[i] = obj
 	}
 
+	camera := s.Camera
+	if s.Focus != nil {
+		camera.FocusDistance = s.Focus(t)
+	}
 	direction := geometry.OriginPosition
 	if s.CameraPath != nil {
 		direction = s.CameraPath.GetDirection(t)
 	}
+	frameLights := make([]Light, len(s.Lights))
+	for i, light := range s.Lights {
+		frameLights[i] = light.GetFrame(t)
+	}
 	return ObjectScene{
 		Objects:         frameObjects,
 		Background:      s.Background.GetFrame(t),
 		CameraDirection: direction,
+		Camera:          camera,
+		Lights:          frameLights,
 	}
 }
+
+// WithCamera returns a copy of the scene, viewed through the camera
+func (s CombinedDynamicScene) WithCamera(c geometry.Camera) CombinedDynamicScene {
+	s.Camera = c
+	return s
+}
+
+// WithDynamicFocus returns a copy of the scene, with the camera focused at distance f(t) at each frame
+func (s CombinedDynamicScene) WithDynamicFocus(f func(t float64) float64) CombinedDynamicScene {
+	s.Focus = f
+	return s
+}
+
+// WithLights returns a copy of the scene, lit by the provided lights in addition to any existing ones
+func (s CombinedDynamicScene) WithLights(lights ...DynamicLight) CombinedDynamicScene {
+	s.Lights = append(append([]DynamicLight{}, s.Lights...), lights...)
+	return s
+}
@@ -0,0 +1,191 @@
+package sdf
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/libeks/go-scene-renderer/geometry"
+	"github.com/libeks/go-scene-renderer/maths"
+)
+
+// Union returns the points inside any of the shapes
+func Union(shapes ...Shape) Shape {
+	return union{shapes: shapes}
+}
+
+type union struct {
+	shapes []Shape
+}
+
+func (u union) Distance(p geometry.Point) float64 {
+	d := math.Inf(1)
+	for _, s := range u.shapes {
+		d = min(d, s.Distance(p))
+	}
+	return d
+}
+
+func (u union) Bounds() geometry.AABB {
+	bounds := geometry.EmptyAABB
+	for _, s := range u.shapes {
+		bounds = bounds.Union(s.Bounds())
+	}
+	return bounds
+}
+
+// Intersect returns the points inside both a and b
+func Intersect(a, b Shape) Shape {
+	return SmoothIntersect(0, a, b)
+}
+
+// Subtract returns the points inside a, but not inside b
+func Subtract(a, b Shape) Shape {
+	return SmoothSubtract(0, a, b)
+}
+
+// SmoothUnion returns the points inside either a or b, blended where they meet, over a width of k
+// (see maths.SmoothMin). The blend fills the crease between them, like a fillet.
+func SmoothUnion(k float64, a, b Shape) Shape {
+	return smoothUnion{k: k, a: a, b: b}
+}
+
+type smoothUnion struct {
+	k    float64
+	a, b Shape
+}
+
+func (s smoothUnion) Distance(p geometry.Point) float64 {
+	return maths.SmoothMin(s.a.Distance(p), s.b.Distance(p), s.k)
+}
+
+// Bounds returns the union of the bounds, grown by how far the blend can reach past the shapes
+func (s smoothUnion) Bounds() geometry.AABB {
+	return s.a.Bounds().Union(s.b.Bounds()).Expand(s.k / 4)
+}
+
+// SmoothIntersect returns the points inside both a and b, with the edge where their surfaces meet
+// rounded off over a width of k
+func SmoothIntersect(k float64, a, b Shape) Shape {
+	return smoothIntersection{k: k, a: a, b: b}
+}
+
+type smoothIntersection struct {
+	k    float64
+	a, b Shape
+}
+
+func (s smoothIntersection) Distance(p geometry.Point) float64 {
+	return -maths.SmoothMin(-s.a.Distance(p), -s.b.Distance(p), s.k)
+}
+
+func (s smoothIntersection) Bounds() geometry.AABB {
+	return s.a.Bounds().Intersect(s.b.Bounds())
+}
+
+// SmoothSubtract returns the points inside a, with b cut out of it, with the edge of the cut rounded off
+// over a width of k
+func SmoothSubtract(k float64, a, b Shape) Shape {
+	return smoothIntersection{k: k, a: a, b: complement{b}}
+}
+
+// complement is the points outside of a shape. It has no bounds of its own, so it is only used
+// intersected with another shape.
+type complement struct {
+	Shape
+}
+
+func (c complement) Distance(p geometry.Point) float64 {
+	return -c.Shape.Distance(p)
+}
+
+func (c complement) Bounds() geometry.AABB {
+	return geometry.InfiniteAABB
+}
+
+// Round returns the points within the radius of the shape, which rounds off its edges
+func Round(s Shape, radius float64) Shape {
+	return rounded{shape: s, radius: radius}
+}
+
+type rounded struct {
+	shape  Shape
+	radius float64
+}
+
+func (r rounded) Distance(p geometry.Point) float64 {
+	return r.shape.Distance(p) - r.radius
+}
+
+func (r rounded) Bounds() geometry.AABB {
+	return r.shape.Bounds().Expand(r.radius)
+}
+
+// Translate returns the shape moved by v
+func Translate(s Shape, v geometry.Vector3D) Shape {
+	return Transform(s, geometry.TranslationMatrix(v))
+}
+
+// Transform returns the shape moved by the matrix, which has to be a rigid motion, made of rotations and
+// translations, so that it keeps the distances to the shape. Use Scale to resize shapes.
+func Transform(s Shape, m geometry.HomogeneusMatrix) Shape {
+	inverse, ok := m.Inverse()
+	if !ok {
+		panic(fmt.Errorf("could not invert matrix %s", m))
+	}
+	return transformed{shape: s, m: m, inverse: inverse}
+}
+
+type transformed struct {
+	shape      Shape
+	m, inverse geometry.HomogeneusMatrix
+}
+
+func (t transformed) Distance(p geometry.Point) float64 {
+	local, ok := t.inverse.MultVect(p.ToHomogenous()).ToPoint()
+	if !ok {
+		panic(fmt.Errorf("could not apply matrix %s to point %s", t.inverse, p))
+	}
+	return t.shape.Distance(local)
+}
+
+// Bounds returns the box around the moved corners of the bounds of the shape
+func (t transformed) Bounds() geometry.AABB {
+	bounds := t.shape.Bounds()
+	if bounds.IsEmpty() {
+		return bounds
+	}
+	corners := bounds.Corners()
+	for i, corner := range corners {
+		p, ok := t.m.MultVect(corner.ToHomogenous()).ToPoint()
+		if !ok {
+			panic(fmt.Errorf("could not apply matrix %s to point %s", t.m, corner))
+		}
+		corners[i] = p
+	}
+	return geometry.AABBFromPoints(corners[:]...)
+}
+
+// Scale returns the shape resized around the origin by the factor, which has to be positive
+func Scale(s Shape, factor float64) Shape {
+	return scaled{shape: s, factor: factor}
+}
+
+type scaled struct {
+	shape  Shape
+	factor float64
+}
+
+func (s scaled) Distance(p geometry.Point) float64 {
+	return s.factor * s.shape.Distance(geometry.Point(p.Vector().ScalarMultiply(1/s.factor)))
+}
+
+func (s scaled) Bounds() geometry.AABB {
+	bounds := s.shape.Bounds()
+	if bounds.IsEmpty() {
+		return bounds
+	}
+	return geometry.AABB{
+		Min: geometry.Point(bounds.Min.Vector().ScalarMultiply(s.factor)),
+		Max: geometry.Point(bounds.Max.Vector().ScalarMultiply(s.factor)),
+	}
+}
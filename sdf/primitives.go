@@ -0,0 +1,109 @@
+package sdf
+
+import (
+	"math"
+
+	"github.com/libeks/go-scene-renderer/geometry"
+)
+
+// Sphere returns the sphere around the origin with the radius
+func Sphere(radius float64) Shape {
+	return sphere{radius: radius}
+}
+
+type sphere struct {
+	radius float64
+}
+
+func (s sphere) Distance(p geometry.Point) float64 {
+	return p.Vector().Mag() - s.radius
+}
+
+func (s sphere) Bounds() geometry.AABB {
+	return symmetricBounds(geometry.V3(s.radius, s.radius, s.radius))
+}
+
+// Box returns the box around the origin, extending by half along each axis
+func Box(half geometry.Vector3D) Shape {
+	return box{half: half}
+}
+
+type box struct {
+	half geometry.Vector3D
+}
+
+func (b box) Distance(p geometry.Point) float64 {
+	q := geometry.V3(math.Abs(p.X)-b.half.X, math.Abs(p.Y)-b.half.Y, math.Abs(p.Z)-b.half.Z)
+	outside := geometry.V3(max(q.X, 0), max(q.Y, 0), max(q.Z, 0)).Mag()
+	inside := min(max(q.X, q.Y, q.Z), 0)
+	return outside + inside
+}
+
+func (b box) Bounds() geometry.AABB {
+	return symmetricBounds(b.half)
+}
+
+// Torus returns the torus around the y axis, with the center of its tube at the major radius,
+// and the tube of the minor radius
+func Torus(majorRadius, minorRadius float64) Shape {
+	return torus{major: majorRadius, minor: minorRadius}
+}
+
+type torus struct {
+	major, minor float64
+}
+
+func (t torus) Distance(p geometry.Point) float64 {
+	return math.Hypot(math.Hypot(p.X, p.Z)-t.major, p.Y) - t.minor
+}
+
+func (t torus) Bounds() geometry.AABB {
+	r := t.major + t.minor
+	return symmetricBounds(geometry.V3(r, t.minor, r))
+}
+
+// Cylinder returns the capped cylinder around the y axis with the radius, from -halfHeight to halfHeight
+func Cylinder(radius, halfHeight float64) Shape {
+	return cylinder{radius: radius, halfHeight: halfHeight}
+}
+
+type cylinder struct {
+	radius, halfHeight float64
+}
+
+func (c cylinder) Distance(p geometry.Point) float64 {
+	dx, dy := math.Hypot(p.X, p.Z)-c.radius, math.Abs(p.Y)-c.halfHeight
+	return min(max(dx, dy), 0) + math.Hypot(max(dx, 0), max(dy, 0))
+}
+
+func (c cylinder) Bounds() geometry.AABB {
+	return symmetricBounds(geometry.V3(c.radius, c.halfHeight, c.radius))
+}
+
+// Capsule returns the points within the radius of the segment from a to b
+func Capsule(a, b geometry.Point, radius float64) Shape {
+	return capsule{a: a, b: b, radius: radius}
+}
+
+type capsule struct {
+	a, b   geometry.Point
+	radius float64
+}
+
+func (c capsule) Distance(p geometry.Point) float64 {
+	ab, ap := c.b.Subtract(c.a), p.Subtract(c.a)
+	h := 0.0
+	if abab := ab.DotProduct(ab); abab > 0 {
+		h = max(0, min(1, ap.DotProduct(ab)/abab))
+	}
+	return ap.AddVector(ab.ScalarMultiply(-h)).Mag() - c.radius
+}
+
+func (c capsule) Bounds() geometry.AABB {
+	return geometry.AABBFromPoints(c.a, c.b).Expand(c.radius)
+}
+
+// symmetricBounds returns the box around the origin, extending by half along each axis
+func symmetricBounds(half geometry.Vector3D) geometry.AABB {
+	return geometry.AABB{Min: geometry.Point(half.ScalarMultiply(-1)), Max: geometry.Point(half)}
+}
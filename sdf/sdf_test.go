@@ -0,0 +1,89 @@
+package sdf
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"github.com/libeks/go-scene-renderer/geometry"
+)
+
+func TestDistance(t *testing.T) {
+	twoSpheres := []Shape{
+		Translate(Sphere(1), geometry.V3(-1, 0, 0)),
+		Translate(Sphere(1), geometry.V3(1, 0, 0)),
+	}
+	for _, tc := range []struct {
+		name  string
+		shape Shape
+		p     geometry.Point
+		want  float64
+	}{
+		{name: "outside a sphere", shape: Sphere(1), p: geometry.Pt(0, 3, 0), want: 2},
+		{name: "inside a sphere", shape: Sphere(1), p: geometry.Pt(0.5, 0, 0), want: -0.5},
+		{name: "beside a box", shape: Box(geometry.V3(1, 2, 3)), p: geometry.Pt(3, 0, 0), want: 2},
+		{name: "off the corner of a box", shape: Box(geometry.V3(1, 1, 1)), p: geometry.Pt(4, 5, 1), want: 5},
+		{name: "inside a box", shape: Box(geometry.V3(1, 2, 3)), p: geometry.Pt(0.5, 0, 0), want: -0.5},
+		{name: "in the hole of a torus", shape: Torus(1, 0.25), p: geometry.OriginPoint, want: 0.75},
+		{name: "above a cylinder", shape: Cylinder(1, 2), p: geometry.Pt(0.5, 3, 0), want: 1},
+		{name: "off the rim of a cylinder", shape: Cylinder(1, 2), p: geometry.Pt(4, 6, 0), want: 5},
+		{name: "beside a capsule", shape: Capsule(geometry.Pt(0, -1, 0), geometry.Pt(0, 1, 0), 0.5), p: geometry.Pt(2, 0.5, 0), want: 1.5},
+		{name: "past the end of a capsule", shape: Capsule(geometry.Pt(0, -1, 0), geometry.Pt(0, 1, 0), 0.5), p: geometry.Pt(0, 3, 0), want: 1.5},
+		{name: "union", shape: Union(twoSpheres...), p: geometry.Pt(3, 0, 0), want: 1},
+		{name: "intersection", shape: Intersect(twoSpheres[0], twoSpheres[1]), p: geometry.Pt(3, 0, 0), want: 3},
+		{name: "subtraction", shape: Subtract(Sphere(2), Sphere(1)), p: geometry.OriginPoint, want: 1},
+		{name: "smooth union at the seam", shape: SmoothUnion(1, twoSpheres[0], twoSpheres[1]), p: geometry.Pt(0, 1, 0), want: math.Sqrt2 - 1 - 0.25},
+		{name: "smooth union far from the seam", shape: SmoothUnion(1, twoSpheres[0], twoSpheres[1]), p: geometry.Pt(4, 0, 0), want: 2},
+		{name: "rounded box", shape: Round(Box(geometry.V3(1, 1, 1)), 0.5), p: geometry.Pt(3, 0, 0), want: 1.5},
+		{name: "rotated box", shape: Transform(Box(geometry.V3(2, 1, 1)), geometry.RotateMatrixZ(math.Pi/2)), p: geometry.Pt(0, 3, 0), want: 1},
+		{name: "scaled sphere", shape: Scale(Sphere(1), 2), p: geometry.Pt(0, 0, 5), want: 3},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, tc.shape.Distance(tc.p), cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Errorf("Distance() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestBounds(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		shape Shape
+		want  geometry.AABB
+	}{
+		{
+			name:  "capsule",
+			shape: Capsule(geometry.Pt(0, -1, 0), geometry.Pt(2, 1, 0), 0.5),
+			want:  geometry.AABB{Min: geometry.Pt(-0.5, -1.5, -0.5), Max: geometry.Pt(2.5, 1.5, 0.5)},
+		},
+		{
+			name:  "smooth union",
+			shape: SmoothUnion(1, Sphere(1), Translate(Sphere(1), geometry.V3(2, 0, 0))),
+			want:  geometry.AABB{Min: geometry.Pt(-1.25, -1.25, -1.25), Max: geometry.Pt(3.25, 1.25, 1.25)},
+		},
+		{
+			name:  "subtraction",
+			shape: Subtract(Sphere(1), Box(geometry.V3(2, 2, 2))),
+			want:  geometry.AABB{Min: geometry.Pt(-1, -1, -1), Max: geometry.Pt(1, 1, 1)},
+		},
+		{
+			name:  "rotated cylinder",
+			shape: Transform(Cylinder(0.5, 2), geometry.RotateMatrixZ(math.Pi/2)),
+			want:  geometry.AABB{Min: geometry.Pt(-2, -0.5, -0.5), Max: geometry.Pt(2, 0.5, 0.5)},
+		},
+		{
+			name:  "scaled torus",
+			shape: Scale(Torus(1, 0.5), 2),
+			want:  geometry.AABB{Min: geometry.Pt(-3, -1, -3), Max: geometry.Pt(3, 1, 3)},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := cmp.Diff(tc.want, tc.shape.Bounds(), cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Errorf("Bounds() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+// Package sdf describes solids by their signed distance functions, which can be combined and blended,
+// and rendered by sphere tracing with objects.SDFObject.
+package sdf
+
+import "github.com/libeks/go-scene-renderer/geometry"
+
+// Shape is a solid given by its signed distance function
+type Shape interface {
+	// Distance returns the distance from p to the surface of the shape, negative inside of it.
+	// It may underestimate the distance, but not overestimate it, so that sphere tracing doesn't step over
+	// the surface.
+	Distance(p geometry.Point) float64
+	// Bounds returns a box that contains the whole shape
+	Bounds() geometry.AABB
+}
@@ -0,0 +1,79 @@
+package textures
+
+import (
+	"github.com/libeks/go-scene-renderer/colors"
+	"github.com/libeks/go-scene-renderer/geometry"
+)
+
+// FilteredTexture is a Texture that can average its colors over the footprint of a pixel, so that details
+// smaller than a pixel are blurred rather than aliased
+type FilteredTexture interface {
+	Texture
+	GetFilteredColor(b, c float64, f geometry.Footprint) colors.Color
+}
+
+// FilteredTransparentTexture is the TransparentTexture version of FilteredTexture
+type FilteredTransparentTexture interface {
+	TransparentTexture
+	GetFilteredColor(b, c float64, f geometry.Footprint) colors.AlphaColor
+}
+
+// FilteredAnimatedTexture is the AnimatedTexture version of FilteredTexture
+type FilteredAnimatedTexture interface {
+	AnimatedTexture
+	GetFilteredFrameColor(x, y, t float64, f geometry.Footprint) colors.Color
+}
+
+// FilteredTextureColor returns the color of the texture averaged over the footprint around (b,c). FilteredTextures
+// are band-limited, other textures are sampled at points spread across the footprint.
+func FilteredTextureColor(t Texture, b, c float64, f geometry.Footprint) colors.Color {
+	if filtered, ok := t.(FilteredTexture); ok {
+		return filtered.GetFilteredColor(b, c, f)
+	}
+	if f.IsZero() {
+		return t.GetTextureColor(b, c)
+	}
+	// colors are clamped when added, so each sample is scaled down first
+	var sum colors.Color
+	f.ForEachSample(b, c, func(b, c float64) {
+		sum = sum.Add(t.GetTextureColor(b, c).Scale(f.SampleWeight()))
+	})
+	return sum
+}
+
+// FilteredTransparentColor is FilteredTextureColor for TransparentTextures. Supersampled colors are weighted
+// by their opacity.
+func FilteredTransparentColor(t TransparentTexture, b, c float64, f geometry.Footprint) colors.AlphaColor {
+	if filtered, ok := t.(FilteredTransparentTexture); ok {
+		return filtered.GetFilteredColor(b, c, f)
+	}
+	if f.IsZero() {
+		return t.GetTextureColor(b, c)
+	}
+	var sum colors.Color
+	alpha := 0.0
+	f.ForEachSample(b, c, func(b, c float64) {
+		color := t.GetTextureColor(b, c)
+		sum = sum.Add(color.Color.Scale(color.A * f.SampleWeight()))
+		alpha += color.A * f.SampleWeight()
+	})
+	if alpha <= 0 {
+		return colors.Transparent
+	}
+	return sum.Scale(1 / alpha).WithAlpha(alpha)
+}
+
+// FilteredFrameColor is FilteredTextureColor for AnimatedTextures
+func FilteredFrameColor(a AnimatedTexture, x, y, t float64, f geometry.Footprint) colors.Color {
+	if filtered, ok := a.(FilteredAnimatedTexture); ok {
+		return filtered.GetFilteredFrameColor(x, y, t, f)
+	}
+	if f.IsZero() {
+		return a.GetFrameColor(x, y, t)
+	}
+	var sum colors.Color
+	f.ForEachSample(x, y, func(x, y float64) {
+		sum = sum.Add(a.GetFrameColor(x, y, t).Scale(f.SampleWeight()))
+	})
+	return sum
+}
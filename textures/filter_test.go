@@ -0,0 +1,112 @@
+package textures
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"github.com/libeks/go-scene-renderer/colors"
+	"github.com/libeks/go-scene-renderer/geometry"
+	"github.com/libeks/go-scene-renderer/sampler"
+)
+
+// stripes is black left of 0.5, and white right of it, it can't be filtered
+type stripes struct{}
+
+func (stripes) GetTextureColor(x, y float64) colors.Color {
+	if x < 0.5 {
+		return colors.Black
+	}
+	return colors.White
+}
+
+func TestFilteredTextureColor(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		texture   Texture
+		x, y      float64
+		footprint geometry.Footprint
+		want      colors.Color
+	}{
+		{
+			name:    "checkerboard without a footprint",
+			texture: Checkerboard{Squares: 4},
+			x:       0.3,
+			y:       0.1,
+			want:    colors.White,
+		},
+		{
+			name:      "checkerboard within a square",
+			texture:   Checkerboard{Squares: 4},
+			x:         0.375,
+			y:         0.125,
+			footprint: geometry.Footprint{DUDX: 0.1, DVDY: 0.1},
+			want:      colors.White,
+		},
+		{
+			name:      "checkerboard across two squares",
+			texture:   Checkerboard{Squares: 4},
+			x:         0.25,
+			y:         0.125,
+			footprint: geometry.Footprint{DUDX: 0.1, DVDY: 0.1},
+			want:      gray,
+		},
+		{
+			name:      "checkerboard across many squares",
+			texture:   Checkerboard{Squares: 16},
+			x:         0.3,
+			y:         0.6,
+			footprint: geometry.Footprint{DUDX: 0.25, DVDY: 0.25},
+			want:      gray,
+		},
+		{
+			name:      "circles far from the center",
+			texture:   BinarySamplerWithColors{StaticSampler: sampler.ConcentricCircles(0.01), On: colors.Black, Off: colors.White},
+			x:         0.9,
+			y:         0.5,
+			footprint: geometry.Footprint{DUDX: 0.01, DVDY: 0.01},
+			want:      gray,
+		},
+		{
+			name:      "supersampled across the edge",
+			texture:   stripes{},
+			x:         0.5,
+			y:         0.5,
+			footprint: geometry.Footprint{DUDX: 0.1, DVDY: 0.1},
+			want:      gray,
+		},
+		{
+			name:      "supersampled on one side of the edge",
+			texture:   stripes{},
+			x:         0.3,
+			y:         0.5,
+			footprint: geometry.Footprint{DUDX: 0.1, DVDY: 0.1},
+			want:      colors.Black,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := FilteredTextureColor(tc.texture, tc.x, tc.y, tc.footprint)
+			if diff := cmp.Diff(tc.want, got, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Errorf("FilteredTextureColor() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFilteredSineWave(t *testing.T) {
+	wave := GetAniTextureFromSampler(
+		sampler.Sigmoid{Sampler: sampler.SineWave{Factor: 400}, Ratio: 5},
+		colors.SimpleGradient{Start: colors.White, End: colors.Black},
+	)
+	// waves much finer than the footprint blur to the middle of the gradient
+	got := FilteredFrameColor(wave, 0.3, 0.4, 0, geometry.Footprint{DUDX: 0.05, DVDY: 0.05})
+	if diff := cmp.Diff(gray, got, cmpopts.EquateApprox(0, 1e-6)); diff != "" {
+		t.Errorf("FilteredFrameColor() mismatch (-want +got):\n%s", diff)
+	}
+	// without a footprint, the wave is sampled at the point
+	got = FilteredFrameColor(wave, 0.3, 0.4, 0, geometry.Footprint{})
+	if diff := cmp.Diff(wave.GetFrameColor(0.3, 0.4, 0), got); diff != "" {
+		t.Errorf("FilteredFrameColor() without a footprint mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/libeks/go-scene-renderer/colors"
+	"github.com/libeks/go-scene-renderer/geometry"
 	"github.com/libeks/go-scene-renderer/sampler"
 )
 
@@ -27,6 +28,10 @@ func (f dynamicTextureFrameHelper) GetTextureColor(x, y float64) colors.Color {
 	return f.ani.GetFrameColor(x, y, f.t)
 }
 
+func (f dynamicTextureFrameHelper) GetFilteredColor(x, y float64, fp geometry.Footprint) colors.Color {
+	return FilteredFrameColor(f.ani, x, y, f.t, fp)
+}
+
 func DynamicFromAnimatedTexture(ani AnimatedTexture) DynamicTexture {
 	return dynamicTextureHelper{
 		ani: ani,
@@ -49,6 +54,10 @@ func (s samplerColorer) GetFrameColor(x, y, t float64) colors.Color {
 	return s.gradient.Interpolate(s.sampler.GetFrameValue(x, y, t))
 }
 
+func (s samplerColorer) GetFilteredFrameColor(x, y, t float64, f geometry.Footprint) colors.Color {
+	return s.gradient.Interpolate(sampler.FilteredFrameValue(s.sampler, x, y, t, f))
+}
+
 func GetAniTextureFromSampler(s sampler.Sampler, g colors.Gradient) AnimatedTexture {
 	return samplerColorer{
 		sampler:  s,
@@ -131,3 +140,12 @@ func (s BinarySamplerWithColors) GetTextureColor(x, y float64) colors.Color {
 	}
 	panic(fmt.Errorf("BinarySampler returned value %.3f", val))
 }
+
+// GetFilteredColor blends Off and On by the share of the footprint where the sampler is on
+func (s BinarySamplerWithColors) GetFilteredColor(x, y float64, f geometry.Footprint) colors.Color {
+	if f.IsZero() {
+		return s.GetTextureColor(x, y)
+	}
+	on := sampler.FilteredValue(s.StaticSampler, x, y, f)
+	return s.Off.Scale(1 - on).Add(s.On.Scale(on))
+}
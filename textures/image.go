@@ -0,0 +1,282 @@
+package textures
+
+import (
+	"fmt"
+	"image"
+	_ "image/jpeg" // register JPEG decoding with image.Decode
+	_ "image/png"  // register PNG decoding with image.Decode
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/libeks/go-scene-renderer/colors"
+	"github.com/libeks/go-scene-renderer/geometry"
+)
+
+// WrapMode sets how an ImageTexture is sampled outside of (0,1)
+type WrapMode int
+
+const (
+	WrapRepeat WrapMode = iota // the image is tiled
+	WrapClamp                  // the colors at the edges of the image extend outwards
+	WrapMirror                 // the image is tiled, with every other tile mirrored
+)
+
+// ParseWrapMode parses the name of a WrapMode
+func ParseWrapMode(wrap string) (WrapMode, error) {
+	switch wrap {
+	case "repeat":
+		return WrapRepeat, nil
+	case "clamp":
+		return WrapClamp, nil
+	case "mirror":
+		return WrapMirror, nil
+	}
+	return 0, fmt.Errorf("could not parse wrap mode '%s', expect repeat, clamp or mirror", wrap)
+}
+
+// FilterMode sets how an ImageTexture blends its pixels
+type FilterMode int
+
+const (
+	FilterNearest   FilterMode = iota // the color of the closest pixel of the closest mipmap level
+	FilterBilinear                    // the four closest pixels of the closest mipmap level, weighted by distance
+	FilterTrilinear                   // bilinear filtering of the two mipmap levels closest to the footprint, blended
+)
+
+// ParseFilterMode parses the name of a FilterMode
+func ParseFilterMode(filter string) (FilterMode, error) {
+	switch filter {
+	case "nearest":
+		return FilterNearest, nil
+	case "bilinear":
+		return FilterBilinear, nil
+	case "trilinear":
+		return FilterTrilinear, nil
+	}
+	return 0, fmt.Errorf("could not parse filter mode '%s', expect nearest, bilinear or trilinear", filter)
+}
+
+// ImageTexture is a texture sampled from an image, with (0,0) at its bottom left corner and (1,1) at its top right.
+// Outside of that range, it is wrapped by its WrapMode, repeating by default. It keeps a mipmap of the image,
+// successively halved in size, and looks up the level whose pixels are about as big as the footprint of
+// the pixel being rendered, so that images seen from afar are averaged rather than aliased.
+type ImageTexture struct {
+	levels []imageLevel // mipmap, the full image first
+	wrap   WrapMode
+	filter FilterMode
+}
+
+// imageLevel is one level of an ImageTexture's mipmap
+type imageLevel struct {
+	width  int
+	height int
+	pixels []colors.Color // bottom row first
+}
+
+// NewImageTexture returns a texture of the image's colors, with trilinear filtering
+func NewImageTexture(img image.Image) ImageTexture {
+	bounds := img.Bounds()
+	level := imageLevel{
+		width:  bounds.Dx(),
+		height: bounds.Dy(),
+		pixels: make([]colors.Color, bounds.Dx()*bounds.Dy()),
+	}
+	for y := range level.height {
+		for x := range level.width {
+			level.pixels[(level.height-1-y)*level.width+x] = colors.FromColor(img.At(bounds.Min.X+x, bounds.Min.Y+y)).Color
+		}
+	}
+	levels := []imageLevel{level}
+	for level.width > 1 || level.height > 1 {
+		level = level.halve()
+		levels = append(levels, level)
+	}
+	return ImageTexture{
+		levels: levels,
+		filter: FilterTrilinear,
+	}
+}
+
+// ReadImageTexture decodes a PNG or JPEG image into a texture
+func ReadImageTexture(r io.Reader) (ImageTexture, error) {
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return ImageTexture{}, err
+	}
+	if img.Bounds().Empty() {
+		return ImageTexture{}, fmt.Errorf("image is empty")
+	}
+	return NewImageTexture(img), nil
+}
+
+// LoadImageTexture reads the PNG or JPEG file at path into a texture
+func LoadImageTexture(path string) (ImageTexture, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return ImageTexture{}, err
+	}
+	defer f.Close()
+	t, err := ReadImageTexture(f)
+	if err != nil {
+		return ImageTexture{}, fmt.Errorf("could not read image %s: %w", path, err)
+	}
+	return t, nil
+}
+
+func (t ImageTexture) WithWrap(wrap WrapMode) ImageTexture {
+	t.wrap = wrap
+	return t
+}
+
+func (t ImageTexture) WithFilter(filter FilterMode) ImageTexture {
+	t.filter = filter
+	return t
+}
+
+// GetTextureColor returns the color of the full-size image at (x,y)
+func (t ImageTexture) GetTextureColor(x, y float64) colors.Color {
+	return t.GetFilteredColor(x, y, geometry.Footprint{})
+}
+
+// GetFilteredColor returns the color at (x,y), from the mipmap level that matches the footprint
+func (t ImageTexture) GetFilteredColor(x, y float64, f geometry.Footprint) colors.Color {
+	base := t.levels[0]
+	// the footprint's extent in pixels of the full image, along its longer side
+	lod := math.Log2(max(
+		math.Hypot(f.DUDX*float64(base.width), f.DVDX*float64(base.height)),
+		math.Hypot(f.DUDY*float64(base.width), f.DVDY*float64(base.height)),
+	))
+	lod = max(0, min(lod, float64(len(t.levels)-1)))
+	switch t.filter {
+	case FilterNearest:
+		return t.levels[int(math.Round(lod))].nearest(x, y, t.wrap)
+	case FilterBilinear:
+		return t.levels[int(math.Round(lod))].bilinear(x, y, t.wrap)
+	}
+	lower := int(lod)
+	if lower == len(t.levels)-1 {
+		return t.levels[lower].bilinear(x, y, t.wrap)
+	}
+	ratio := lod - float64(lower)
+	return t.levels[lower].bilinear(x, y, t.wrap).Scale(1 - ratio).Add(t.levels[lower+1].bilinear(x, y, t.wrap).Scale(ratio))
+}
+
+// halve returns the next level of the mipmap, each pixel averaging up to 2x2 pixels of this one
+func (l imageLevel) halve() imageLevel {
+	next := imageLevel{
+		width:  max(1, l.width/2),
+		height: max(1, l.height/2),
+	}
+	next.pixels = make([]colors.Color, next.width*next.height)
+	for y := range next.height {
+		for x := range next.width {
+			xEnd, yEnd := min(2*x+2, l.width), min(2*y+2, l.height)
+			// colors are clamped when added, so each pixel is scaled down first
+			weight := 1 / float64((xEnd-2*x)*(yEnd-2*y))
+			var sum colors.Color
+			for sy := 2 * y; sy < yEnd; sy++ {
+				for sx := 2 * x; sx < xEnd; sx++ {
+					sum = sum.Add(l.pixels[sy*l.width+sx].Scale(weight))
+				}
+			}
+			next.pixels[y*next.width+x] = sum
+		}
+	}
+	return next
+}
+
+func (l imageLevel) at(px, py int, wrap WrapMode) colors.Color {
+	return l.pixels[wrapIndex(py, l.height, wrap)*l.width+wrapIndex(px, l.width, wrap)]
+}
+
+func (l imageLevel) nearest(x, y float64, wrap WrapMode) colors.Color {
+	return l.at(int(math.Floor(x*float64(l.width))), int(math.Floor(y*float64(l.height))), wrap)
+}
+
+// bilinear interpolates between the centers of the four pixels around (x,y)
+func (l imageLevel) bilinear(x, y float64, wrap WrapMode) colors.Color {
+	sx, sy := x*float64(l.width)-0.5, y*float64(l.height)-0.5
+	fx, fy := math.Floor(sx), math.Floor(sy)
+	px, py := int(fx), int(fy)
+	rx, ry := sx-fx, sy-fy
+	bottom := l.at(px, py, wrap).Scale(1 - rx).Add(l.at(px+1, py, wrap).Scale(rx))
+	top := l.at(px, py+1, wrap).Scale(1 - rx).Add(l.at(px+1, py+1, wrap).Scale(rx))
+	return bottom.Scale(1 - ry).Add(top.Scale(ry))
+}
+
+// wrapIndex maps the pixel index i into [0,n)
+func wrapIndex(i, n int, wrap WrapMode) int {
+	switch wrap {
+	case WrapClamp:
+		return max(0, min(i, n-1))
+	case WrapMirror:
+		i = repeatIndex(i, 2*n)
+		if i >= n {
+			i = 2*n - 1 - i
+		}
+		return i
+	}
+	return repeatIndex(i, n)
+}
+
+// repeatIndex wraps i into [0,n)
+func repeatIndex(i, n int) int {
+	i %= n
+	if i < 0 {
+		i += n
+	}
+	return i
+}
+
+// ImageSequence is a DynamicTexture that shows its frames one after another, such as the frames of a video,
+// each for an equal part of the animation. The sequence is played Loops times, and at least once.
+type ImageSequence struct {
+	Frames []ImageTexture
+	Loops  int
+}
+
+// LoadImageSequence reads the PNG or JPEG files matching the glob pattern, in lexical order, into a sequence
+// that is played once
+func LoadImageSequence(pattern string) (ImageSequence, error) {
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return ImageSequence{}, err
+	}
+	if len(files) == 0 {
+		return ImageSequence{}, fmt.Errorf("no images match %s", pattern)
+	}
+	slices.Sort(files)
+	frames := make([]ImageTexture, len(files))
+	for i, file := range files {
+		if frames[i], err = LoadImageTexture(file); err != nil {
+			return ImageSequence{}, err
+		}
+	}
+	return ImageSequence{Frames: frames, Loops: 1}, nil
+}
+
+// WithWrap sets the wrap mode of every frame
+func (s ImageSequence) WithWrap(wrap WrapMode) ImageSequence {
+	s.Frames = slices.Clone(s.Frames)
+	for i := range s.Frames {
+		s.Frames[i] = s.Frames[i].WithWrap(wrap)
+	}
+	return s
+}
+
+// WithFilter sets the filter mode of every frame
+func (s ImageSequence) WithFilter(filter FilterMode) ImageSequence {
+	s.Frames = slices.Clone(s.Frames)
+	for i := range s.Frames {
+		s.Frames[i] = s.Frames[i].WithFilter(filter)
+	}
+	return s
+}
+
+func (s ImageSequence) GetFrame(t float64) Texture {
+	n := len(s.Frames)
+	return s.Frames[repeatIndex(int(math.Floor(t*float64(max(1, s.Loops)*n))), n)]
+}
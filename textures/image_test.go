@@ -0,0 +1,146 @@
+package textures
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+
+	"github.com/libeks/go-scene-renderer/colors"
+	"github.com/libeks/go-scene-renderer/geometry"
+)
+
+// rowImage returns an image of a single row of pixels, left to right
+func rowImage(pixels ...color.Color) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, len(pixels), 1))
+	for x, c := range pixels {
+		img.Set(x, 0, c)
+	}
+	return img
+}
+
+var (
+	red   = color.RGBA{R: 255, A: 255}
+	blue  = color.RGBA{B: 255, A: 255}
+	black = color.RGBA{A: 255}
+	white = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	gray  = colors.Color{R: 0.5, G: 0.5, B: 0.5}
+)
+
+func TestImageTextureWrap(t *testing.T) {
+	texture := NewImageTexture(rowImage(red, blue)).WithFilter(FilterNearest)
+	for _, tc := range []struct {
+		wrap WrapMode
+		x    float64
+		want colors.Color
+	}{
+		{wrap: WrapRepeat, x: -0.25, want: colors.Blue},
+		{wrap: WrapRepeat, x: 1.25, want: colors.Red},
+		{wrap: WrapClamp, x: -0.25, want: colors.Red},
+		{wrap: WrapClamp, x: 1.25, want: colors.Blue},
+		{wrap: WrapMirror, x: -0.25, want: colors.Red},
+		{wrap: WrapMirror, x: 1.25, want: colors.Blue},
+		{wrap: WrapMirror, x: 2.25, want: colors.Red},
+	} {
+		got := texture.WithWrap(tc.wrap).GetTextureColor(tc.x, 0.5)
+		if diff := cmp.Diff(tc.want, got); diff != "" {
+			t.Errorf("color of wrap mode %d at %v mismatch (-want +got):\n%s", tc.wrap, tc.x, diff)
+		}
+	}
+}
+
+func TestImageTextureFilter(t *testing.T) {
+	stripes := NewImageTexture(rowImage(black, white, black, white))
+	for _, tc := range []struct {
+		name      string
+		texture   ImageTexture
+		x         float64
+		footprint geometry.Footprint
+		want      colors.Color
+	}{
+		{
+			name:    "bilinear between pixel centers",
+			texture: NewImageTexture(rowImage(red, blue)).WithFilter(FilterBilinear).WithWrap(WrapClamp),
+			x:       0.5,
+			want:    colors.Color{R: 0.5, B: 0.5},
+		},
+		{
+			name:    "full size without a footprint",
+			texture: stripes,
+			x:       0.125,
+			want:    colors.Black,
+		},
+		{
+			name:      "footprint of two pixels",
+			texture:   stripes,
+			x:         0.125,
+			footprint: geometry.Footprint{DUDX: 0.5},
+			want:      gray,
+		},
+		{
+			name:      "between mipmap levels",
+			texture:   stripes,
+			x:         0.125,
+			footprint: geometry.Footprint{DUDY: math.Pow(2, 0.6) / 4},
+			want:      colors.Color{R: 0.3, G: 0.3, B: 0.3},
+		},
+		{
+			name:      "nearest mipmap level",
+			texture:   stripes.WithFilter(FilterNearest),
+			x:         0.125,
+			footprint: geometry.Footprint{DUDY: math.Pow(2, 0.6) / 4},
+			want:      gray,
+		},
+		{
+			name:      "footprint larger than the image",
+			texture:   stripes,
+			x:         0.125,
+			footprint: geometry.Footprint{DUDX: 3, DVDY: 3},
+			want:      gray,
+		},
+		{
+			name:      "bright image averaged",
+			texture:   NewImageTexture(rowImage(white, white, white, white)),
+			x:         0.125,
+			footprint: geometry.Footprint{DUDX: 3, DVDY: 3},
+			want:      colors.White,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.texture.GetFilteredColor(tc.x, 0.5, tc.footprint)
+			if diff := cmp.Diff(tc.want, got, cmpopts.EquateApprox(0, 1e-9)); diff != "" {
+				t.Errorf("GetFilteredColor() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestImageSequence(t *testing.T) {
+	sequence := ImageSequence{
+		Frames: []ImageTexture{
+			NewImageTexture(rowImage(black)),
+			NewImageTexture(rowImage(red)),
+			NewImageTexture(rowImage(blue)),
+			NewImageTexture(rowImage(white)),
+		},
+		Loops: 2,
+	}
+	for _, tc := range []struct {
+		t    float64
+		want colors.Color
+	}{
+		{t: 0, want: colors.Black},
+		{t: 0.2, want: colors.Red},
+		{t: 0.4, want: colors.White},
+		{t: 0.6, want: colors.Black},
+		{t: 1, want: colors.Black},
+	} {
+		got := sequence.GetFrame(tc.t).GetTextureColor(0.5, 0.5)
+		if diff := cmp.Diff(tc.want, got); diff != "" {
+			t.Errorf("frame at %v mismatch (-want +got):\n%s", tc.t, diff)
+		}
+	}
+}
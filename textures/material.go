@@ -0,0 +1,33 @@
+package textures
+
+// Material describes how the surface of an object interacts with light, beyond its texture color.
+// The zero value is an opaque, matte surface.
+type Material struct {
+	Reflectivity float64 // fraction of light mirrored off the surface, in (0,1)
+	Transmission float64 // fraction of light passing through the surface, refracting according to IOR, in (0,1)
+	IOR          float64 // index of refraction of the object's interior, 1.0 for air, 1.5 for glass. 0 is treated as 1.0
+	Roughness    float64 // in (0,1), blurs reflections and refractions, and widens specular highlights
+}
+
+// Mirror is a perfectly reflective surface
+func Mirror() Material {
+	return Material{Reflectivity: 1}
+}
+
+// Glass is a clear, refractive surface, which also reflects depending on the viewing angle
+func Glass() Material {
+	return Material{Transmission: 1, IOR: 1.5}
+}
+
+// Diffuse returns the fraction of light that is neither reflected nor transmitted,
+// to be shaded with the surface color
+func (m Material) Diffuse() float64 {
+	return max(0, 1-m.Reflectivity-m.Transmission)
+}
+
+func (m Material) GetIOR() float64 {
+	if m.IOR == 0 {
+		return 1
+	}
+	return m.IOR
+}
@@ -2,6 +2,7 @@ package textures
 
 import (
 	"github.com/libeks/go-scene-renderer/colors"
+	"github.com/libeks/go-scene-renderer/geometry"
 	"github.com/libeks/go-scene-renderer/sampler"
 )
 
@@ -11,7 +12,8 @@ type Texture interface {
 }
 
 type TransparentTexture interface {
-	GetTextureColor(b, c float64) *colors.Color
+	// returns the color along with its opacity, colors.Transparent where there is a hole in the texture
+	GetTextureColor(b, c float64) colors.AlphaColor
 }
 
 type DynamicTexture interface {
@@ -36,12 +38,21 @@ type transparentTexture struct {
 	transparency Transparency
 }
 
-func (t transparentTexture) GetTextureColor(b, c float64) *colors.Color {
-	if !t.transparency.GetAlpha(b, c) {
-		return nil
+func (t transparentTexture) GetTextureColor(b, c float64) colors.AlphaColor {
+	alpha := t.transparency.GetAlpha(b, c)
+	if alpha <= 0 {
+		return colors.Transparent
 	}
-	color := t.texture.GetTextureColor(b, c)
-	return &color
+	return t.texture.GetTextureColor(b, c).WithAlpha(alpha)
+}
+
+// GetFilteredColor returns the texture's color averaged over the footprint, the transparency is not filtered
+func (t transparentTexture) GetFilteredColor(b, c float64, f geometry.Footprint) colors.AlphaColor {
+	alpha := t.transparency.GetAlpha(b, c)
+	if alpha <= 0 {
+		return colors.Transparent
+	}
+	return FilteredTextureColor(t.texture, b, c, f).WithAlpha(alpha)
 }
 
 type dynamicTransparentTexture struct {
@@ -66,9 +77,12 @@ type opaqueTexture struct {
 	texture Texture
 }
 
-func (t opaqueTexture) GetTextureColor(b, c float64) *colors.Color {
-	color := t.texture.GetTextureColor(b, c)
-	return &color
+func (t opaqueTexture) GetTextureColor(b, c float64) colors.AlphaColor {
+	return t.texture.GetTextureColor(b, c).WithAlpha(1)
+}
+
+func (t opaqueTexture) GetFilteredColor(b, c float64, f geometry.Footprint) colors.AlphaColor {
+	return FilteredTextureColor(t.texture, b, c, f).WithAlpha(1)
 }
 
 func OpaqueTexture(t Texture) TransparentTexture {
@@ -80,7 +94,7 @@ type dynamicOpaqueTexture struct {
 }
 
 func (t dynamicOpaqueTexture) GetFrame(tt float64) TransparentTexture {
-	return opaqueTexture{t.texture.GetFrame(tt)}
+	return OpaqueTexture(t.texture.GetFrame(tt))
 }
 
 func OpaqueDynamicTexture(t DynamicTexture) DynamicTransparentTexture {
@@ -121,6 +135,11 @@ func (t triangleGradientTexture) GetTextureColor(b, c float64) colors.Color {
 	return cColor
 }
 
+// gradients have no detail to filter
+func (t triangleGradientTexture) GetFilteredColor(b, c float64, f geometry.Footprint) colors.Color {
+	return t.GetTextureColor(b, c)
+}
+
 type TriangleGradientInterpolationTexture struct {
 	colors.Gradient
 	A float64
@@ -142,6 +161,11 @@ func (g TriangleGradientInterpolationTexture) GetTextureColor(b, c float64) colo
 	return cColor
 }
 
+// gradients have no detail to filter
+func (g TriangleGradientInterpolationTexture) GetFilteredColor(b, c float64, f geometry.Footprint) colors.Color {
+	return g.GetTextureColor(b, c)
+}
+
 func SquareGradientTexture(A, B, C, D colors.Color) Texture {
 	return squareGradientTexture{
 		triangleGradientTexture{
@@ -236,28 +260,9 @@ func (g squareGradientTexture) GetTextureColor(b, c float64) colors.Color {
 	return g.upper.GetTextureColor(1-b, 1-c)
 }
 
-type rotatedTexture struct {
-	t TransparentTexture
-}
-
-func (t rotatedTexture) GetTextureColor(b, c float64) *colors.Color {
-	return t.t.GetTextureColor(1-b, 1-c)
-}
-
-func RotateTexture180(texture TransparentTexture) TransparentTexture {
-	return rotatedTexture{texture}
-}
-
-type rotatedDynamicTexture struct {
-	t DynamicTransparentTexture
-}
-
-func (r rotatedDynamicTexture) GetFrame(t float64) TransparentTexture {
-	return RotateTexture180(r.t.GetFrame(t))
-}
-
-func RotateDynamicTexture180(texture DynamicTransparentTexture) DynamicTransparentTexture {
-	return rotatedDynamicTexture{texture}
+// gradients have no detail to filter
+func (g squareGradientTexture) GetFilteredColor(b, c float64, f geometry.Footprint) colors.Color {
+	return g.GetTextureColor(b, c)
 }
 
 type VerticalGradientTexture struct {
@@ -267,3 +272,8 @@ type VerticalGradientTexture struct {
 func (g VerticalGradientTexture) GetTextureColor(b, c float64) colors.Color {
 	return g.Gradient.Interpolate(c)
 }
+
+// gradients have no detail to filter
+func (g VerticalGradientTexture) GetFilteredColor(b, c float64, f geometry.Footprint) colors.Color {
+	return g.GetTextureColor(b, c)
+}
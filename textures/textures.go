@@ -4,6 +4,8 @@ import (
 	"math/rand"
 
 	"github.com/libeks/go-scene-renderer/colors"
+	"github.com/libeks/go-scene-renderer/geometry"
+	"github.com/libeks/go-scene-renderer/maths"
 )
 
 type HorizontalGradient struct {
@@ -14,6 +16,10 @@ func (d HorizontalGradient) GetTextureColor(x, y float64) colors.Color {
 	return d.Gradient.Interpolate(x)
 }
 
+func (d HorizontalGradient) GetFilteredColor(x, y float64, f geometry.Footprint) colors.Color {
+	return d.GetTextureColor(x, y)
+}
+
 type VerticalGradient struct {
 	colors.Gradient
 }
@@ -22,6 +28,10 @@ func (d VerticalGradient) GetTextureColor(x, y float64) colors.Color {
 	return d.Gradient.Interpolate(y)
 }
 
+func (d VerticalGradient) GetFilteredColor(x, y float64, f geometry.Footprint) colors.Color {
+	return d.GetTextureColor(x, y)
+}
+
 type Fuzzy struct {
 	Texture Texture
 	StdDev  float64
@@ -71,6 +81,10 @@ func (d uniform) GetTextureColor(x, y float64) colors.Color {
 	return d.Color
 }
 
+func (d uniform) GetFilteredColor(x, y float64, f geometry.Footprint) colors.Color {
+	return d.Color
+}
+
 func Random() Texture {
 	return random{}
 }
@@ -89,8 +103,8 @@ type MiddleBand struct {
 	Max float64
 }
 
-func (c MiddleBand) GetAlpha(x, y float64) bool {
-	return y > c.Min && y < c.Max
+func (c MiddleBand) GetAlpha(x, y float64) float64 {
+	return alpha(y > c.Min && y < c.Max)
 }
 
 type Checkerboard struct {
@@ -110,8 +124,21 @@ func (c Checkerboard) GetTextureColor(x, y float64) colors.Color {
 	return colors.White
 }
 
-func (c Checkerboard) GetAlpha(x, y float64) bool {
+// GetFilteredColor blends black and white by the share of black squares within the footprint
+func (c Checkerboard) GetFilteredColor(x, y float64, f geometry.Footprint) colors.Color {
+	if x < 0 || x > 1 || y < 0 || y > 1 {
+		return colors.Red
+	}
+	n := float64(c.Squares)
+	wx, wy := f.Extent()
+	// share of even columns and rows, a square is black if both or neither are even
+	evenX, evenY := maths.SquareWaveAverage(x*n, wx*n), maths.SquareWaveAverage(y*n, wy*n)
+	black := evenX*evenY + (1-evenX)*(1-evenY)
+	return colors.Black.Scale(black).Add(colors.White.Scale(1 - black))
+}
+
+func (c Checkerboard) GetAlpha(x, y float64) float64 {
 	r := 1 / float64(c.Squares)
 	xV, yV := int(x/r), int(y/r)
-	return (xV+yV)%2 == 0
+	return alpha((xV+yV)%2 == 0)
 }
@@ -3,8 +3,8 @@ package textures
 import "github.com/libeks/go-scene-renderer/sampler"
 
 type Transparency interface {
-	// true means the object is opaque, false means it is transparent
-	GetAlpha(b, c float64) bool
+	// returns the opacity, 1 means the object is opaque, 0 means it is transparent
+	GetAlpha(b, c float64) float64
 }
 
 type DynamicTransparency interface {
@@ -12,7 +12,7 @@ type DynamicTransparency interface {
 }
 
 type AnimatedTransparency interface {
-	GetAlpha(b, c, t float64) bool
+	GetAlpha(b, c, t float64) float64
 }
 
 // a helper for when a static texture is needed as a dynamic texture
@@ -29,15 +29,20 @@ func StaticTransparency(t Transparency) DynamicTransparency {
 }
 
 type constantTransparency struct {
-	val bool
+	val float64
 }
 
-func (tr constantTransparency) GetAlpha(b, c float64) bool {
+func (tr constantTransparency) GetAlpha(b, c float64) float64 {
 	return tr.val
 }
 
 func Opaque() Transparency {
-	return constantTransparency{val: true}
+	return constantTransparency{val: 1}
+}
+
+// ConstantTransparency has the same opacity alpha everywhere, from 0 (transparent) to 1 (opaque)
+func ConstantTransparency(alpha float64) Transparency {
+	return constantTransparency{val: alpha}
 }
 
 func DynamicFromAnimatedTransparency(ani AnimatedTransparency) DynamicTransparency {
@@ -62,23 +67,31 @@ type animatedStaticTransparencyHelper struct {
 	t   float64
 }
 
-func (tr animatedStaticTransparencyHelper) GetAlpha(b, c float64) bool {
+func (tr animatedStaticTransparencyHelper) GetAlpha(b, c float64) float64 {
 	return tr.ani.GetAlpha(b, c, tr.t)
 }
 
 type samplerTransparency struct {
 	sampler.Sampler
 	threshold float64
+	softness  float64
 }
 
-func (tr samplerTransparency) GetAlpha(b, c, t float64) bool {
-	return tr.Sampler.GetFrameValue(b, c, t) > tr.threshold
+func (tr samplerTransparency) GetAlpha(b, c, t float64) float64 {
+	v := tr.Sampler.GetFrameValue(b, c, t)
+	if tr.softness <= 0 {
+		return alpha(v > tr.threshold)
+	}
+	return max(0, min(1, (v-tr.threshold)/tr.softness+0.5))
 }
 
-func SamplerTransparency(s sampler.Sampler, threshold float64) AnimatedTransparency {
+// SamplerTransparency is opaque where the sampler is above threshold, and transparent below it.
+// Within softness of the threshold, the opacity ramps linearly, producing soft edges.
+func SamplerTransparency(s sampler.Sampler, threshold, softness float64) AnimatedTransparency {
 	return samplerTransparency{
 		Sampler:   s,
 		threshold: threshold,
+		softness:  softness,
 	}
 }
 
@@ -86,9 +99,9 @@ type CircleCutout struct {
 	Radius float64
 }
 
-func (tr CircleCutout) GetAlpha(b, c, t float64) bool {
+func (tr CircleCutout) GetAlpha(b, c, t float64) float64 {
 	b, c = 2*b-1, 2*c-1
-	return b*b+c*c > tr.Radius*tr.Radius
+	return alpha(b*b+c*c > tr.Radius*tr.Radius)
 }
 
 func InvertTransparency(ani AnimatedTransparency) AnimatedTransparency {
@@ -99,6 +112,14 @@ type invertedAnimatedTransparency struct {
 	AnimatedTransparency
 }
 
-func (tr invertedAnimatedTransparency) GetAlpha(b, c, t float64) bool {
-	return !tr.AnimatedTransparency.GetAlpha(b, c, t)
+func (tr invertedAnimatedTransparency) GetAlpha(b, c, t float64) float64 {
+	return 1 - tr.AnimatedTransparency.GetAlpha(b, c, t)
+}
+
+// alpha returns the opacity of a point that is either fully opaque or fully transparent
+func alpha(opaque bool) float64 {
+	if opaque {
+		return 1
+	}
+	return 0
 }
@@ -0,0 +1,37 @@
+package textures
+
+import (
+	"math"
+	"testing"
+
+	"github.com/libeks/go-scene-renderer/sampler"
+)
+
+func TestSamplerTransparency(t *testing.T) {
+	tests := []struct {
+		name      string
+		val       float64
+		softness  float64
+		wantAlpha float64
+	}{
+		{"hard above", 0.6, 0, 1},
+		{"hard below", 0.4, 0, 0},
+		{"soft at threshold", 0.5, 0.2, 0.5},
+		{"soft within edge", 0.55, 0.2, 0.75},
+		{"soft above edge", 0.7, 0.2, 1},
+		{"soft below edge", 0.3, 0.2, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := SamplerTransparency(sampler.Constant{Val: tt.val}, 0.5, tt.softness)
+			if alpha := tr.GetAlpha(0, 0, 0); math.Abs(alpha-tt.wantAlpha) > 1e-9 {
+				t.Errorf("wanted alpha %.3f, got %.3f", tt.wantAlpha, alpha)
+			}
+			inverted := InvertTransparency(tr)
+			if alpha := inverted.GetAlpha(0, 0, 0); math.Abs(alpha-(1-tt.wantAlpha)) > 1e-9 {
+				t.Errorf("wanted inverted alpha %.3f, got %.3f", 1-tt.wantAlpha, alpha)
+			}
+		})
+	}
+}